@@ -896,9 +896,27 @@ func (v *CreateExtensionCreateAddOnCreateAddOnPayload) GetAddOn() CreateExtensio
 
 // CreateExtensionCreateAddOnCreateAddOnPayloadAddOn includes the requested fields of the GraphQL type AddOn.
 type CreateExtensionCreateAddOnCreateAddOnPayloadAddOn struct {
+	Id string `json:"id"`
+	// Add-on options
+	Options interface{} `json:"options"`
+	// The add-on plan
+	AddOnPlan     CreateExtensionCreateAddOnCreateAddOnPayloadAddOnAddOnPlan `json:"addOnPlan"`
 	ExtensionData `json:"-"`
 }
 
+// GetId returns CreateExtensionCreateAddOnCreateAddOnPayloadAddOn.Id, and is useful for accessing the field via an interface.
+func (v *CreateExtensionCreateAddOnCreateAddOnPayloadAddOn) GetId() string { return v.Id }
+
+// GetOptions returns CreateExtensionCreateAddOnCreateAddOnPayloadAddOn.Options, and is useful for accessing the field via an interface.
+func (v *CreateExtensionCreateAddOnCreateAddOnPayloadAddOn) GetOptions() interface{} {
+	return v.Options
+}
+
+// GetAddOnPlan returns CreateExtensionCreateAddOnCreateAddOnPayloadAddOn.AddOnPlan, and is useful for accessing the field via an interface.
+func (v *CreateExtensionCreateAddOnCreateAddOnPayloadAddOn) GetAddOnPlan() CreateExtensionCreateAddOnCreateAddOnPayloadAddOnAddOnPlan {
+	return v.AddOnPlan
+}
+
 // GetName returns CreateExtensionCreateAddOnCreateAddOnPayloadAddOn.Name, and is useful for accessing the field via an interface.
 func (v *CreateExtensionCreateAddOnCreateAddOnPayloadAddOn) GetName() string {
 	return v.ExtensionData.Name
@@ -945,6 +963,12 @@ func (v *CreateExtensionCreateAddOnCreateAddOnPayloadAddOn) UnmarshalJSON(b []by
 }
 
 type __premarshalCreateExtensionCreateAddOnCreateAddOnPayloadAddOn struct {
+	Id string `json:"id"`
+
+	Options interface{} `json:"options"`
+
+	AddOnPlan CreateExtensionCreateAddOnCreateAddOnPayloadAddOnAddOnPlan `json:"addOnPlan"`
+
 	Name string `json:"name"`
 
 	SsoLink string `json:"ssoLink"`
@@ -965,6 +989,9 @@ func (v *CreateExtensionCreateAddOnCreateAddOnPayloadAddOn) MarshalJSON() ([]byt
 func (v *CreateExtensionCreateAddOnCreateAddOnPayloadAddOn) __premarshalJSON() (*__premarshalCreateExtensionCreateAddOnCreateAddOnPayloadAddOn, error) {
 	var retval __premarshalCreateExtensionCreateAddOnCreateAddOnPayloadAddOn
 
+	retval.Id = v.Id
+	retval.Options = v.Options
+	retval.AddOnPlan = v.AddOnPlan
 	retval.Name = v.ExtensionData.Name
 	retval.SsoLink = v.ExtensionData.SsoLink
 	retval.Environment = v.ExtensionData.Environment
@@ -972,6 +999,14 @@ func (v *CreateExtensionCreateAddOnCreateAddOnPayloadAddOn) __premarshalJSON() (
 	return &retval, nil
 }
 
+// CreateExtensionCreateAddOnCreateAddOnPayloadAddOnAddOnPlan includes the requested fields of the GraphQL type AddOnPlan.
+type CreateExtensionCreateAddOnCreateAddOnPayloadAddOnAddOnPlan struct {
+	Id string `json:"id"`
+}
+
+// GetId returns CreateExtensionCreateAddOnCreateAddOnPayloadAddOnAddOnPlan.Id, and is useful for accessing the field via an interface.
+func (v *CreateExtensionCreateAddOnCreateAddOnPayloadAddOnAddOnPlan) GetId() string { return v.Id }
+
 // CreateExtensionResponse is returned by CreateExtension on success.
 type CreateExtensionResponse struct {
 	CreateAddOn CreateExtensionCreateAddOnCreateAddOnPayload `json:"createAddOn"`
@@ -1220,6 +1255,8 @@ type GetAddOnAddOn struct {
 	Options interface{} `json:"options"`
 	// Add-on metadata
 	Metadata interface{} `json:"metadata"`
+	// Environment variables for the add-on
+	Environment interface{} `json:"environment"`
 	// Single sign-on link to the add-on dashboard
 	SsoLink string `json:"ssoLink"`
 	// Organization that owns this service
@@ -1256,6 +1293,9 @@ func (v *GetAddOnAddOn) GetOptions() interface{} { return v.Options }
 // GetMetadata returns GetAddOnAddOn.Metadata, and is useful for accessing the field via an interface.
 func (v *GetAddOnAddOn) GetMetadata() interface{} { return v.Metadata }
 
+// GetEnvironment returns GetAddOnAddOn.Environment, and is useful for accessing the field via an interface.
+func (v *GetAddOnAddOn) GetEnvironment() interface{} { return v.Environment }
+
 // GetSsoLink returns GetAddOnAddOn.SsoLink, and is useful for accessing the field via an interface.
 func (v *GetAddOnAddOn) GetSsoLink() string { return v.SsoLink }
 
@@ -1322,6 +1362,8 @@ type __premarshalGetAddOnAddOn struct {
 
 	Metadata interface{} `json:"metadata"`
 
+	Environment interface{} `json:"environment"`
+
 	SsoLink string `json:"ssoLink"`
 
 	Organization GetAddOnAddOnOrganization `json:"organization"`
@@ -1358,6 +1400,7 @@ func (v *GetAddOnAddOn) __premarshalJSON() (*__premarshalGetAddOnAddOn, error) {
 	retval.ReadRegions = v.ReadRegions
 	retval.Options = v.Options
 	retval.Metadata = v.Metadata
+	retval.Environment = v.Environment
 	retval.SsoLink = v.SsoLink
 	retval.Organization = v.Organization
 	retval.AddOnProvider = v.AddOnProvider
@@ -3392,6 +3435,11 @@ const CreateExtension_Operation = `
 mutation CreateExtension ($input: CreateAddOnInput!) {
 	createAddOn(input: $input) {
 		addOn {
+			id
+			options
+			addOnPlan {
+				id
+			}
 			... ExtensionData
 		}
 	}
@@ -3590,6 +3638,7 @@ query GetAddOn ($name: String, $provider: String) {
 		readRegions
 		options
 		metadata
+		environment
 		ssoLink
 		organization {
 			slug
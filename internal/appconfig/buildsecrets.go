@@ -0,0 +1,46 @@
+package appconfig
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// BuildSecret is a secret mounted into the Docker build, where it's read with
+// RUN --mount=type=secret,id=<id>, instead of being baked into a layer.
+// Its value comes from either a local environment variable or a local file.
+type BuildSecret struct {
+	ID string `toml:"id" json:"id"`
+	// Env names the local environment variable holding the secret.
+	Env string `toml:"env,omitempty" json:"env,omitempty"`
+	// Src is the path of the local file holding the secret.
+	Src string `toml:"src,omitempty" json:"src,omitempty"`
+}
+
+var buildSecretIDRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// checkBuildSecrets returns an error naming the first [[build.secrets]] entry without a valid id,
+// with an id that's already taken, or that doesn't take its value from exactly one of env and src.
+func (c *Config) checkBuildSecrets() error {
+	if c.Build == nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	for i, secret := range c.Build.Secrets {
+		name := fmt.Sprintf("[[build.secrets]] #%d", i+1)
+		if secret.ID != "" {
+			name = fmt.Sprintf("%s (id '%s')", name, secret.ID)
+		}
+		switch {
+		case secret.ID == "":
+			return fmt.Errorf("%s must set an id", name)
+		case !buildSecretIDRegex.MatchString(secret.ID):
+			return fmt.Errorf("%s has an invalid id, use only letters, digits, '_', '.' and '-'", name)
+		case seen[secret.ID]:
+			return fmt.Errorf("%s uses an id that's already taken", name)
+		case (secret.Env == "") == (secret.Src == ""):
+			return fmt.Errorf("%s must set exactly one of env and src", name)
+		}
+		seen[secret.ID] = true
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package appconfig
+
+import (
+	"fmt"
+	"strings"
+
+	fly "github.com/superfly/fly-go"
+)
+
+// checkCompute returns an error naming the first [[vm]] section whose size is unknown, whose cpus
+// contradict its size, or whose memory is outside of what machines of its size can have, so that the
+// machines API doesn't have to reject it at deploy time. Setting cpu_kind along with cpus replaces
+// the CPUs of the size rather than contradicting them, and a section whose CPUs differ from its
+// size's is only held to the most memory those CPUs can have.
+func (c *Config) checkCompute() error {
+	for i, compute := range c.Compute {
+		name := compute.name(i)
+
+		guest, err := compute.toMachineGuest("")
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if compute.Size == "" {
+			continue
+		}
+
+		preset := fly.MachinePresets[compute.Size]
+		if compute.MachineGuest != nil && compute.CPUKind == "" && compute.CPUs != 0 && compute.CPUs != preset.CPUs {
+			return fmt.Errorf("%s sets size '%s', which has %d CPUs, and cpus = %d; drop one of them",
+				name, compute.Size, preset.CPUs, compute.CPUs)
+		}
+
+		var minMemory, maxMemory int
+		switch guest.CPUKind {
+		case "shared":
+			minMemory, maxMemory = fly.MIN_MEMORY_MB_PER_SHARED_CPU, fly.MAX_MEMORY_MB_PER_SHARED_CPU
+		case "performance":
+			minMemory, maxMemory = fly.MIN_MEMORY_MB_PER_CPU, fly.MAX_MEMORY_MB_PER_CPU
+		default:
+			continue
+		}
+		minMemory *= guest.CPUs
+		maxMemory *= guest.CPUs
+		switch {
+		case guest.CPUKind != preset.CPUKind || guest.CPUs != preset.CPUs:
+			if guest.MemoryMB > maxMemory {
+				return fmt.Errorf("%s has %dMB of memory, but cpu_kind '%s' with cpus = %d allows at most %dMB",
+					name, guest.MemoryMB, guest.CPUKind, guest.CPUs, maxMemory)
+			}
+		case guest.MemoryMB < minMemory || guest.MemoryMB > maxMemory:
+			return fmt.Errorf("%s has %dMB of memory, but size '%s' allows between %dMB and %dMB",
+				name, guest.MemoryMB, compute.Size, minMemory, maxMemory)
+		}
+	}
+	return nil
+}
+
+// name identifies the compute section at index i of [[vm]] in messages.
+func (compute *Compute) name(i int) string {
+	name := fmt.Sprintf("[[vm]] #%d", i+1)
+	if len(compute.Processes) > 0 {
+		name = fmt.Sprintf("%s (processes %s)", name, strings.Join(compute.Processes, ", "))
+	}
+	return name
+}
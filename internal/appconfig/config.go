@@ -9,6 +9,7 @@ import (
 	"os"
 	"reflect"
 	"slices"
+	"strings"
 
 	fly "github.com/superfly/fly-go"
 )
@@ -16,6 +17,11 @@ import (
 const (
 	// DefaultConfigFileName denotes the default application configuration file name.
 	DefaultConfigFileName = "fly.toml"
+
+	// DefaultStaticsKeepVersions is the number of releases whose statics are kept by default.
+	DefaultStaticsKeepVersions = 3
+	// DefaultStaticsPrefix is the first part of the keys statics are pushed under.
+	DefaultStaticsPrefix = "fly-statics"
 )
 
 type RestartPolicy string
@@ -65,8 +71,9 @@ type Config struct {
 	Compute []*Compute `toml:"vm,omitempty" json:"vm,omitempty"`
 
 	// Others, less important.
-	Statics []Static   `toml:"statics,omitempty" json:"statics,omitempty"`
-	Metrics []*Metrics `toml:"metrics,omitempty" json:"metrics,omitempty"`
+	Statics        []Static        `toml:"statics,omitempty" json:"statics,omitempty"`
+	StaticsOptions *StaticsOptions `toml:"statics_options,omitempty" json:"statics_options,omitempty"`
+	Metrics        []*Metrics      `toml:"metrics,omitempty" json:"metrics,omitempty"`
 
 	// MergedFiles is a list of files that have been merged from the app config and flags.
 	MergedFiles []*fly.File `toml:"-" json:"-"`
@@ -74,11 +81,18 @@ type Config struct {
 	// Path to application configuration file, usually fly.toml.
 	configFilePath string
 
+	// Set when values were interpolated from the environment on load
+	interpolated bool
+
 	// Set when it fails to unmarshal fly.toml into Config
 	v2UnmarshalError error
 
 	// The default group name to refer to (used with flatten configs)
 	defaultGroupName string
+
+	// Top-level keys this version doesn't know about, likely added by a newer flyctl.
+	// They're kept as loaded so writing the config back doesn't drop them.
+	unknownFields map[string]any
 }
 
 type Metrics struct {
@@ -123,6 +137,29 @@ func (f File) toMachineFile() (*fly.File, error) {
 	return file, nil
 }
 
+// SecretRef is a reference to an app secret from the config.
+type SecretRef struct {
+	Name string
+	// GuestPath is the path of the file whose contents come from the secret.
+	GuestPath string
+	Processes []string
+}
+
+// SecretRefs returns every reference to an app secret made by the config.
+func (c *Config) SecretRefs() []SecretRef {
+	var refs []SecretRef
+	for _, f := range c.Files {
+		if f.SecretName != "" {
+			refs = append(refs, SecretRef{
+				Name:      f.SecretName,
+				GuestPath: f.GuestPath,
+				Processes: f.Processes,
+			})
+		}
+	}
+	return refs
+}
+
 type Static struct {
 	GuestPath     string `toml:"guest_path" json:"guest_path,omitempty" validate:"required"`
 	UrlPrefix     string `toml:"url_prefix" json:"url_prefix,omitempty" validate:"required"`
@@ -130,6 +167,56 @@ type Static struct {
 	IndexDocument string `toml:"index_document,omitempty" json:"index_document,omitempty"`
 }
 
+// StaticsOptions are app-wide settings for the statics pushed to Tigris on deploy.
+type StaticsOptions struct {
+	// KeepVersions is the number of releases whose statics are kept in the bucket.
+	KeepVersions *int `toml:"keep_versions,omitempty" json:"keep_versions,omitempty"`
+	// Compress uploads compressible assets gzipped, with a matching Content-Encoding.
+	Compress bool `toml:"compress,omitempty" json:"compress,omitempty"`
+	// MaxAge is the Cache-Control max-age of files not matched by any of CacheRules.
+	MaxAge *fly.Duration `toml:"max_age,omitempty" json:"max_age,omitempty"`
+	// CacheRules override MaxAge for the files matching their glob. The first matching rule wins.
+	CacheRules []StaticsCacheRule `toml:"cache_rules,omitempty" json:"cache_rules,omitempty"`
+	// Ignore lists .dockerignore-style patterns of files not to upload, in addition to
+	// those in a .flyignore file at the root of each static's directory.
+	Ignore []string `toml:"ignore,omitempty" json:"ignore,omitempty"`
+	// SkipHidden leaves out files and directories whose name starts with a dot, except .well-known.
+	SkipHidden bool `toml:"skip_hidden,omitempty" json:"skip_hidden,omitempty"`
+	// ContentTypes maps file extensions, like ".wasm", to the content type their files are
+	// uploaded with, taking precedence over the one flyctl would pick.
+	ContentTypes map[string]string `toml:"content_types,omitempty" json:"content_types,omitempty"`
+	// Region is where the Tigris bucket for statics is created, instead of the app's primary region.
+	// It has no effect once the bucket exists.
+	Region string `toml:"region,omitempty" json:"region,omitempty"`
+	// Prefix replaces fly-statics as the first part of the keys statics are pushed under,
+	// <prefix>/<app>/<version>/, e.g. to share a bucket between apps.
+	Prefix string `toml:"prefix,omitempty" json:"prefix,omitempty"`
+	// Storage pushes statics to the user's own S3-compatible object storage,
+	// instead of a Tigris bucket that flyctl creates for the app.
+	Storage *StaticsStorage `toml:"storage,omitempty" json:"storage,omitempty"`
+}
+
+// StaticsStorage is S3-compatible object storage that statics are pushed to.
+type StaticsStorage struct {
+	Endpoint string `toml:"endpoint" json:"endpoint"`
+	Bucket   string `toml:"bucket" json:"bucket"`
+	Region   string `toml:"region,omitempty" json:"region,omitempty"`
+	// PathStyle addresses the bucket as a path on the endpoint rather than as a subdomain of it.
+	PathStyle bool `toml:"path_style,omitempty" json:"path_style,omitempty"`
+	// AccessKeyIDEnv and SecretAccessKeyEnv name the local environment variables holding the storage's
+	// credentials, so they stay out of fly.toml. They default to AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+	AccessKeyIDEnv     string `toml:"access_key_id_env,omitempty" json:"access_key_id_env,omitempty"`
+	SecretAccessKeyEnv string `toml:"secret_access_key_env,omitempty" json:"secret_access_key_env,omitempty"`
+}
+
+// StaticsCacheRule sets the Cache-Control max-age of the statics matching Glob.
+type StaticsCacheRule struct {
+	// Glob is matched against file paths relative to the static's guest path, e.g. "assets/*".
+	// "**" matches any number of directories, so "**/*.html" matches HTML files anywhere.
+	Glob   string        `toml:"glob" json:"glob"`
+	MaxAge *fly.Duration `toml:"max_age" json:"max_age"`
+}
+
 type Mount struct {
 	Source                  string   `toml:"source,omitempty" json:"source,omitempty"`
 	Destination             string   `toml:"destination,omitempty" json:"destination,omitempty"`
@@ -151,6 +238,8 @@ type Build struct {
 	Dockerfile        string            `toml:"dockerfile,omitempty" json:"dockerfile,omitempty"`
 	Ignorefile        string            `toml:"ignorefile,omitempty" json:"ignorefile,omitempty"`
 	DockerBuildTarget string            `toml:"build-target,omitempty" json:"build-target,omitempty"`
+	// Secrets are mounted into the Docker build, see BuildSecret.
+	Secrets []BuildSecret `toml:"secrets,omitempty" json:"secrets,omitempty"`
 }
 
 type Experimental struct {
@@ -188,6 +277,21 @@ func (c *Config) ConfigFilePath() string {
 	return c.configFilePath
 }
 
+// Equal reports whether c and other hold the same settings. Where they were loaded from,
+// and the other bookkeeping that isn't part of the config itself, is ignored.
+func (c *Config) Equal(other *Config) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+	a, b := *c, *other
+	for _, cfg := range []*Config{&a, &b} {
+		cfg.configFilePath = ""
+		cfg.defaultGroupName = ""
+		cfg.v2UnmarshalError = nil
+	}
+	return reflect.DeepEqual(a, b)
+}
+
 func (c *Config) SetConfigFilePath(configFilePath string) {
 	c.configFilePath = configFilePath
 }
@@ -239,6 +343,76 @@ func (c *Config) HasUdpService() bool {
 	return false
 }
 
+// RootStatics returns the statics mapped at the root URL prefix when the app
+// also declares services. Those statics capture every request, shadowing the
+// routes served by the app's machines.
+func (c *Config) RootStatics() []Static {
+	if c.HTTPService == nil && len(c.Services) == 0 {
+		return nil
+	}
+	var statics []Static
+	for _, static := range c.Statics {
+		if strings.Trim(static.UrlPrefix, "/") == "" {
+			statics = append(statics, static)
+		}
+	}
+	return statics
+}
+
+// StaticsKeepVersions returns the number of releases whose statics are kept in the bucket.
+func (c *Config) StaticsKeepVersions() int {
+	if c == nil || c.StaticsOptions == nil || c.StaticsOptions.KeepVersions == nil {
+		return DefaultStaticsKeepVersions
+	}
+	return *c.StaticsOptions.KeepVersions
+}
+
+// StaticsStorage returns the user's object storage for statics, or nil to use a Tigris bucket managed by flyctl.
+func (c *Config) StaticsStorage() *StaticsStorage {
+	if c == nil || c.StaticsOptions == nil {
+		return nil
+	}
+	return c.StaticsOptions.Storage
+}
+
+// StaticsCompress reports whether compressible statics are uploaded pre-compressed.
+func (c *Config) StaticsCompress() bool {
+	return c != nil && c.StaticsOptions != nil && c.StaticsOptions.Compress
+}
+
+// StaticsRegion returns the region the Tigris bucket for statics is created in.
+func (c *Config) StaticsRegion() string {
+	if c == nil {
+		return ""
+	}
+	if c.StaticsOptions != nil && c.StaticsOptions.Region != "" {
+		return c.StaticsOptions.Region
+	}
+	return c.PrimaryRegion
+}
+
+// StaticsPrefix returns the prefix the statics of every app version are pushed under, without slashes around it.
+func (c *Config) StaticsPrefix() string {
+	if c != nil && c.StaticsOptions != nil {
+		if prefix := strings.Trim(c.StaticsOptions.Prefix, "/"); prefix != "" {
+			return prefix
+		}
+	}
+	return DefaultStaticsPrefix
+}
+
+// StaticsContentTypes returns the content type overrides for statics, keyed by lowercase extension.
+func (c *Config) StaticsContentTypes() map[string]string {
+	if c == nil || c.StaticsOptions == nil || len(c.StaticsOptions.ContentTypes) == 0 {
+		return nil
+	}
+	contentTypes := make(map[string]string, len(c.StaticsOptions.ContentTypes))
+	for ext, contentType := range c.StaticsOptions.ContentTypes {
+		contentTypes[strings.ToLower(ext)] = contentType
+	}
+	return contentTypes
+}
+
 func (c *Config) Dockerfile() string {
 	if c == nil || c.Build == nil {
 		return ""
@@ -1,9 +1,11 @@
 package appconfig
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	fly "github.com/superfly/fly-go"
 	"github.com/superfly/flyctl/helpers"
 )
@@ -16,7 +18,7 @@ func TestGetAndSetEnvVariables(t *testing.T) {
 
 	bytes, err := cfg.marshalTOML()
 	assert.NoError(t, err)
-	cfg2, err := unmarshalTOML(bytes)
+	cfg2, err := unmarshalTOML(bytes, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, cfg.Env, cfg2.Env)
 }
@@ -153,6 +155,54 @@ func TestCloneAppconfig(t *testing.T) {
 		"expected deep copy, but cloned object was modified by change to original config")
 }
 
+func TestConfigEqual(t *testing.T) {
+	newConfig := func() *Config {
+		cfg := NewConfig()
+		cfg.AppName = "testcfg"
+		cfg.KillTimeout = fly.MustParseDuration("5s")
+		cfg.Env = map[string]string{"FOO": "BAR"}
+		cfg.HTTPService = &HTTPService{InternalPort: 8080, ForceHTTPS: true}
+		cfg.Statics = []Static{{GuestPath: "public", UrlPrefix: "/"}}
+		return cfg
+	}
+
+	a, b := newConfig(), newConfig()
+	// Bookkeeping isn't part of the config
+	b.configFilePath = "other/fly.toml"
+	b.defaultGroupName = "web"
+	b.v2UnmarshalError = errors.New("boom")
+	assert.True(t, a.Equal(b))
+	assert.True(t, b.Equal(a))
+	assert.Equal(t, "other/fly.toml", b.configFilePath, "Equal must not change the configs it compares")
+
+	var none *Config
+	assert.True(t, none.Equal(nil))
+	assert.False(t, a.Equal(nil))
+	assert.False(t, none.Equal(a))
+}
+
+func TestConfigEqualFieldsDiffer(t *testing.T) {
+	changes := map[string]func(*Config){
+		"app":             func(c *Config) { c.AppName = "other" },
+		"kill_timeout":    func(c *Config) { c.KillTimeout = fly.MustParseDuration("6s") },
+		"env":             func(c *Config) { c.Env["FOO"] = "BAZ" },
+		"http_service":    func(c *Config) { c.HTTPService.ForceHTTPS = false },
+		"statics":         func(c *Config) { c.Statics = append(c.Statics, Static{GuestPath: "docs", UrlPrefix: "/docs"}) },
+		"unknown setting": func(c *Config) { c.unknownFields = map[string]any{"future": true} },
+	}
+	newConfig := func() *Config {
+		return &Config{AppName: "testcfg", Env: map[string]string{"FOO": "BAR"}, HTTPService: &HTTPService{InternalPort: 8080, ForceHTTPS: true}}
+	}
+	for name, change := range changes {
+		a, b := newConfig(), newConfig()
+		require.True(t, a.Equal(b), name)
+
+		change(b)
+		assert.False(t, a.Equal(b), name)
+		assert.False(t, b.Equal(a), name)
+	}
+}
+
 func TestDetermineIPType(t *testing.T) {
 	port80 := 80
 	port443 := 443
@@ -278,3 +328,39 @@ func TestURL(t *testing.T) {
 	}}
 	assert.Nil(t, cfg.URL())
 }
+
+func TestRootStatics(t *testing.T) {
+	statics := []Static{
+		{GuestPath: "/app/public", UrlPrefix: "/"},
+		{GuestPath: "/app/assets", UrlPrefix: "/assets"},
+	}
+
+	// Root statics shadow the app's services
+	cfg := NewConfig()
+	cfg.HTTPService = &HTTPService{InternalPort: 8080}
+	cfg.Statics = statics
+	assert.Equal(t, []Static{statics[0]}, cfg.RootStatics())
+
+	cfg = NewConfig()
+	cfg.Services = []Service{{InternalPort: 8080}}
+	cfg.Statics = []Static{{GuestPath: "/app/public", UrlPrefix: ""}}
+	assert.Len(t, cfg.RootStatics(), 1)
+
+	// Static-only apps are free to serve from the root
+	cfg = NewConfig()
+	cfg.Statics = statics
+	assert.Empty(t, cfg.RootStatics())
+}
+
+func TestSecretRefs(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Files = []File{
+		{GuestPath: "/etc/app.conf", LocalPath: "app.conf"},
+		{GuestPath: "/etc/tls/key.pem", SecretName: "TLS_KEY", Processes: []string{"web"}},
+		{GuestPath: "/etc/db.conf", SecretName: "DB_CONFIG"},
+	}
+	assert.Equal(t, []SecretRef{
+		{Name: "TLS_KEY", GuestPath: "/etc/tls/key.pem", Processes: []string{"web"}},
+		{Name: "DB_CONFIG", GuestPath: "/etc/db.conf"},
+	}, cfg.SecretRefs())
+}
@@ -10,6 +10,7 @@ const (
 	_ contextKeyType = iota
 	configContextKey
 	nameContextKey
+	remoteCacheContextKey
 )
 
 // WithConfig derives a context that carries cfg from ctx.
@@ -31,6 +32,12 @@ func WithName(ctx context.Context, name string) context.Context {
 	return context.WithValue(ctx, nameContextKey, name)
 }
 
+// WithRemoteConfigCache derives a context from ctx in which FromRemoteApp fetches the config of
+// each app once, and returns a copy of it on later calls. Commands get one for their whole run.
+func WithRemoteConfigCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, remoteCacheContextKey, &remoteConfigCache{configs: map[string]*Config{}})
+}
+
 // NameFromContext returns the app name ctx carries or an empty string.
 func NameFromContext(ctx context.Context) string {
 	if name, ok := ctx.Value(nameContextKey).(string); ok {
@@ -24,5 +24,6 @@ func FromDefinition(definition *fly.Definition) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	return unmarshalTOML(buf)
+	// Remote configs are never interpolated with the local environment.
+	return unmarshalTOML(buf, nil)
 }
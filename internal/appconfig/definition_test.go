@@ -176,6 +176,10 @@ func TestToDefinition(t *testing.T) {
 				"param1": "value1",
 				"param2": "value2",
 			},
+			"secrets": []any{
+				map[string]any{"id": "npmrc", "src": ".npmrc"},
+				map[string]any{"id": "registry_token", "env": "REGISTRY_TOKEN"},
+			},
 		},
 
 		"restart": []any{
@@ -285,6 +289,31 @@ func TestToDefinition(t *testing.T) {
 				"index_document": "index.html",
 			},
 		},
+		"statics_options": map[string]any{
+			"keep_versions": int64(5),
+			"compress":      true,
+			"max_age":       "1h0m0s",
+			"cache_rules": []any{
+				map[string]any{"glob": "assets/*", "max_age": "8760h0m0s"},
+				map[string]any{"glob": "**/*.html", "max_age": "0s"},
+			},
+			"ignore":      []any{"*.map", ".DS_Store"},
+			"skip_hidden": true,
+			"region":      "ams",
+			"prefix":      "cdn/statics",
+			"content_types": map[string]any{
+				".wasm":        "application/wasm",
+				".webmanifest": "application/manifest+json",
+			},
+			"storage": map[string]any{
+				"endpoint":              "https://s3.us-east-1.amazonaws.com",
+				"bucket":                "my-statics",
+				"region":                "us-east-1",
+				"path_style":            true,
+				"access_key_id_env":     "STATICS_ACCESS_KEY_ID",
+				"secret_access_key_env": "STATICS_SECRET_ACCESS_KEY",
+			},
+		},
 		"files": []any{
 			map[string]any{
 				"guest_path": "/path/to/hello.txt",
@@ -0,0 +1,130 @@
+package appconfig
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// interpolationPattern matches `$$`, `${VAR}` and `${VAR:-default}`.
+var interpolationPattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolateEnv substitutes environment variables into build.image, the values of build.args and the values
+// of [env] of a config loaded from a file, before the compatibility patches run. Other values are left alone,
+// since they often hold shell syntax meant for the machine, like the commands of processes.
+// `${VAR}` is replaced by the value of VAR, which must be set. `${VAR:-default}` falls back to default
+// when VAR is unset or empty, and `$$` is a literal `$`. Any other `$` is left alone.
+// Nothing is interpolated if lookupEnv is nil. It reports whether any of these sequences were found.
+func interpolateEnv(cfgMap map[string]any, lookupEnv func(string) (string, bool)) (found bool, _ error) {
+	if lookupEnv == nil {
+		return false, nil
+	}
+
+	interpolate := func(m map[string]any, key string) error {
+		s, ok := m[key].(string)
+		if !ok || !interpolationPattern.MatchString(s) {
+			return nil
+		}
+		found = true
+		interpolated, err := interpolateString(s, lookupEnv)
+		if err != nil {
+			return err
+		}
+		m[key] = interpolated
+		return nil
+	}
+
+	if build, ok := cfgMap["build"].(map[string]any); ok {
+		if err := interpolate(build, "image"); err != nil {
+			return false, err
+		}
+		if args, ok := build["args"].(map[string]any); ok {
+			for k := range args {
+				if err := interpolate(args, k); err != nil {
+					return false, err
+				}
+			}
+		}
+	}
+	if env, ok := cfgMap["env"].(map[string]any); ok {
+		for k := range env {
+			if err := interpolate(env, k); err != nil {
+				return false, err
+			}
+		}
+	}
+	return found, nil
+}
+
+func interpolateString(s string, lookupEnv func(string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var err error
+	out := interpolationPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$$" {
+			return "$"
+		}
+		groups := interpolationPattern.FindStringSubmatch(match)
+		name, hasDefault, def := groups[1], groups[2] != "", groups[3]
+
+		value, ok := lookupEnv(name)
+		switch {
+		case ok && value != "":
+			return value
+		case hasDefault:
+			return def
+		case ok:
+			return ""
+		}
+		if err == nil {
+			err = fmt.Errorf("config references the environment variable %s, which isn't set; set it, give a default with ${%s:-default}, or escape the $ as $$", name, name)
+		}
+		return match
+	})
+	if err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// escapeInterpolation returns a copy of the config whose interpolated values are escaped,
+// so that loading it again from a file yields the same values.
+func (c *Config) escapeInterpolation() *Config {
+	escaped := *c
+	if c.Build != nil {
+		build := *c.Build
+		build.Image = escapeInterpolationString(build.Image)
+		build.Args = escapeInterpolationMap(build.Args)
+		escaped.Build = &build
+	}
+	escaped.Env = escapeInterpolationMap(c.Env)
+	return &escaped
+}
+
+func escapeInterpolationMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	escaped := make(map[string]string, len(m))
+	for k, v := range m {
+		escaped[k] = escapeInterpolationString(v)
+	}
+	return escaped
+}
+
+// escapeInterpolationString doubles every `$` that would start a `$$` or `${` sequence.
+func escapeInterpolationString(s string) string {
+	if !strings.Contains(s, "$") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		b.WriteByte(s[i])
+		if s[i] == '$' && i+1 < len(s) && (s[i+1] == '$' || s[i+1] == '{') {
+			b.WriteByte('$')
+		}
+	}
+	return b.String()
+}
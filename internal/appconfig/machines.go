@@ -304,15 +304,7 @@ func (c *Config) updateMachineConfig(src *fly.MachineConfig) (*fly.MachineConfig
 	}
 
 	// Statics
-	mConfig.Statics = nil
-	for _, s := range c.Statics {
-		mConfig.Statics = append(mConfig.Statics, &fly.Static{
-			GuestPath:     s.GuestPath,
-			UrlPrefix:     s.UrlPrefix,
-			TigrisBucket:  s.TigrisBucket,
-			IndexDocument: s.IndexDocument,
-		})
-	}
+	mConfig.Statics = c.MachineStatics()
 
 	// Mounts
 	mConfig.Mounts = nil
@@ -406,8 +398,12 @@ func (c *Config) toMachineGuest() (*fly.MachineGuest, error) {
 	}
 
 	// At most one compute after group flattening
-	compute := c.Compute[0]
+	return c.Compute[0].toMachineGuest(c.HostDedicationID)
+}
 
+// toMachineGuest builds the guest the compute section describes: its size, or the default one,
+// with the memory and guest fields it sets on top.
+func (compute *Compute) toMachineGuest(hostDedicationID string) (*fly.MachineGuest, error) {
 	size := fly.DefaultVMSize
 	switch {
 	case compute.Size != "":
@@ -421,8 +417,8 @@ func (c *Config) toMachineGuest() (*fly.MachineGuest, error) {
 		return nil, err
 	}
 
-	if c.HostDedicationID != "" {
-		guest.HostDedicationID = c.HostDedicationID
+	if hostDedicationID != "" {
+		guest.HostDedicationID = hostDedicationID
 	}
 
 	if compute.Memory != "" {
@@ -447,3 +443,17 @@ func (c *Config) toMachineGuest() (*fly.MachineGuest, error) {
 
 	return guest, nil
 }
+
+// MachineStatics returns the app's statics as they're set in machine configs.
+func (c *Config) MachineStatics() []*fly.Static {
+	var statics []*fly.Static
+	for _, s := range c.Statics {
+		statics = append(statics, &fly.Static{
+			GuestPath:     s.GuestPath,
+			UrlPrefix:     s.UrlPrefix,
+			TigrisBucket:  s.TigrisBucket,
+			IndexDocument: s.IndexDocument,
+		})
+	}
+	return statics
+}
@@ -0,0 +1,32 @@
+package appconfig
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// mountSizeRegex matches the sizes a mount accepts: a bare number of GB, or a number
+// with a unit like 500mb, 30gb or 1tib.
+var mountSizeRegex = regexp.MustCompile(`(?i)^\d+(\.\d+)?([kmgtp]i?b?)?$`)
+
+// checkMounts returns an error naming the first [[mounts]] entry without a source, without an
+// absolute destination, or with a malformed initial_size, so that typos like "30 gb" are caught
+// when the config is loaded rather than when the volume is created.
+func (c *Config) checkMounts() error {
+	for i, m := range c.Mounts {
+		name := fmt.Sprintf("[[mounts]] #%d", i+1)
+		if m.Source != "" {
+			name = fmt.Sprintf("%s (source '%s')", name, m.Source)
+		}
+		switch {
+		case m.Source == "":
+			return fmt.Errorf("%s must set a source", name)
+		case !strings.HasPrefix(m.Destination, "/"):
+			return fmt.Errorf("%s must set an absolute destination, got '%s'", name, m.Destination)
+		case m.InitialSize != "" && !mountSizeRegex.MatchString(m.InitialSize):
+			return fmt.Errorf("%s has an invalid initial_size '%s', use a number of GB or a size like 500mb or 30gb", name, m.InitialSize)
+		}
+	}
+	return nil
+}
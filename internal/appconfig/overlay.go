@@ -0,0 +1,71 @@
+package appconfig
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OverlayEnvKey names the environment variable that selects the overlay applied on top of the app config,
+// e.g. FLY_CONFIG_ENV=production merges fly.production.toml into fly.toml.
+const OverlayEnvKey = "FLY_CONFIG_ENV"
+
+// OverlayConfigPath returns the path of the overlay for environment next to the config at path,
+// fly.production.toml for fly.toml and production.
+func OverlayConfigPath(path, environment string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + environment + ext
+}
+
+// LoadConfigWithOverlay loads the app config at path with the overlay for environment merged into it,
+// see mergeConfigMaps. The overlay must exist. Without an environment it's the same as LoadConfig.
+func LoadConfigWithOverlay(path, environment string) (*Config, error) {
+	if environment == "" {
+		return LoadConfig(path)
+	}
+
+	base, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	overlayPath := OverlayConfigPath(path, environment)
+	overlay, err := os.ReadFile(overlayPath)
+	if err != nil {
+		// Don't let a missing overlay pass for a missing config.
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("no config overlay for environment '%s', expected %s", environment, overlayPath)
+		}
+		return nil, err
+	}
+
+	return loadConfig(path, func() (map[string]any, error) {
+		baseMap, err := decodeConfigMap(base, configFormat(path))
+		if err != nil {
+			return nil, err
+		}
+		overlayMap, err := decodeConfigMap(overlay, configFormat(overlayPath))
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing %s: %w", overlayPath, err)
+		}
+		return mergeConfigMaps(baseMap, overlayMap), nil
+	})
+}
+
+// mergeConfigMaps merges the raw overlay config into base before any patches apply, so the result
+// is the same as a hand-written file combining both. Tables are merged key by key, while scalars and
+// arrays, including arrays of tables like [[services]], replace the base value wholesale.
+func mergeConfigMaps(base, overlay map[string]any) map[string]any {
+	for key, value := range overlay {
+		baseTable, baseIsTable := base[key].(map[string]any)
+		overlayTable, overlayIsTable := value.(map[string]any)
+		if baseIsTable && overlayIsTable {
+			base[key] = mergeConfigMaps(baseTable, overlayTable)
+		} else {
+			base[key] = value
+		}
+	}
+	return base
+}
@@ -0,0 +1,47 @@
+package appconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverlayConfigPath(t *testing.T) {
+	assert.Equal(t, "fly.production.toml", OverlayConfigPath("fly.toml", "production"))
+	assert.Equal(t, "/app/fly.staging.json", OverlayConfigPath("/app/fly.json", "staging"))
+}
+
+func TestLoadConfigWithOverlay(t *testing.T) {
+	cfg, err := LoadConfigWithOverlay("./testdata/overlay.toml", "production")
+	require.NoError(t, err)
+
+	assert.Equal(t, "foo", cfg.AppName)
+	assert.Equal(t, "ams", cfg.PrimaryRegion)
+	// Maps merge key by key.
+	assert.Equal(t, map[string]string{
+		"LOG_LEVEL":  "warn",
+		"PORT":       "8080",
+		"SENTRY_ENV": "production",
+	}, cfg.Env)
+	assert.Equal(t, 8080, cfg.HTTPService.InternalPort)
+	assert.False(t, cfg.HTTPService.ForceHTTPS)
+	// Arrays are replaced wholesale.
+	require.Len(t, cfg.Services, 1)
+	assert.Equal(t, 9100, cfg.Services[0].InternalPort)
+
+	combined, err := LoadConfig("./testdata/overlay-combined.toml")
+	require.NoError(t, err)
+	combined.configFilePath = cfg.configFilePath
+	assert.Equal(t, combined, cfg)
+}
+
+func TestLoadConfigWithoutOverlay(t *testing.T) {
+	cfg, err := LoadConfigWithOverlay("./testdata/overlay.toml", "")
+	require.NoError(t, err)
+	assert.Equal(t, "ord", cfg.PrimaryRegion)
+	assert.Len(t, cfg.Services, 2)
+
+	_, err = LoadConfigWithOverlay("./testdata/overlay.toml", "staging")
+	require.ErrorContains(t, err, "no config overlay for environment 'staging', expected ./testdata/overlay.staging.toml")
+}
@@ -3,6 +3,8 @@ package appconfig
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -28,7 +30,12 @@ func applyPatches(cfgMap map[string]any) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	return mapToConfig(cfgMap)
+	cfg, err := mapToConfig(cfgMap)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.unknownFields = unknownConfigFields(cfgMap)
+	return cfg, nil
 }
 
 func mapToConfig(cfgMap map[string]any) (*Config, error) {
@@ -72,6 +79,53 @@ func patchEnv(cfg map[string]any) (map[string]any, error) {
 	return cfg, nil
 }
 
+// envFileKey marks an env value read from a file, as in FOO = { from_file = "./foo.txt" }.
+const envFileKey = "from_file"
+
+// resolveEnvFiles replaces env values that reference a file with the file's contents, verbatim.
+// Relative paths are relative to dir, the directory of the config file.
+func resolveEnvFiles(cfg map[string]any, dir string) error {
+	var resolve func(raw any) error
+	resolve = func(raw any) error {
+		switch cast := raw.(type) {
+		case []map[string]any:
+			for _, raw2 := range cast {
+				if err := resolve(raw2); err != nil {
+					return err
+				}
+			}
+		case []any:
+			for _, raw2 := range cast {
+				if err := resolve(raw2); err != nil {
+					return err
+				}
+			}
+		case map[string]any:
+			for k, v := range cast {
+				ref, ok := v.(map[string]any)
+				if !ok {
+					continue
+				}
+				path, ok := ref[envFileKey].(string)
+				if !ok || len(ref) != 1 {
+					return fmt.Errorf("env %s must be a string or { %s = \"path\" }", k, envFileKey)
+				}
+				if !filepath.IsAbs(path) {
+					path = filepath.Join(dir, path)
+				}
+				contents, err := os.ReadFile(path)
+				if err != nil {
+					// Not wrapped, a missing file mustn't pass for a missing config.
+					return fmt.Errorf("can't read env %s from file: %v", k, err)
+				}
+				cast[k] = string(contents)
+			}
+		}
+		return nil
+	}
+	return resolve(cfg["env"])
+}
+
 func _patchEnv(raw any) (map[string]string, error) {
 	env := map[string]string{}
 
@@ -102,6 +156,8 @@ func _patchEnv(raw any) (map[string]string, error) {
 		for k, v := range cast {
 			if stringVal, ok := v.(string); ok {
 				env[k] = stringVal
+			} else if _, ok := v.(map[string]any); ok {
+				return nil, fmt.Errorf("env %s can only be read from a file in a config loaded from disk", k)
 			} else {
 				env[k] = fmt.Sprintf("%v", v)
 			}
@@ -272,6 +328,7 @@ func patchMetrics(cfg map[string]any) (map[string]any, error) {
 			if err != nil {
 				return nil, fmt.Errorf("Error processing mounts: %w", err)
 			}
+			delete(cfg, k)
 			metrics = append(metrics, cast...)
 		}
 	}
@@ -550,6 +607,14 @@ func _castDuration(v any, shift time.Duration) (ret *string) {
 		}
 		str := d.String()
 		return &str
+	case int:
+		// YAML decodes integers as int
+		d := time.Duration(cast)
+		if shift > 0 {
+			d = d * shift
+		}
+		str := d.String()
+		return &str
 	}
 	return nil
 }
@@ -0,0 +1,156 @@
+package appconfig
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+	fly "github.com/superfly/fly-go"
+)
+
+// servicePortBlock is a service's external ports, along with what to call it in errors.
+type servicePortBlock struct {
+	name      string
+	protocol  string
+	processes []string
+	ranges    [][2]int
+}
+
+// checkServicePortConflicts returns an error if two services expose the same external port,
+// either directly or through overlapping start_port/end_port ranges. Services only conflict
+// when they use the same protocol and run in at least one common process group.
+func (c *Config) checkServicePortConflicts() error {
+	var blocks []servicePortBlock
+	if c.HTTPService != nil {
+		blocks = append(blocks, c.servicePortBlock("[http_service]", *c.HTTPService.ToService()))
+	}
+	for i, service := range c.Services {
+		name := fmt.Sprintf("[[services]] #%d (internal_port %d)", i+1, service.InternalPort)
+		blocks = append(blocks, c.servicePortBlock(name, service))
+	}
+
+	for i, a := range blocks {
+		for _, b := range blocks[i+1:] {
+			if a.protocol != b.protocol || len(lo.Intersect(a.processes, b.processes)) == 0 {
+				continue
+			}
+			for _, ra := range a.ranges {
+				for _, rb := range b.ranges {
+					if ra[0] <= rb[1] && rb[0] <= ra[1] {
+						return fmt.Errorf("%s and %s both expose %s port %s", a.name, b.name, a.protocol, formatPortOverlap(ra, rb))
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Config) servicePortBlock(name string, service Service) servicePortBlock {
+	block := servicePortBlock{
+		name:      name,
+		protocol:  strings.ToLower(service.Protocol),
+		processes: service.Processes,
+	}
+	if block.protocol == "" {
+		block.protocol = "tcp"
+	}
+	if len(block.processes) == 0 {
+		block.processes = []string{c.DefaultProcessName()}
+	}
+	for _, port := range service.Ports {
+		if port.Port != nil {
+			block.ranges = append(block.ranges, [2]int{*port.Port, *port.Port})
+		}
+		if port.StartPort != nil || port.EndPort != nil {
+			// Same bounds as fly.MachinePort.ContainsPort
+			start, end := 0, 65535
+			if port.StartPort != nil {
+				start = *port.StartPort
+			}
+			if port.EndPort != nil {
+				end = *port.EndPort
+			}
+			block.ranges = append(block.ranges, [2]int{start, end})
+		}
+	}
+	return block
+}
+
+// formatPortOverlap describes the ports shared by two ranges.
+func formatPortOverlap(a, b [2]int) string {
+	start, end := max(a[0], b[0]), min(a[1], b[1])
+	if start == end {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d-%d", start, end)
+}
+
+// portOptionHandlers are the handlers that use each block of port options.
+var portOptionHandlers = []struct {
+	option   string
+	handlers []string
+	set      func(port fly.MachinePort) bool
+}{
+	{"tls_options", []string{"tls", "https"}, func(port fly.MachinePort) bool { return port.TLSOptions != nil }},
+	{"http_options", []string{"http", "https"}, func(port fly.MachinePort) bool { return port.HTTPOptions != nil }},
+	{"proxy_proto_options", []string{"proxy_proto"}, func(port fly.MachinePort) bool { return port.ProxyProtoOptions != nil }},
+}
+
+// checkPortHandlers returns an error if a service port uses a handler fly-proxy doesn't know,
+// which would otherwise be rejected at deploy. [http_service] isn't checked, as its ports and
+// their handlers are derived from it.
+func (c *Config) checkPortHandlers() error {
+	for i, service := range c.Services {
+		for j, port := range service.Ports {
+			for _, handler := range port.Handlers {
+				if !slices.Contains(KnownServiceHandlers, handler) {
+					return fmt.Errorf("%s has unknown handler '%s', expected one of %s",
+						servicePortName(service, i, port, j), handler, strings.Join(KnownServiceHandlers, ", "))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkPortOptions returns an error if a service port sets a block of options none of its
+// handlers use, which fly-proxy would silently ignore.
+func (c *Config) checkPortOptions() error {
+	for i, service := range c.Services {
+		for j, port := range service.Ports {
+			for _, option := range portOptionHandlers {
+				if option.set(port) && !lo.Some(port.Handlers, option.handlers) {
+					return fmt.Errorf("%s sets %s, but it only applies to the %s handlers; add one of them to the port's handlers or remove %s",
+						servicePortName(service, i, port, j), option.option, strings.Join(option.handlers, " or "), option.option)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// servicePortName identifies port j of the service at index i of [[services]] in messages.
+func servicePortName(service Service, i int, port fly.MachinePort, j int) string {
+	return fmt.Sprintf("[[services]] #%d (internal_port %d) port %s", i+1, service.InternalPort, portName(port, j))
+}
+
+// portName describes a service port by its number or range, or its position when it has neither.
+func portName(port fly.MachinePort, index int) string {
+	switch {
+	case port.Port != nil:
+		return fmt.Sprint(*port.Port)
+	case port.StartPort != nil || port.EndPort != nil:
+		return fmt.Sprintf("%s-%s", portBound(port.StartPort, "0"), portBound(port.EndPort, "65535"))
+	default:
+		return fmt.Sprintf("#%d", index+1)
+	}
+}
+
+func portBound(bound *int, unset string) string {
+	if bound == nil {
+		return unset
+	}
+	return fmt.Sprint(*bound)
+}
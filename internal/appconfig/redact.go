@@ -0,0 +1,61 @@
+package appconfig
+
+import "strings"
+
+// RedactedPlaceholder replaces the values WriteToFile leaves out with RedactSecrets: the
+// raw_value of [[files]] and the [env] values whose names look like secrets. It isn't valid
+// base64, so a config that still has it in a raw_value fails to deploy rather than writing the
+// placeholder to the machines.
+const RedactedPlaceholder = "<redacted>"
+
+// sensitiveEnvSubstrings are parts of the names of env values that usually hold secrets.
+var sensitiveEnvSubstrings = []string{"KEY", "PRIVATE", "DATABASE_URL", "PASSWORD", "SECRET"}
+
+// IsSensitiveEnv reports whether the env value called name likely holds a secret, which
+// should be set with fly secrets instead.
+func IsSensitiveEnv(name string) bool {
+	// Allowlist for names which contain a substring but are not secrets.
+	switch name {
+	case "AWS_ACCESS_KEY_ID":
+		return false
+	}
+
+	for _, substr := range sensitiveEnvSubstrings {
+		if strings.Contains(name, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteOption changes how WriteToFile writes a config.
+type WriteOption func(*writeOptions)
+
+type writeOptions struct {
+	redact bool
+}
+
+// RedactSecrets makes WriteToFile replace the raw values of files and the env values that
+// look like secrets with RedactedPlaceholder, so they aren't committed along with fly.toml.
+// Files that refer to a secret by name are kept as they are.
+func RedactSecrets() WriteOption {
+	return func(o *writeOptions) {
+		o.redact = true
+	}
+}
+
+// redacted returns a copy of c with the values RedactSecrets leaves out replaced.
+func (c *Config) redacted() *Config {
+	dst := c.clone()
+	for i, file := range dst.Files {
+		if file.RawValue != "" {
+			dst.Files[i].RawValue = RedactedPlaceholder
+		}
+	}
+	for name := range dst.Env {
+		if IsSensitiveEnv(name) {
+			dst.Env[name] = RedactedPlaceholder
+		}
+	}
+	return dst
+}
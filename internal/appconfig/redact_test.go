@@ -0,0 +1,60 @@
+package appconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteToFileRedactSecrets(t *testing.T) {
+	cfg := NewConfig()
+	cfg.AppName = "foo"
+	cfg.Env = map[string]string{"LOG_LEVEL": "debug", "STRIPE_SECRET": "sk_live_123"}
+	cfg.Files = []File{
+		{GuestPath: "/app/seed.json", RawValue: "eyJzZWVkIjogdHJ1ZX0="},
+		{GuestPath: "/app/token", SecretName: "API_TOKEN"},
+		{GuestPath: "/app/local.conf", LocalPath: "local.conf"},
+	}
+
+	path := filepath.Join(t.TempDir(), "fly.toml")
+	require.NoError(t, cfg.WriteToFile(path, RedactSecrets()))
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var written struct {
+		Env   map[string]string `toml:"env"`
+		Files []File            `toml:"files"`
+	}
+	require.NoError(t, toml.Unmarshal(raw, &written))
+
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "debug", "STRIPE_SECRET": RedactedPlaceholder}, written.Env)
+	assert.Equal(t, []File{
+		{GuestPath: "/app/seed.json", RawValue: RedactedPlaceholder},
+		{GuestPath: "/app/token", SecretName: "API_TOKEN"},
+		{GuestPath: "/app/local.conf", LocalPath: "local.conf"},
+	}, written.Files)
+	assert.NotContains(t, string(raw), "sk_live_123")
+
+	// The config itself keeps its values
+	assert.Equal(t, "sk_live_123", cfg.Env["STRIPE_SECRET"])
+	assert.Equal(t, "eyJzZWVkIjogdHJ1ZX0=", cfg.Files[0].RawValue)
+
+	// Without the option, everything is written
+	require.NoError(t, os.Remove(path))
+	require.NoError(t, cfg.WriteToFile(path))
+	raw, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "sk_live_123")
+	assert.Contains(t, string(raw), "eyJzZWVkIjogdHJ1ZX0=")
+}
+
+func TestIsSensitiveEnv(t *testing.T) {
+	assert.True(t, IsSensitiveEnv("DATABASE_URL"))
+	assert.True(t, IsSensitiveEnv("SIGNING_PRIVATE_PEM"))
+	assert.False(t, IsSensitiveEnv("AWS_ACCESS_KEY_ID"))
+	assert.False(t, IsSensitiveEnv("LOG_LEVEL"))
+}
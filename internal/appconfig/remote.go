@@ -3,16 +3,35 @@ package appconfig
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	fly "github.com/superfly/fly-go"
 	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/flapsutil"
 	"github.com/superfly/flyctl/internal/flyutil"
 	"github.com/superfly/flyctl/internal/machine"
 	"github.com/superfly/flyctl/iostreams"
 )
 
+// FromRemoteApp returns the config of the app's current release, or one put together from its
+// machines when there's no release. Within a context from WithRemoteConfigCache the config is
+// only fetched the first time.
 func FromRemoteApp(ctx context.Context, appName string) (*Config, error) {
+	cache, _ := ctx.Value(remoteCacheContextKey).(*remoteConfigCache)
+	if cfg := cache.get(appName); cfg != nil {
+		return cfg, nil
+	}
+
+	cfg, err := fetchRemoteApp(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+	cache.put(appName, cfg)
+	return cfg, nil
+}
+
+func fetchRemoteApp(ctx context.Context, appName string) (*Config, error) {
 	apiClient := flyutil.ClientFromContext(ctx)
 
 	cfg, err := getAppV2ConfigFromReleases(ctx, apiClient, appName)
@@ -79,3 +98,41 @@ func getAppV2ConfigFromReleases(ctx context.Context, apiClient flyutil.Client, a
 	}
 	return appConfig, err
 }
+
+// remoteConfigCache holds the configs FromRemoteApp fetched, keyed by app name. Callers get
+// copies so they can change them without affecting each other.
+type remoteConfigCache struct {
+	mu      sync.Mutex
+	configs map[string]*Config
+}
+
+func (c *remoteConfigCache) get(appName string) *Config {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cfg, ok := c.configs[appName]; ok {
+		return cfg.clone()
+	}
+	return nil
+}
+
+func (c *remoteConfigCache) put(appName string, cfg *Config) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.configs[appName] = cfg.clone()
+}
+
+// clone deep copies c, including the unexported fields helpers.Clone skips.
+func (c *Config) clone() *Config {
+	dst := helpers.Clone(c)
+	dst.configFilePath = c.configFilePath
+	dst.v2UnmarshalError = c.v2UnmarshalError
+	dst.defaultGroupName = c.defaultGroupName
+	dst.unknownFields = helpers.Clone(c.unknownFields)
+	return dst
+}
@@ -0,0 +1,61 @@
+package appconfig
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	genq "github.com/Khan/genqlient/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/flyutil"
+	"github.com/superfly/flyctl/internal/mock"
+)
+
+// countingGenqClient answers every query with the release of an app with a web process, and
+// counts the queries.
+type countingGenqClient struct {
+	requests int
+}
+
+func (c *countingGenqClient) MakeRequest(_ context.Context, _ *genq.Request, resp *genq.Response) error {
+	c.requests++
+	return json.Unmarshal([]byte(`{"app": {"currentReleaseUnprocessed": {"configDefinition": {
+		"primary_region": "ord",
+		"processes": {"web": "run web"}
+	}}}}`), resp.Data)
+}
+
+func TestFromRemoteAppCached(t *testing.T) {
+	genqClient := &countingGenqClient{}
+	client := &mock.Client{
+		GenqClientFunc: func() genq.Client { return genqClient },
+	}
+	ctx := flyutil.NewContextWithClient(context.Background(), client)
+
+	// Without a cache every call is a round trip
+	_, err := FromRemoteApp(ctx, "foo")
+	require.NoError(t, err)
+	_, err = FromRemoteApp(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, 2, genqClient.requests)
+
+	genqClient.requests = 0
+	ctx = WithRemoteConfigCache(ctx)
+	first, err := FromRemoteApp(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "foo", first.AppName)
+	assert.Equal(t, "ord", first.PrimaryRegion)
+
+	// Changing what a caller got doesn't show up in later calls
+	first.PrimaryRegion = "ams"
+	second, err := FromRemoteApp(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "ord", second.PrimaryRegion)
+	assert.Equal(t, []string{"web"}, second.ProcessNames())
+	assert.Equal(t, 1, genqClient.requests)
+
+	_, err = FromRemoteApp(ctx, "bar")
+	require.NoError(t, err)
+	assert.Equal(t, 2, genqClient.requests)
+}
@@ -0,0 +1,115 @@
+package appconfig
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+	fly "github.com/superfly/fly-go"
+)
+
+// JSONSchemaURL is the draft of JSON Schema that JSONSchema is written in.
+const JSONSchemaURL = "http://json-schema.org/draft-07/schema#"
+
+// schemaEnums restricts fields to the values flyctl or the platform accept, by the type
+// holding them and their toml key. The values of arrays apply to their items.
+var schemaEnums = map[reflect.Type]map[string][]string{
+	reflect.TypeOf(Deploy{}): {
+		"strategy": MachinesDeployStrategies,
+	},
+	reflect.TypeOf(Service{}): {
+		"protocol": {"tcp", "udp"},
+	},
+	reflect.TypeOf(fly.MachinePort{}): {
+		"handlers": KnownServiceHandlers,
+	},
+	reflect.TypeOf(fly.MachineServiceConcurrency{}): {
+		"type": {"connections", "requests"},
+	},
+	reflect.TypeOf(ToplevelCheck{}): {
+		"type": {"tcp", "http"},
+	},
+	reflect.TypeOf(Compute{}): {
+		"size":     lo.Keys(fly.MachinePresets),
+		"cpu_kind": {"shared", "performance"},
+	},
+	reflect.TypeOf(Restart{}): {
+		"policy": {string(RestartPolicyAlways), string(RestartPolicyNever), string(RestartPolicyOnFailure)},
+	},
+}
+
+// JSONSchema describes fly.toml as a JSON Schema, for editors to complete and check it.
+// It's derived from Config and the types of its fields, so it describes the current form
+// of each setting; older forms that are migrated on load aren't part of it.
+func JSONSchema() map[string]any {
+	schema := schemaForType(reflect.TypeOf(Config{}))
+	schema["$schema"] = JSONSchemaURL
+	schema["title"] = "Fly.io app configuration"
+	return schema
+}
+
+func schemaForType(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case reflect.TypeOf(fly.Duration{}):
+		// A duration string like "30s", or a number of nanoseconds.
+		return map[string]any{"type": []string{"string", "integer"}}
+	case reflect.TypeOf(fly.MachineAutostop(0)):
+		return map[string]any{"enum": []any{false, true, "off", "stop", "suspend"}}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return schemaForStruct(t)
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaForType(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+func schemaForStruct(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	for _, field := range reflect.VisibleFields(t) {
+		name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		property := schemaForType(field.Type)
+		if enum, ok := schemaEnums[t][name]; ok {
+			enum = slices.Clone(enum)
+			slices.Sort(enum)
+			if items, ok := property["items"].(map[string]any); ok {
+				items["enum"] = enum
+			} else {
+				property["enum"] = enum
+			}
+		}
+		properties[name] = property
+		if strings.Contains(field.Tag.Get("validate"), "required") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
@@ -0,0 +1,47 @@
+package appconfig
+
+import (
+	"os"
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+func validateAgainstJSONSchema(t *testing.T, doc map[string]any) []gojsonschema.ResultError {
+	result, err := gojsonschema.Validate(gojsonschema.NewGoLoader(JSONSchema()), gojsonschema.NewGoLoader(doc))
+	require.NoError(t, err)
+	return result.Errors()
+}
+
+func TestJSONSchema(t *testing.T) {
+	buf, err := os.ReadFile("./testdata/full-reference.toml")
+	require.NoError(t, err)
+	doc := map[string]any{}
+	require.NoError(t, toml.Unmarshal(buf, &doc))
+
+	// The reference config uses made-up values to check they round-trip, replace them with real ones.
+	doc["deploy"].(map[string]any)["strategy"] = "rolling"
+	doc["http_service"].(map[string]any)["concurrency"].(map[string]any)["type"] = "requests"
+	assert.Empty(t, validateAgainstJSONSchema(t, doc))
+
+	port := doc["services"].([]any)[0].(map[string]any)["ports"].([]any)[0].(map[string]any)
+	port["handlers"] = []any{"tls", "htttp"}
+	errs := validateAgainstJSONSchema(t, doc)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "services.0.ports.0.handlers.1", errs[0].Field())
+}
+
+func TestJSONSchemaEnums(t *testing.T) {
+	properties := JSONSchema()["properties"].(map[string]any)
+
+	deploy := properties["deploy"].(map[string]any)["properties"].(map[string]any)
+	assert.Equal(t, []string{"bluegreen", "canary", "immediate", "rolling"}, deploy["strategy"].(map[string]any)["enum"])
+
+	service := properties["services"].(map[string]any)["items"].(map[string]any)["properties"].(map[string]any)
+	concurrency := service["concurrency"].(map[string]any)["properties"].(map[string]any)
+	assert.Equal(t, []string{"connections", "requests"}, concurrency["type"].(map[string]any)["enum"])
+	assert.Equal(t, map[string]any{"type": []string{"string", "integer"}}, service["tcp_checks"].(map[string]any)["items"].(map[string]any)["properties"].(map[string]any)["interval"])
+}
@@ -5,23 +5,24 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strings"
-	"time"
 
 	"github.com/itchyny/json2yaml"
 	"github.com/pelletier/go-toml/v2"
+	"github.com/samber/lo"
 	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/iostreams"
 	"gopkg.in/yaml.v2"
 )
 
-const flyConfigHeader = `# fly.%s app configuration file generated for %s on %s
+// flyConfigHeader doesn't carry a timestamp, so writing the same config twice gives the same bytes.
+const flyConfigHeader = `# fly.%s app configuration file generated for %s
 #
 # See https://fly.io/docs/reference/configuration/ for information about how to use this file.
 #
@@ -38,16 +39,24 @@ func LoadConfig(path string) (cfg *Config, err error) {
 		return nil, err
 	}
 
-	if strings.HasSuffix(path, ".json") {
-		cfg, err = unmarshalJSON(buf)
-	} else if strings.HasSuffix(path, ".yaml") {
-		cfg, err = unmarshalYAML(buf)
-	} else {
-		cfg, err = unmarshalTOML(buf)
-	}
+	return loadConfig(path, func() (map[string]any, error) {
+		return decodeConfigMap(buf, configFormat(path))
+	})
+}
+
+// loadConfig builds the config loaded from path out of the raw map returned by decode.
+func loadConfig(path string, decode func() (map[string]any, error)) (*Config, error) {
+	cfg, err := unmarshalConfigMap(decode, os.LookupEnv, filepath.Dir(path))
 	if err != nil {
 		return nil, err
 	}
+	if cfg.v2UnmarshalError == nil {
+		for _, check := range []func() error{cfg.checkServicePortConflicts, cfg.checkForceHTTPS, cfg.checkStaticsOptions, cfg.checkBuildSecrets, cfg.checkMounts, cfg.checkCompute, cfg.checkPortHandlers, cfg.checkPortOptions} {
+			if err := check(); err != nil {
+				return nil, fmt.Errorf("invalid app config %s: %w", path, err)
+			}
+		}
+	}
 
 	cfg.configFilePath = path
 	// cfg.WriteToFile("patched-fly.toml")
@@ -72,7 +81,7 @@ func (c *Config) WriteTo(w io.Writer, format string) (int64, error) {
 
 	if format != "json" {
 		// JSON doesn't allow comments, so we can't add a header
-		_, err = fmt.Fprintf(w, flyConfigHeader, format, c.AppName, time.Now().Format(time.RFC3339))
+		_, err = fmt.Fprintf(w, flyConfigHeader, format, c.AppName)
 		if err != nil {
 			return 0, err
 		}
@@ -85,11 +94,36 @@ func (c *Config) WriteTo(w io.Writer, format string) (int64, error) {
 	}
 }
 
-func (c *Config) WriteToFile(filename string) (err error) {
+// WriteToFile writes the config to filename, in the format its extension calls for.
+func (c *Config) WriteToFile(filename string, opts ...WriteOption) (err error) {
+	var o writeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.redact {
+		c = c.redacted()
+	}
+
 	if err = helpers.MkdirAll(filename); err != nil {
 		return
 	}
 
+	// Values interpolated on load are escaped, so that they aren't interpolated again on the next load.
+	if c.interpolated {
+		c = c.escapeInterpolation()
+	}
+
+	format := configFormat(filename)
+	// Rewriting an existing TOML file only changes the values that differ, so the user's comments
+	// and layout are kept. Anything that can't be edited in place falls back to a fresh serialization.
+	if format == "toml" {
+		if doc, err := os.ReadFile(filename); err == nil {
+			if patched, ok := patchTOMLDocument(doc, filepath.Dir(filename), c); ok {
+				return os.WriteFile(filename, patched, 0o644)
+			}
+		}
+	}
+
 	var file *os.File
 	if file, err = os.Create(filename); err != nil {
 		return
@@ -100,13 +134,26 @@ func (c *Config) WriteToFile(filename string) (err error) {
 		}
 	}()
 
-	_, err = c.WriteTo(file, strings.TrimLeft(strings.ToLower(filepath.Ext(filename)), "."))
+	_, err = c.WriteTo(file, format)
 	return
 }
 
-func (c *Config) WriteToDisk(ctx context.Context, path string) (err error) {
+// configFormat returns the format of the config file at path, going by its extension:
+// "json", "yaml", or "toml" for anything else.
+func configFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	default:
+		return "toml"
+	}
+}
+
+func (c *Config) WriteToDisk(ctx context.Context, path string, opts ...WriteOption) (err error) {
 	io := iostreams.FromContext(ctx)
-	err = c.WriteToFile(path)
+	err = c.WriteToFile(path, opts...)
 	fmt.Fprintf(io.Out, "Wrote config file %s\n", helpers.PathRelativeToCWD(path))
 	return
 }
@@ -116,7 +163,21 @@ func (c *Config) MarshalJSON() ([]byte, error) {
 	if c == nil {
 		return json.Marshal(nil)
 	}
-	return json.Marshal(*c)
+	b, err := json.Marshal(*c)
+	if err != nil || len(c.unknownFields) == 0 {
+		return b, err
+	}
+
+	// Splice the unknown fields in after the known ones.
+	unknown, err := json.Marshal(c.unknownFields)
+	if err != nil {
+		return nil, err
+	}
+	b = b[:len(b)-1]
+	if len(b) > 1 {
+		b = append(b, ',')
+	}
+	return append(b, unknown[1:]...), nil
 }
 
 // MarshalAsYAML first marshals the config to JSON and then converts it to YAML
@@ -126,7 +187,7 @@ func (c *Config) MarshalAsYAML() ([]byte, error) {
 	if c == nil {
 		return json.Marshal(nil)
 	}
-	jsonConfig, err := json.Marshal(*c)
+	jsonConfig, err := json.Marshal(c)
 
 	if err != nil {
 		return nil, err
@@ -160,87 +221,160 @@ func (c *Config) marshalTOML() ([]byte, error) {
 	encoder.SetMarshalJsonNumbers(true)
 
 	if c != nil {
+		// Unknown top-level values have to come before the first table, and unknown tables after the known ones.
+		values, tables := splitUnknownFields(c.unknownFields)
+		if len(values) > 0 {
+			if err := encoder.Encode(values); err != nil {
+				return nil, err
+			}
+		}
 		if err := encoder.Encode(c); err != nil {
 			return nil, err
 		}
+		if len(tables) > 0 {
+			b.WriteString("\n")
+			if err := encoder.Encode(tables); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return b.Bytes(), nil
 }
 
-func unmarshalTOML(buf []byte) (*Config, error) {
-	cfgMap := map[string]any{}
-	if err := toml.Unmarshal(buf, &cfgMap); err != nil {
-		var derr *toml.DecodeError
-		if errors.As(err, &derr) {
-			row, col := derr.Position()
-			return nil, fmt.Errorf("row %d column %d\n%s", row, col, derr.String())
+// knownConfigFields holds the top-level keys of the config.
+var knownConfigFields = func() map[string]bool {
+	known := map[string]bool{}
+	for _, field := range reflect.VisibleFields(reflect.TypeOf(Config{})) {
+		if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+			known[name] = true
 		}
-		return nil, err
 	}
-	cfg, err := applyPatches(cfgMap)
-
-	// In case of parsing error fallback to bare compatibility
-	if err != nil {
-		// Unmarshal twice due to in-place cfgMap updates performed by patches
-		raw := map[string]any{}
-		if err := toml.Unmarshal(buf, &raw); err != nil {
-			return nil, err
+	return known
+}()
+
+// unknownConfigFields returns the keys of the patched cfgMap the config doesn't have fields for.
+func unknownConfigFields(cfgMap map[string]any) map[string]any {
+	var unknown map[string]any
+	for key, value := range cfgMap {
+		if knownConfigFields[key] {
+			continue
 		}
-		cfg = &Config{v2UnmarshalError: err}
-		if name, ok := (raw["app"]).(string); ok {
-			cfg.AppName = name
+		if unknown == nil {
+			unknown = map[string]any{}
 		}
+		unknown[key] = value
 	}
+	return unknown
+}
 
-	return cfg, nil
+// splitUnknownFields separates the unknown fields that TOML encodes as tables or arrays of tables
+// from plain values.
+func splitUnknownFields(unknown map[string]any) (values, tables map[string]any) {
+	values, tables = map[string]any{}, map[string]any{}
+	for key, value := range unknown {
+		switch cast := value.(type) {
+		case map[string]any, []map[string]any:
+			tables[key] = value
+		case []any:
+			if len(cast) > 0 && lo.EveryBy(cast, func(item any) bool { _, ok := item.(map[string]any); return ok }) {
+				tables[key] = value
+			} else {
+				values[key] = value
+			}
+		default:
+			values[key] = value
+		}
+	}
+	return values, tables
 }
 
-func unmarshalJSON(buf []byte) (*Config, error) {
-	cfgMap := map[string]any{}
-	if err := json.Unmarshal(buf, &cfgMap); err != nil {
+// unmarshalTOML parses a TOML config. If lookupEnv isn't nil, environment variables
+// referenced by the values that support it are interpolated, see interpolateEnv.
+func unmarshalTOML(buf []byte, lookupEnv func(string) (string, bool)) (*Config, error) {
+	return unmarshalConfigMap(func() (map[string]any, error) { return decodeTOML(buf) }, lookupEnv, "")
+}
+
+func unmarshalJSON(buf []byte, lookupEnv func(string) (string, bool)) (*Config, error) {
+	return unmarshalConfigMap(func() (map[string]any, error) { return decodeJSON(buf) }, lookupEnv, "")
+}
+
+func unmarshalYAML(buf []byte, lookupEnv func(string) (string, bool)) (*Config, error) {
+	return unmarshalConfigMap(func() (map[string]any, error) { return decodeYAML(buf) }, lookupEnv, "")
+}
+
+// unmarshalConfigMap builds a config from the raw map returned by decode. Env values read from
+// files are resolved relative to configDir, and aren't allowed if it's empty.
+func unmarshalConfigMap(decode func() (map[string]any, error), lookupEnv func(string) (string, bool), configDir string) (*Config, error) {
+	cfgMap, err := decode()
+	if err != nil {
+		return nil, err
+	}
+	interpolated, err := interpolateEnv(cfgMap, lookupEnv)
+	if err != nil {
 		return nil, err
 	}
+	// File contents are read after interpolation so they're taken literally.
+	if configDir != "" {
+		if err := resolveEnvFiles(cfgMap, configDir); err != nil {
+			return nil, err
+		}
+	}
 	cfg, err := applyPatches(cfgMap)
 
 	// In case of parsing error fallback to bare compatibility
 	if err != nil {
-		// Unmarshal twice due to in-place cfgMap updates performed by patches
-		raw := map[string]any{}
-		if err := json.Unmarshal(buf, &raw); err != nil {
-			return nil, err
+		// Decode twice due to in-place cfgMap updates performed by patches
+		raw, err2 := decode()
+		if err2 != nil {
+			return nil, err2
 		}
 		cfg = &Config{v2UnmarshalError: err}
 		if name, ok := (raw["app"]).(string); ok {
 			cfg.AppName = name
 		}
 	}
+	cfg.interpolated = interpolated
 
 	return cfg, nil
 }
 
-func unmarshalYAML(buf []byte) (*Config, error) {
-	cfgMap := map[string]any{}
-	if err := yaml.Unmarshal(buf, &cfgMap); err != nil {
-		return nil, err
+// decodeConfigMap parses buf, a config in the given format, into a raw map.
+func decodeConfigMap(buf []byte, format string) (map[string]any, error) {
+	switch format {
+	case "json":
+		return decodeJSON(buf)
+	case "yaml":
+		return decodeYAML(buf)
+	default:
+		return decodeTOML(buf)
 	}
-	stringifyYAMLMapKeys(cfgMap)
-	cfg, err := applyPatches(cfgMap)
+}
 
-	// In case of parsing error fallback to bare compatibility
+// decodeTOML parses buf with parseTOMLDocument, which reports problems with their row and column.
+func decodeTOML(buf []byte) (map[string]any, error) {
+	doc, err := parseTOMLDocument(buf)
 	if err != nil {
-		// Unmarshal twice due to in-place cfgMap updates performed by patches
-		raw := map[string]any{}
-		if err := yaml.Unmarshal(buf, &raw); err != nil {
-			return nil, err
-		}
-		cfg = &Config{v2UnmarshalError: err}
-		if name, ok := (raw["app"]).(string); ok {
-			cfg.AppName = name
-		}
+		return nil, err
 	}
+	return doc.values, nil
+}
 
-	return cfg, nil
+func decodeJSON(buf []byte) (map[string]any, error) {
+	cfgMap := map[string]any{}
+	if err := json.Unmarshal(buf, &cfgMap); err != nil {
+		return nil, err
+	}
+	return cfgMap, nil
+}
+
+func decodeYAML(buf []byte) (map[string]any, error) {
+	cfgMap := map[string]any{}
+	if err := yaml.Unmarshal(buf, &cfgMap); err != nil {
+		return nil, err
+	}
+	stringifyYAMLMapKeys(cfgMap)
+	return cfgMap, nil
 }
 
 // stringifyYAMLMapKeys converts map keys from interface{} to string
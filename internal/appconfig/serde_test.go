@@ -1,14 +1,20 @@
 package appconfig
 
 import (
+	"bytes"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/pelletier/go-toml/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	fly "github.com/superfly/fly-go"
+	"gopkg.in/yaml.v2"
 )
 
 func TestLoadTOMLAppConfigWithAppName(t *testing.T) {
@@ -69,7 +75,8 @@ func TestLoadTOMLAppConfigServicePorts(t *testing.T) {
 		InternalPort: 8080,
 		Ports: []fly.MachinePort{
 			{
-				Port: fly.Pointer(80),
+				Port:     fly.Pointer(443),
+				Handlers: []string{"tls", "http"},
 				TLSOptions: &fly.TLSOptions{
 					ALPN:     []string{"h2", "http/1.1"},
 					Versions: []string{"TLSv1.2", "TLSv1.3"},
@@ -98,6 +105,156 @@ func TestLoadTOMLAppConfigServicePorts(t *testing.T) {
 	assert.Equal(t, want, p.Services)
 }
 
+func TestLoadTOMLAppConfigForceHTTPS(t *testing.T) {
+	cfg, err := LoadConfig("./testdata/services-force-https.toml")
+	require.NoError(t, err)
+	require.Len(t, cfg.Services, 2)
+	assert.True(t, cfg.Services[0].Ports[0].ForceHTTPS)
+
+	_, err = LoadConfig("./testdata/services-force-https-without-tls.toml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "[[services]] #1 (internal_port 8080) port 80 sets force_https, but none of the service's ports have a 'tls' or 'https' handler to redirect to")
+}
+
+func TestLoadTOMLAppConfigStaticsKeepVersions(t *testing.T) {
+	_, err := LoadConfig("./testdata/statics-keep-no-versions.toml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "statics_options.keep_versions must be at least 1, got 0")
+}
+
+func TestLoadTOMLAppConfigConflictingServicePorts(t *testing.T) {
+	_, err := LoadConfig("./testdata/services-conflicting-ports.toml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "[http_service] and [[services]] #1 (internal_port 9000) both expose tcp port 443")
+
+	cfg := NewConfig()
+	cfg.Services = []Service{
+		{InternalPort: 8080, Ports: []fly.MachinePort{{StartPort: fly.Pointer(100), EndPort: fly.Pointer(200)}}},
+		{InternalPort: 8081, Ports: []fly.MachinePort{{StartPort: fly.Pointer(150), EndPort: fly.Pointer(300)}}},
+	}
+	assert.EqualError(t, cfg.checkServicePortConflicts(),
+		"[[services]] #1 (internal_port 8080) and [[services]] #2 (internal_port 8081) both expose tcp port 150-200")
+}
+
+func TestLoadTOMLAppConfigPortHandlers(t *testing.T) {
+	cfg, err := LoadConfig("./testdata/services-handlers.toml")
+	require.NoError(t, err)
+	require.Len(t, cfg.Services, 2)
+	assert.Len(t, cfg.Services[0].Ports, 2)
+
+	_, err = LoadConfig("./testdata/services-handlers-unknown.toml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "[[services]] #1 (internal_port 8080) port 443 has unknown handler 'htttp', expected one of http, https, tls, pg_tls, proxy_proto")
+
+	_, err = LoadConfig("./testdata/services-handlers-mismatched-options.toml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "[[services]] #1 (internal_port 5432) port 5432 sets proxy_proto_options, but it only applies to the proxy_proto handlers")
+
+	cfg = NewConfig()
+	cfg.Services = []Service{{InternalPort: 8080, Ports: []fly.MachinePort{
+		{StartPort: fly.Pointer(8000), EndPort: fly.Pointer(8010), TLSOptions: &fly.TLSOptions{ALPN: []string{"h2"}}},
+	}}}
+	assert.NoError(t, cfg.checkPortHandlers())
+	assert.EqualError(t, cfg.checkPortOptions(),
+		"[[services]] #1 (internal_port 8080) port 8000-8010 sets tls_options, but it only applies to the tls or https handlers; add one of them to the port's handlers or remove tls_options")
+
+	cfg.Services = []Service{{InternalPort: 8080, Ports: []fly.MachinePort{
+		{Port: fly.Pointer(443), Handlers: []string{"tls", "http"}, TLSOptions: &fly.TLSOptions{ALPN: []string{"h2"}}},
+	}}}
+	assert.NoError(t, cfg.checkPortOptions())
+}
+
+func TestLoadTOMLAppConfigBuildSecrets(t *testing.T) {
+	cfg, err := LoadConfig("./testdata/build-secrets.toml")
+	require.NoError(t, err)
+	assert.Equal(t, []BuildSecret{
+		{ID: "npm_token", Env: "NPM_TOKEN"},
+		{ID: "pip.conf", Src: "/home/me/.config/pip/pip.conf"},
+	}, cfg.Build.Secrets)
+
+	cases := map[string][]BuildSecret{
+		"[[build.secrets]] #1 must set an id":                                      {{Env: "NPM_TOKEN"}},
+		"[[build.secrets]] #1 (id 'npm token') has an invalid id":                  {{ID: "npm token", Env: "NPM_TOKEN"}},
+		"[[build.secrets]] #2 (id 'npm') uses an id that's already taken":          {{ID: "npm", Env: "NPM_TOKEN"}, {ID: "npm", Src: ".npmrc"}},
+		"[[build.secrets]] #1 (id 'npm') must set exactly one of env and src":      {{ID: "npm"}},
+		"[[build.secrets]] #2 (id 'registry') must set exactly one of env and src": {{ID: "npm", Src: ".npmrc"}, {ID: "registry", Env: "TOKEN", Src: "token.txt"}},
+	}
+	for want, secrets := range cases {
+		cfg := NewConfig()
+		cfg.Build = &Build{Secrets: secrets}
+		assert.ErrorContains(t, cfg.checkBuildSecrets(), want)
+	}
+}
+
+func TestLoadTOMLAppConfigMountSizes(t *testing.T) {
+	for _, size := range []string{"200", "30gb", "30GB", "1.5gb", "500mb", "1tib", "10g"} {
+		cfg := NewConfig()
+		cfg.Mounts = []Mount{{Source: "data", Destination: "/data", InitialSize: size}}
+		assert.NoError(t, cfg.checkMounts(), size)
+	}
+	for _, size := range []string{"30 gb", "30gigs", "gb", "-5", "30gb "} {
+		cfg := NewConfig()
+		cfg.Mounts = []Mount{{Source: "data", Destination: "/data", InitialSize: size}}
+		assert.EqualError(t, cfg.checkMounts(),
+			fmt.Sprintf("[[mounts]] #1 (source 'data') has an invalid initial_size '%s', use a number of GB or a size like 500mb or 30gb", size))
+	}
+
+	cfg := NewConfig()
+	cfg.Mounts = []Mount{{Source: "data", Destination: "/data"}, {Destination: "/logs"}}
+	assert.EqualError(t, cfg.checkMounts(), "[[mounts]] #2 must set a source")
+	cfg.Mounts = []Mount{{Source: "data", Destination: "data"}}
+	assert.EqualError(t, cfg.checkMounts(), "[[mounts]] #1 (source 'data') must set an absolute destination, got 'data'")
+
+	cfg, err := LoadConfig("./testdata/mounts-sizes.toml")
+	require.NoError(t, err)
+	assert.Equal(t, []Mount{
+		{Source: "data", Destination: "/data", InitialSize: "30gb"},
+		{Source: "logs", Destination: "/var/log/app", InitialSize: "10"},
+	}, cfg.Mounts)
+
+	_, err = LoadConfig("./testdata/mounts-invalid-size.toml")
+	assert.ErrorContains(t, err, "[[mounts]] #1 (source 'data') has an invalid initial_size '30 gb'")
+	_, err = LoadConfig("./testdata/mounts-relative-destination.toml")
+	assert.ErrorContains(t, err, "[[mounts]] #1 (source 'data') must set an absolute destination, got 'data'")
+}
+
+func TestLoadTOMLAppConfigCompute(t *testing.T) {
+	cfg, err := LoadConfig("./testdata/compute-valid.toml")
+	require.NoError(t, err)
+	require.Len(t, cfg.Compute, 2)
+
+	_, err = LoadConfig("./testdata/compute-over-memory.toml")
+	assert.ErrorContains(t, err, "[[vm]] #2 (processes worker) has 4096MB of memory, but size 'shared-cpu-1x' allows between 256MB and 2048MB")
+
+	cfg = NewConfig()
+	cfg.Compute = []*Compute{{Size: "performance-2x", Memory: "1gb"}}
+	assert.EqualError(t, cfg.checkCompute(), "[[vm]] #1 has 1024MB of memory, but size 'performance-2x' allows between 4096MB and 16384MB")
+	cfg.Compute = []*Compute{{Size: "huge-cpu-1x"}}
+	assert.ErrorContains(t, cfg.checkCompute(), "[[vm]] #1: ")
+
+	_, err = LoadConfig("./testdata/compute-over-memory-with-cpus.toml")
+	assert.ErrorContains(t, err, "[[vm]] #1 has 65536MB of memory, but size 'shared-cpu-1x' allows between 256MB and 2048MB")
+
+	// cpu_kind and cpus replace the CPUs of the size, and memory is checked against them instead
+	cfg.Compute = []*Compute{{Size: "shared-cpu-1x", Memory: "8gb", MachineGuest: &fly.MachineGuest{CPUKind: "performance", CPUs: 8}}}
+	assert.NoError(t, cfg.checkCompute())
+	cfg.Compute = []*Compute{{Size: "shared-cpu-1x", Memory: "128gb", MachineGuest: &fly.MachineGuest{CPUKind: "performance", CPUs: 8}}}
+	assert.EqualError(t, cfg.checkCompute(), "[[vm]] #1 has 131072MB of memory, but cpu_kind 'performance' with cpus = 8 allows at most 65536MB")
+	cfg.Compute = []*Compute{{MachineGuest: &fly.MachineGuest{MemoryMB: 4096}}}
+	assert.NoError(t, cfg.checkCompute())
+
+	cfg.Compute = []*Compute{{Size: "performance-2x", MachineGuest: &fly.MachineGuest{CPUs: 4}}}
+	assert.EqualError(t, cfg.checkCompute(), "[[vm]] #1 sets size 'performance-2x', which has 2 CPUs, and cpus = 4; drop one of them")
+	cfg.Compute = []*Compute{{Size: "performance-2x", MachineGuest: &fly.MachineGuest{CPUs: 2}}}
+	assert.NoError(t, cfg.checkCompute())
+}
+
+func TestLoadTOMLAppConfigAdjacentServicePorts(t *testing.T) {
+	cfg, err := LoadConfig("./testdata/services-adjacent-ports.toml")
+	require.NoError(t, err)
+	assert.Len(t, cfg.Services, 4)
+}
+
 func TestLoadTOMLAppConfigServiceMulti(t *testing.T) {
 	const path = "./testdata/services-multi.toml"
 
@@ -210,6 +367,30 @@ func TestLoadTOMLAppConfigEnvList(t *testing.T) {
 	assert.Equal(t, want, cfg.Env)
 }
 
+func TestLoadTOMLAppConfigEnvFromFile(t *testing.T) {
+	cfg, err := LoadConfig("./testdata/env-file.toml")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"LOG_LEVEL":    "info",
+		"DATABASE_URL": "postgres://db.internal:5432/app\n",
+		"CA_CERT":      "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n",
+	}, cfg.Env)
+
+	cfg, err = LoadConfig("./testdata/env-file-list.toml")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"LOG_LEVEL":    "info",
+		"DATABASE_URL": "postgres://db.internal:5432/app\n",
+	}, cfg.Env)
+}
+
+func TestLoadTOMLAppConfigEnvFromMissingFile(t *testing.T) {
+	_, err := LoadConfig("./testdata/env-file-missing.toml")
+	require.ErrorContains(t, err, "can't read env DATABASE_URL from file")
+	require.ErrorContains(t, err, "env-files/missing.txt")
+	assert.NotErrorIs(t, err, fs.ErrNotExist)
+}
+
 func TestLoadTOMLAppConfigOldFormat(t *testing.T) {
 	const path = "./testdata/old-format.toml"
 	cfg, err := LoadConfig(path)
@@ -283,6 +464,248 @@ func TestLoadTOMLAppConfigOldFormat(t *testing.T) {
 	}, cfg)
 }
 
+func TestLoadYAMLAppConfigOldFormat(t *testing.T) {
+	const path = "./testdata/old-format.toml"
+	expected, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	// The compatibility patches must handle the types YAML decodes to, e.g. int instead of int64
+	buf, err := os.ReadFile(path)
+	require.NoError(t, err)
+	raw := map[string]any{}
+	require.NoError(t, toml.Unmarshal(buf, &raw))
+	buf, err = yaml.Marshal(raw)
+	require.NoError(t, err)
+
+	YAMLpath := filepath.Join(t.TempDir(), "fly.yml")
+	require.NoError(t, os.WriteFile(YAMLpath, buf, 0o644))
+	actual, err := LoadConfig(YAMLpath)
+	require.NoError(t, err)
+
+	expected.configFilePath = ""
+	actual.configFilePath = ""
+	require.Equal(t, expected, actual)
+}
+
+func TestLoadTOMLAppConfigInterpolation(t *testing.T) {
+	const path = "./testdata/interpolation.toml"
+	t.Setenv("FLY_TEST_IMAGE_TAG", "v42")
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "registry.fly.io/foo:v42", cfg.Build.Image)
+	assert.Equal(t, map[string]string{"VERSION": "v42", "EMPTY": ""}, cfg.Build.Args)
+	assert.Equal(t, map[string]string{
+		"PRICE":    "$5",
+		"TEMPLATE": "${HOME}/app",
+		"PASSWORD": "pa$word",
+	}, cfg.Env)
+
+	// Only build.image, build.args and [env] are interpolated, other values often hold shell syntax
+	t.Setenv("FLY_TEST_REGION", "ams")
+	assert.Equal(t, "${FLY_TEST_REGION:-iad}", cfg.PrimaryRegion)
+	assert.Equal(t, map[string]string{"app": "sh -c 'exec ${FLY_TEST_UNSET} $$'"}, cfg.Processes)
+	assert.Equal(t, "echo ${FLY_TEST_UNSET}", cfg.Deploy.ReleaseCommand)
+
+	// Showing the config displays the values as they were loaded
+	var shown bytes.Buffer
+	_, err = cfg.WriteTo(&shown, "toml")
+	require.NoError(t, err)
+	assert.Contains(t, shown.String(), `PRICE = '$5'`)
+	assert.Contains(t, shown.String(), `app = "sh -c 'exec ${FLY_TEST_UNSET} $$'"`)
+
+	// Writing the config out must not let its values be interpolated again
+	flyToml := filepath.Join(t.TempDir(), "fly.toml")
+	require.NoError(t, cfg.WriteToFile(flyToml))
+	actual, err := LoadConfig(flyToml)
+	require.NoError(t, err)
+	cfg.configFilePath = ""
+	actual.configFilePath = ""
+	assert.Equal(t, cfg, actual)
+}
+
+func TestLoadTOMLAppConfigInterpolationUnset(t *testing.T) {
+	_, err := LoadConfig("./testdata/interpolation.toml")
+	require.ErrorContains(t, err, "FLY_TEST_IMAGE_TAG")
+}
+
+func TestWriteToFileLeavesDollarsAlone(t *testing.T) {
+	cfg := NewConfig()
+	cfg.AppName = "foo"
+	cfg.Env = map[string]string{"PASSWORD": "pa$word", "TRAILING": "cost$"}
+
+	flyToml := filepath.Join(t.TempDir(), "fly.toml")
+	require.NoError(t, cfg.WriteToFile(flyToml))
+	buf, err := os.ReadFile(flyToml)
+	require.NoError(t, err)
+	assert.Contains(t, string(buf), "pa$word")
+	assert.Contains(t, string(buf), "cost$")
+	assert.NotContains(t, string(buf), "$$")
+}
+
+func TestWriteToFilePreservesComments(t *testing.T) {
+	orig, err := os.ReadFile("./testdata/commented.toml")
+	require.NoError(t, err)
+	flyToml := filepath.Join(t.TempDir(), "fly.toml")
+	require.NoError(t, os.WriteFile(flyToml, orig, 0o644))
+
+	cfg, err := LoadConfig(flyToml)
+	require.NoError(t, err)
+	cfg.PrimaryRegion = "ams"
+	require.NoError(t, cfg.WriteToFile(flyToml))
+
+	buf, err := os.ReadFile(flyToml)
+	require.NoError(t, err)
+	expected := strings.Replace(string(orig), `primary_region = "ord"`, `primary_region = 'ams'`, 1)
+	assert.Equal(t, expected, string(buf))
+}
+
+func TestWriteToFileEditsInPlace(t *testing.T) {
+	orig, err := os.ReadFile("./testdata/commented.toml")
+	require.NoError(t, err)
+	flyToml := filepath.Join(t.TempDir(), "fly.toml")
+	require.NoError(t, os.WriteFile(flyToml, orig, 0o644))
+
+	cfg, err := LoadConfig(flyToml)
+	require.NoError(t, err)
+	cfg.SetEnvVariable("FEATURE", "on")
+	cfg.HTTPService.InternalPort = 3000
+	cfg.Build = nil
+	require.NoError(t, cfg.WriteToFile(flyToml))
+
+	buf, err := os.ReadFile(flyToml)
+	require.NoError(t, err)
+	assert.Equal(t, `# Hand-annotated config, keep these notes around.
+app = "foo"
+primary_region = "ord" # closest to our database
+
+[env]
+  # Quiet by default, crank it up when debugging.
+  LOG_LEVEL = "info"
+  FEATURE = 'on'
+
+# Public traffic.
+[http_service]
+  internal_port = 3000 # matches the app's PORT
+  force_https = true
+`, string(buf))
+
+	actual, err := LoadConfig(flyToml)
+	require.NoError(t, err)
+	cfg.configFilePath = flyToml
+	assert.Equal(t, cfg, actual)
+}
+
+func TestWriteToFileEditsMultilineValues(t *testing.T) {
+	flyToml := filepath.Join(t.TempDir(), "fly.toml")
+	require.NoError(t, os.WriteFile(flyToml, []byte(`app = "foo"
+
+[http_service]
+  internal_port = 8080
+  processes = [
+    "app",
+    "worker",
+  ] # served by both
+  # Redirect everything to https.
+  force_https = true
+`), 0o644))
+
+	cfg, err := LoadConfig(flyToml)
+	require.NoError(t, err)
+	cfg.HTTPService.Processes = []string{"app"}
+	cfg.HTTPService.ForceHTTPS = false
+	require.NoError(t, cfg.WriteToFile(flyToml))
+
+	buf, err := os.ReadFile(flyToml)
+	require.NoError(t, err)
+	assert.Equal(t, `app = "foo"
+
+[http_service]
+  internal_port = 8080
+  processes = ['app'] # served by both
+`, string(buf))
+}
+
+func TestUnknownFieldsRoundTrip(t *testing.T) {
+	cfg, err := LoadConfig("./testdata/unknown-fields.toml")
+	require.NoError(t, err)
+	assert.Equal(t, 8080, cfg.HTTPService.InternalPort)
+	assert.Equal(t, map[string]any{
+		"future_flag":    true,
+		"future_list":    []any{"a", "b"},
+		"future_section": map[string]any{"enabled": true, "mode": "fast"},
+		"future_items":   []any{map[string]any{"name": "one"}, map[string]any{"name": "two"}},
+	}, cfg.unknownFields)
+
+	for _, name := range []string{"fly.toml", "fly.json", "fly.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			require.NoError(t, cfg.WriteToFile(path))
+
+			buf, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Contains(t, string(buf), "future_flag")
+			assert.Contains(t, string(buf), "future_section")
+
+			actual, err := LoadConfig(path)
+			require.NoError(t, err)
+			assert.Equal(t, cfg.unknownFields, actual.unknownFields)
+			assert.Equal(t, cfg.HTTPService, actual.HTTPService)
+		})
+	}
+}
+
+func TestWriteToIsDeterministic(t *testing.T) {
+	cfg, err := LoadConfig("./testdata/full-reference.toml")
+	require.NoError(t, err)
+
+	for _, format := range []string{"toml", "json", "yaml"} {
+		var first, second bytes.Buffer
+		_, err := cfg.WriteTo(&first, format)
+		require.NoError(t, err)
+		_, err = cfg.WriteTo(&second, format)
+		require.NoError(t, err)
+		assert.Equal(t, first.String(), second.String(), format)
+	}
+}
+
+func TestWriteToIgnoresMapInsertionOrder(t *testing.T) {
+	keys := []string{"ALPHA", "BRAVO", "CHARLIE", "DELTA", "ECHO", "FOXTROT", "GOLF", "HOTEL"}
+	build := func(keys []string) *Config {
+		cfg := NewConfig()
+		cfg.AppName = "foo"
+		cfg.Env = map[string]string{}
+		cfg.Build = &Build{Args: map[string]string{}, Settings: map[string]any{}}
+		headers := map[string]string{}
+		for _, k := range keys {
+			cfg.Env[k] = strings.ToLower(k)
+			cfg.Build.Args[k] = k
+			cfg.Build.Settings[strings.ToLower(k)] = len(k)
+			headers["X-"+k] = k
+		}
+		cfg.Checks = map[string]*ToplevelCheck{"status": {Type: fly.Pointer("http"), HTTPHeaders: headers}}
+		return cfg
+	}
+	reversed := slices.Clone(keys)
+	slices.Reverse(reversed)
+	a, b := build(keys), build(reversed)
+
+	for _, format := range []string{"toml", "json", "yaml"} {
+		var bufA, bufB bytes.Buffer
+		_, err := a.WriteTo(&bufA, format)
+		require.NoError(t, err)
+		_, err = b.WriteTo(&bufB, format)
+		require.NoError(t, err)
+		assert.Equal(t, bufA.String(), bufB.String(), format)
+	}
+
+	// The machine checks built from them don't depend on map order either.
+	check, err := a.Checks["status"].toMachineCheck()
+	require.NoError(t, err)
+	assert.Equal(t, "X-ALPHA", check.HTTPHeaders[0].Name)
+	assert.Equal(t, "X-HOTEL", check.HTTPHeaders[len(keys)-1].Name)
+}
+
 func TestLoadTOMLAppConfigOldProcesses(t *testing.T) {
 	const path = "./testdata/old-processes.toml"
 	cfg, err := LoadConfig(path)
@@ -385,6 +808,11 @@ func TestLoadTOMLAppConfigReferenceFormat(t *testing.T) {
 				"param1": "value1",
 				"param2": "value2",
 			},
+
+			Secrets: []BuildSecret{
+				{ID: "npmrc", Src: ".npmrc"},
+				{ID: "registry_token", Env: "REGISTRY_TOKEN"},
+			},
 		},
 
 		Deploy: &Deploy{
@@ -485,6 +913,32 @@ func TestLoadTOMLAppConfigReferenceFormat(t *testing.T) {
 			},
 		},
 
+		StaticsOptions: &StaticsOptions{
+			KeepVersions: fly.Pointer(5),
+			Compress:     true,
+			MaxAge:       fly.MustParseDuration("1h"),
+			CacheRules: []StaticsCacheRule{
+				{Glob: "assets/*", MaxAge: fly.MustParseDuration("8760h")},
+				{Glob: "**/*.html", MaxAge: fly.MustParseDuration("0s")},
+			},
+			Ignore:     []string{"*.map", ".DS_Store"},
+			SkipHidden: true,
+			Region:     "ams",
+			Prefix:     "cdn/statics",
+			ContentTypes: map[string]string{
+				".wasm":        "application/wasm",
+				".webmanifest": "application/manifest+json",
+			},
+			Storage: &StaticsStorage{
+				Endpoint:           "https://s3.us-east-1.amazonaws.com",
+				Bucket:             "my-statics",
+				Region:             "us-east-1",
+				PathStyle:          true,
+				AccessKeyIDEnv:     "STATICS_ACCESS_KEY_ID",
+				SecretAccessKeyEnv: "STATICS_SECRET_ACCESS_KEY",
+			},
+		},
+
 		Files: []File{
 			{
 				GuestPath: "/path/to/hello.txt",
@@ -616,9 +1070,7 @@ func TestIsSameTOMLAppConfigReferenceFormat(t *testing.T) {
 	require.NoError(t, err)
 	require.NoError(t, actual.SetMachinesPlatform())
 
-	cfg.configFilePath = ""
-	actual.configFilePath = ""
-	require.Equal(t, cfg, actual)
+	require.True(t, cfg.Equal(actual))
 }
 
 func TestIsSameJSONAppConfigReferenceFormat(t *testing.T) {
@@ -633,9 +1085,25 @@ func TestIsSameJSONAppConfigReferenceFormat(t *testing.T) {
 	JSONcfg, err := LoadConfig(JSONpath)
 	require.NoError(t, err)
 
-	TOMLcfg.configFilePath = ""
-	JSONcfg.configFilePath = ""
-	require.Equal(t, TOMLcfg, JSONcfg)
+	require.True(t, TOMLcfg.Equal(JSONcfg))
+}
+
+func TestLoadJSONAppConfigUppercaseExtension(t *testing.T) {
+	cfg, err := LoadConfig("./testdata/full-reference.toml")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "FLY.JSON")
+	require.NoError(t, cfg.WriteToFile(path))
+	buf, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(buf), "{\n"))
+
+	actual, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	cfg.configFilePath = ""
+	actual.configFilePath = ""
+	require.Equal(t, cfg, actual)
 }
 
 func TestIsSameYAMLAppConfigReferenceFormat(t *testing.T) {
@@ -650,9 +1118,22 @@ func TestIsSameYAMLAppConfigReferenceFormat(t *testing.T) {
 	YAMLcfg, err := LoadConfig(YAMLpath)
 	require.NoError(t, err)
 
-	TOMLcfg.configFilePath = ""
-	YAMLcfg.configFilePath = ""
-	require.Equal(t, TOMLcfg, YAMLcfg)
+	require.True(t, TOMLcfg.Equal(YAMLcfg))
+}
+
+func TestIsSameYMLAppConfigReferenceFormat(t *testing.T) {
+	const TOMLpath = "./testdata/full-reference.toml"
+	TOMLcfg, err := LoadConfig(TOMLpath)
+	require.NoError(t, err)
+
+	YMLpath := filepath.Join(t.TempDir(), "fly.yml")
+	err = TOMLcfg.WriteToFile(YMLpath)
+	require.NoError(t, err)
+
+	YMLcfg, err := LoadConfig(YMLpath)
+	require.NoError(t, err)
+
+	require.True(t, TOMLcfg.Equal(YMLcfg))
 }
 
 func TestJSONPrettyPrint(t *testing.T) {
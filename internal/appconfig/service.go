@@ -3,6 +3,7 @@ package appconfig
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/samber/lo"
 	fly "github.com/superfly/fly-go"
@@ -132,13 +133,20 @@ func (chk *ServiceHTTPCheck) toMachineCheck() *fly.MachineCheck {
 		HTTPProtocol:      chk.HTTPProtocol,
 		HTTPSkipTLSVerify: chk.HTTPTLSSkipVerify,
 		HTTPTLSServerName: chk.HTTPTLSServerName,
-		HTTPHeaders: lo.MapToSlice(
-			chk.HTTPHeaders, func(k string, v string) fly.MachineHTTPHeader {
-				return fly.MachineHTTPHeader{Name: k, Values: []string{v}}
-			}),
+		HTTPHeaders:       machineHTTPHeaders(chk.HTTPHeaders),
 	}
 }
 
+// machineHTTPHeaders converts check headers for a machine config, sorted by name so the
+// machine config doesn't change from one run to the next.
+func machineHTTPHeaders(headers map[string]string) []fly.MachineHTTPHeader {
+	names := lo.Keys(headers)
+	slices.Sort(names)
+	return lo.Map(names, func(name string, _ int) fly.MachineHTTPHeader {
+		return fly.MachineHTTPHeader{Name: name, Values: []string{headers[name]}}
+	})
+}
+
 func (chk *ServiceHTTPCheck) String(port int) string {
 	return fmt.Sprintf("http-%d-%v", port, chk.HTTPMethod)
 }
@@ -0,0 +1,272 @@
+package appconfig
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/samber/lo"
+)
+
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// patchTOMLDocument edits doc, the current contents of a TOML app config in dir, so that it holds cfg.
+// Only the values that differ are rewritten, so comments, key order and formatting survive.
+// Values are compared as they're written, without interpolating them, so cfg must already be escaped.
+// It returns false when the differences can't be applied in place, in which case cfg has to be
+// serialized from scratch.
+func patchTOMLDocument(doc []byte, dir string, cfg *Config) ([]byte, bool) {
+	current, err := unmarshalTOMLIn(doc, dir)
+	if err != nil || current.v2UnmarshalError != nil {
+		return nil, false
+	}
+	have, err := configTOMLMap(current)
+	if err != nil {
+		return nil, false
+	}
+	want, err := configTOMLMap(cfg)
+	if err != nil {
+		return nil, false
+	}
+
+	lines := strings.SplitAfter(string(doc), "\n")
+	for _, edit := range diffTOMLMaps(nil, have, want) {
+		if lines, err = edit.apply(lines); err != nil {
+			return nil, false
+		}
+	}
+	patched := []byte(strings.Join(lines, ""))
+
+	// The edits are applied to the text and not the config, so make sure the result loads into exactly
+	// what a fresh serialization of cfg would.
+	reloaded, err := unmarshalTOMLIn(patched, dir)
+	if err != nil || reloaded.v2UnmarshalError != nil {
+		return nil, false
+	}
+	got, err := configTOMLMap(reloaded)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		return nil, false
+	}
+	return patched, true
+}
+
+// unmarshalTOMLIn parses doc the way LoadConfig would for a TOML file in dir, without interpolating it.
+func unmarshalTOMLIn(doc []byte, dir string) (*Config, error) {
+	return unmarshalConfigMap(func() (map[string]any, error) { return decodeTOML(doc) }, nil, dir)
+}
+
+// configTOMLMap returns cfg as the generic map its TOML serialization decodes to.
+func configTOMLMap(cfg *Config) (map[string]any, error) {
+	b, err := cfg.marshalTOML()
+	if err != nil {
+		return nil, err
+	}
+	return decodeTOML(b)
+}
+
+// tomlEdit sets the value at path, or removes it.
+type tomlEdit struct {
+	path   []string
+	value  any
+	remove bool
+}
+
+// diffTOMLMaps returns the edits that turn have into want, descending into tables present in both.
+func diffTOMLMaps(path []string, have, want map[string]any) []tomlEdit {
+	keys := lo.Union(lo.Keys(have), lo.Keys(want))
+	sort.Strings(keys)
+
+	var edits []tomlEdit
+	for _, key := range keys {
+		keyPath := append(slices.Clone(path), key)
+		h, inHave := have[key]
+		w, inWant := want[key]
+		switch {
+		case !inWant:
+			edits = append(edits, tomlEdit{path: keyPath, remove: true})
+		case !inHave:
+			edits = append(edits, tomlEdit{path: keyPath, value: w})
+		default:
+			hm, hIsMap := h.(map[string]any)
+			wm, wIsMap := w.(map[string]any)
+			if hIsMap && wIsMap {
+				edits = append(edits, diffTOMLMaps(keyPath, hm, wm)...)
+			} else if !reflect.DeepEqual(h, w) {
+				edits = append(edits, tomlEdit{path: keyPath, value: w})
+			}
+		}
+	}
+	return edits
+}
+
+var errTOMLEditUnsupported = errors.New("edit can't be applied in place")
+
+func (e tomlEdit) apply(lines []string) ([]string, error) {
+	doc, err := parseTOMLDocument([]byte(strings.Join(lines, "")))
+	if err != nil {
+		return nil, err
+	}
+	entries := doc.entries
+	table, key := e.path[:len(e.path)-1], e.path[len(e.path)-1]
+
+	if e.remove {
+		if entry, ok := findTOMLKey(entries, e.path); ok {
+			// The comments right above the key go along with it.
+			return slices.Delete(lines, entry.start-len(entry.comment.Above), entry.end), nil
+		}
+		return removeTOMLTable(lines, entries, e.path)
+	}
+
+	if section, ok := e.value.(map[string]any); ok {
+		// Only whole new top-level sections are appended, deeper ones would need their parent located.
+		if len(e.path) != 1 {
+			return nil, errTOMLEditUnsupported
+		}
+		var b bytes.Buffer
+		encoder := toml.NewEncoder(&b)
+		encoder.SetIndentTables(true)
+		if err := encoder.Encode(map[string]any{key: section}); err != nil {
+			return nil, err
+		}
+		if n := len(lines); n > 0 && strings.TrimSpace(lines[n-1]) != "" {
+			if !strings.HasSuffix(lines[n-1], "\n") {
+				lines[n-1] += "\n"
+			}
+			lines = append(lines, "\n")
+		}
+		return append(lines, b.String()), nil
+	}
+
+	value, err := renderTOMLValue(e.value)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry, ok := findTOMLKey(entries, e.path); ok {
+		prefix := lines[entry.start][:entry.valueColumn]
+		// Keep the comment trailing the value.
+		var trailer string
+		if entry.comment.Inline != "" {
+			last := strings.TrimRight(lines[entry.end-1], "\r\n")
+			trailer = strings.TrimSuffix(last, entry.comment.Inline)
+			trailer = last[len(strings.TrimRight(trailer, " \t")):]
+		}
+		replacement := prefix + value + trailer + "\n"
+		return slices.Replace(lines, entry.start, entry.end, replacement), nil
+	}
+
+	// Add the key after the last one already in its table, with the same indentation.
+	at, indent := -1, tomlKeyIndent(lines, entries, table)
+	for _, entry := range entries {
+		if !entry.arrayTable && slices.Equal(entry.table, table) {
+			at = entry.end
+		}
+	}
+	line := indent + tomlQuoteKey(key) + " = " + value + "\n"
+	if at < 0 {
+		if len(table) > 0 {
+			return nil, errTOMLEditUnsupported
+		}
+		// The root table has no keys yet, they go above the first table and the comments leading into it.
+		at = len(lines)
+		if header, ok := lo.Find(entries, func(entry tomlEntry) bool { return entry.header }); ok {
+			at = header.start - len(header.comment.Above)
+			line += "\n"
+		}
+	}
+	if at > 0 && !strings.HasSuffix(lines[at-1], "\n") {
+		lines[at-1] += "\n"
+	}
+	return slices.Insert(lines, at, line), nil
+}
+
+// tomlKeyIndent returns the indentation of the keys in table, or of the keys in other tables when it has none.
+func tomlKeyIndent(lines []string, entries []tomlEntry, table []string) string {
+	indent := func(entry tomlEntry) string {
+		line := lines[entry.start]
+		return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	}
+	keys := lo.Filter(entries, func(entry tomlEntry, _ int) bool { return !entry.header })
+	if entry, ok := lo.Find(keys, func(entry tomlEntry) bool { return slices.Equal(entry.table, table) }); ok {
+		return indent(entry)
+	}
+	if len(table) == 0 {
+		return ""
+	}
+	if entry, ok := lo.Find(keys, func(entry tomlEntry) bool { return len(entry.table) > 0 }); ok {
+		return indent(entry)
+	}
+	return ""
+}
+
+// removeTOMLTable drops the sections of the table at path and of every table nested in it,
+// along with the comments right above their headers.
+func removeTOMLTable(lines []string, entries []tomlEntry, path []string) ([]string, error) {
+	var sections [][2]int
+	for i, entry := range entries {
+		if !entry.header || len(entry.table) < len(path) || !slices.Equal(entry.table[:len(path)], path) {
+			continue
+		}
+		// The section runs until the next header and the comments above it.
+		end := len(lines)
+		for _, next := range entries[i+1:] {
+			if next.header {
+				end = next.start - len(next.comment.Above)
+				break
+			}
+		}
+		for end > entry.end && strings.TrimSpace(lines[end-1]) == "" {
+			end--
+		}
+		start := entry.start - len(entry.comment.Above)
+		// Don't leave two blank lines where the section was.
+		if start > 0 && strings.TrimSpace(lines[start-1]) == "" && end < len(lines) && strings.TrimSpace(lines[end]) == "" {
+			end++
+		}
+		sections = append(sections, [2]int{start, end})
+	}
+	if len(sections) == 0 {
+		return nil, errTOMLEditUnsupported
+	}
+	for i := len(sections) - 1; i >= 0; i-- {
+		lines = slices.Delete(lines, sections[i][0], sections[i][1])
+	}
+	return lines, nil
+}
+
+// findTOMLKey returns the key/value at path, outside of arrays of tables.
+func findTOMLKey(entries []tomlEntry, path []string) (tomlEntry, bool) {
+	return lo.Find(entries, func(entry tomlEntry) bool {
+		return !entry.header && !entry.arrayTable && slices.Equal(entry.path(), path)
+	})
+}
+
+// renderTOMLValue formats a single value the way marshalTOML would.
+func renderTOMLValue(value any) (string, error) {
+	b, err := toml.Marshal(map[string]any{"v": value})
+	if err != nil {
+		return "", err
+	}
+	rendered, ok := strings.CutPrefix(strings.TrimSuffix(string(b), "\n"), "v = ")
+	if !ok || strings.Contains(rendered, "\n") {
+		// Arrays of tables and the like need their own sections.
+		return "", errTOMLEditUnsupported
+	}
+	return rendered, nil
+}
+
+func tomlQuoteKey(key string) string {
+	if tomlBareKey.MatchString(key) {
+		return key
+	}
+	b, err := toml.Marshal(key)
+	if err != nil {
+		return `"` + key + `"`
+	}
+	return strings.TrimSpace(string(b))
+}
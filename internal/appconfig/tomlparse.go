@@ -0,0 +1,506 @@
+package appconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// tomlDocument is a TOML app config parsed along with where each of its keys is and the comments
+// around them. Keys and tables are identified in positions by their path, such as
+// services[0].ports[1].handlers.
+type tomlDocument struct {
+	// values holds the config as the generic map toml.Unmarshal decodes it to.
+	values    map[string]any
+	positions map[string]unstable.Position
+	// entries are the table headers and key/values of the document, in the order they appear, so
+	// that it can be edited in place. The keys of inline tables are part of the key/value the
+	// table is the value of.
+	entries []tomlEntry
+}
+
+// tomlEntry is a table header or a key/value of a TOML document, spanning lines [start, end)
+// counted from 0.
+type tomlEntry struct {
+	// table is the path of the header, or of the table the key belongs to.
+	table []string
+	// arrayTable is set for [[array]] headers and the keys in them.
+	arrayTable bool
+	header     bool
+	// key holds the parts of the key of a key/value, more than one for dotted keys.
+	key        []string
+	start, end int
+	// valueColumn is where the value of a key/value starts on its first line.
+	valueColumn int
+	comment     tomlComment
+}
+
+// path returns the path of the table of a header, or of the value of a key/value.
+func (e tomlEntry) path() []string {
+	return append(append([]string{}, e.table...), e.key...)
+}
+
+// tomlComment holds the comment lines right above a key or table, and the comment following it
+// on the same line. Comments are kept as written, starting with #.
+type tomlComment struct {
+	Above  []string
+	Inline string
+}
+
+// tomlDecodeError is a problem found while parsing a TOML document, located by the line and
+// column it starts at.
+type tomlDecodeError struct {
+	Line    int
+	Column  int
+	Message string
+	// source is the line of the document the problem is on.
+	source string
+}
+
+func (e *tomlDecodeError) Error() string {
+	return fmt.Sprintf("row %d column %d\n%d| %s\n%s| %s^ %s", e.Line, e.Column,
+		e.Line, e.source, strings.Repeat(" ", len(strconv.Itoa(e.Line))), strings.Repeat(" ", max(e.Column-1, 0)), e.Message)
+}
+
+// parseTOMLDocument parses buf into the same values toml.Unmarshal would, keeping the position
+// of every key and table and its entries. Comments right above an entry, without a blank line in
+// between, and the one trailing it belong to the entry.
+func parseTOMLDocument(buf []byte) (*tomlDocument, error) {
+	d := &tomlDecoder{
+		doc: &tomlDocument{
+			values:    map[string]any{},
+			positions: map[string]unstable.Position{},
+		},
+		defined: map[string]bool{},
+		dotted:  map[string]bool{},
+		inline:  map[string]bool{},
+		arrays:  map[string]bool{},
+	}
+	d.table = d.doc.values
+	d.parser.KeepComments = true
+	d.parser.Reset(buf)
+
+	for d.parser.NextExpression() {
+		expr := d.parser.Expression()
+		var err error
+		switch expr.Kind {
+		case unstable.Comment:
+			line := d.position(expr).Line - 1
+			d.pending = append(d.pending, tomlCommentLine{line: line, text: string(expr.Data)})
+			d.starts = append(d.starts, line)
+			continue
+		case unstable.Table:
+			err = d.openTable(expr)
+		case unstable.ArrayTable:
+			err = d.appendTable(expr)
+		case unstable.KeyValue:
+			err = d.setKeyValue(d.table, d.tablePath, expr)
+		}
+		if err != nil {
+			return nil, err
+		}
+		d.addEntry(expr)
+	}
+
+	if err := d.parser.Error(); err != nil {
+		var perr *unstable.ParserError
+		if !errors.As(err, &perr) {
+			return nil, err
+		}
+		highlight := perr.Highlight
+		if len(highlight) == 0 {
+			// Problems like an unterminated array are found at the end of the document.
+			if len(buf) == 0 {
+				return nil, err
+			}
+			highlight = buf[len(buf)-1:]
+		}
+		return nil, d.errorAt(d.parser.Shape(d.parser.Range(highlight)).Start, "%s", perr.Message)
+	}
+
+	// An entry ends before the next entry or comment line, leaving out the blank lines in between.
+	lines := bytes.SplitAfter(buf, []byte("\n"))
+	next := len(d.starts)
+	for i := len(d.doc.entries) - 1; i >= 0; i-- {
+		entry := &d.doc.entries[i]
+		for next > 0 && d.starts[next-1] > entry.start {
+			next--
+		}
+		entry.end = len(lines)
+		if next < len(d.starts) {
+			entry.end = d.starts[next]
+		}
+		for entry.end > entry.start+1 && len(bytes.TrimSpace(lines[entry.end-1])) == 0 {
+			entry.end--
+		}
+	}
+	return d.doc, nil
+}
+
+type tomlCommentLine struct {
+	line int
+	text string
+}
+
+// tomlDecoder builds a tomlDocument out of the expressions of a TOML document, in order.
+type tomlDecoder struct {
+	parser unstable.Parser
+	doc    *tomlDocument
+
+	// table is where key/values currently go, and tablePath its path. header holds the parts of
+	// the key of the last table header, and arrayTable whether it's an [[array]] one.
+	table      map[string]any
+	tablePath  string
+	header     []string
+	arrayTable bool
+	// defined holds the paths of the tables opened by a [table] header, dotted those of the tables
+	// made by dotted keys, inline those of inline tables, and arrays those of the arrays made of
+	// [[array]] headers.
+	defined map[string]bool
+	dotted  map[string]bool
+	inline  map[string]bool
+	arrays  map[string]bool
+	// pending holds the comments found since the last entry, and starts the first line of every
+	// entry and comment, which is where the entry before them ends at the latest.
+	pending []tomlCommentLine
+	starts  []int
+}
+
+func (d *tomlDecoder) position(node *unstable.Node) unstable.Position {
+	return d.parser.Shape(node.Raw).Start
+}
+
+func (d *tomlDecoder) errorAt(pos unstable.Position, format string, a ...any) error {
+	source := d.parser.Data()[pos.Offset-(pos.Column-1):]
+	if i := bytes.IndexAny(source, "\r\n"); i >= 0 {
+		source = source[:i]
+	}
+	return &tomlDecodeError{Line: pos.Line, Column: pos.Column, Message: fmt.Sprintf(format, a...), source: string(source)}
+}
+
+func (d *tomlDecoder) errorf(node *unstable.Node, format string, a ...any) error {
+	return d.errorAt(d.position(node), format, a...)
+}
+
+// addEntry adds the entry for the table header or key/value expr to the document, along with the
+// comments right above it and the one trailing it.
+func (d *tomlDecoder) addEntry(expr *unstable.Node) {
+	keys := tomlKeyNodes(expr)
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = string(key.Data)
+	}
+	first := d.position(keys[0])
+	entry := tomlEntry{start: first.Line - 1}
+	switch expr.Kind {
+	case unstable.Table, unstable.ArrayTable:
+		d.header, d.arrayTable = names, expr.Kind == unstable.ArrayTable
+		entry.table, entry.arrayTable, entry.header = d.header, d.arrayTable, true
+	case unstable.KeyValue:
+		entry.table, entry.arrayTable, entry.key = d.header, d.arrayTable, names
+		// The value starts after the = and the whitespace around it.
+		buf, last := d.parser.Data(), keys[len(keys)-1].Raw
+		offset := int(last.Offset + last.Length)
+		offset += len(buf[offset:]) - len(bytes.TrimLeft(buf[offset:], " \t="))
+		entry.valueColumn = offset - (first.Offset - (first.Column - 1))
+	}
+
+	i := len(d.pending)
+	for i > 0 && d.pending[i-1].line == entry.start-(len(d.pending)-i)-1 {
+		i--
+	}
+	for _, above := range d.pending[i:] {
+		entry.comment.Above = append(entry.comment.Above, above.text)
+	}
+	d.pending = nil
+	if next := expr.Next(); next != nil && next.Kind == unstable.Comment {
+		entry.comment.Inline = string(next.Data)
+	}
+
+	d.doc.entries = append(d.doc.entries, entry)
+	d.starts = append(d.starts, entry.start)
+}
+
+// descend walks keys down from table at path, creating the tables that don't exist yet and going
+// into the last element of arrays of tables. It returns the table reached and its path. The tables
+// walked through are recorded as made by dotted keys if dotted is set.
+func (d *tomlDecoder) descend(table map[string]any, path string, keys []*unstable.Node, dotted bool) (map[string]any, string, error) {
+	for _, key := range keys {
+		name := string(key.Data)
+		path = joinTOMLPath(path, name)
+		switch value := table[name].(type) {
+		case nil:
+			next := map[string]any{}
+			table[name] = next
+			table = next
+		case map[string]any:
+			if d.inline[path] {
+				return nil, "", d.errorf(key, "table %s is defined inline, so it can't be extended", path)
+			}
+			table = value
+		case []any:
+			// Only arrays of tables can be added to, and they're never empty.
+			if !d.arrays[path] {
+				return nil, "", d.errorf(key, "key %s is already defined as an array", path)
+			}
+			path = fmt.Sprintf("%s[%d]", path, len(value)-1)
+			table = value[len(value)-1].(map[string]any)
+		default:
+			return nil, "", d.errorf(key, "key %s is already defined as a value", path)
+		}
+		if dotted {
+			d.dotted[path] = true
+		}
+	}
+	return table, path, nil
+}
+
+// openTable handles a [table] header.
+func (d *tomlDecoder) openTable(expr *unstable.Node) error {
+	keys := tomlKeyNodes(expr)
+	parent, path, err := d.descend(d.doc.values, "", keys[:len(keys)-1], false)
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	path = joinTOMLPath(path, string(last.Data))
+
+	switch value := parent[string(last.Data)].(type) {
+	case nil:
+		d.table = map[string]any{}
+		parent[string(last.Data)] = d.table
+	case map[string]any:
+		switch {
+		case d.inline[path]:
+			return d.errorf(last, "table %s is defined inline, so it can't be extended", path)
+		case d.defined[path], d.dotted[path]:
+			return d.errorf(last, "table %s is already defined", path)
+		}
+		d.table = value
+	default:
+		return d.errorf(last, "key %s is already defined", path)
+	}
+	d.defined[path] = true
+	d.tablePath = path
+	d.doc.positions[path] = d.position(keys[0])
+	return nil
+}
+
+// appendTable handles an [[array]] header.
+func (d *tomlDecoder) appendTable(expr *unstable.Node) error {
+	keys := tomlKeyNodes(expr)
+	parent, path, err := d.descend(d.doc.values, "", keys[:len(keys)-1], false)
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	path = joinTOMLPath(path, string(last.Data))
+
+	table := map[string]any{}
+	var array []any
+	switch value := parent[string(last.Data)].(type) {
+	case nil:
+		array = []any{table}
+		d.arrays[path] = true
+	case []any:
+		if !d.arrays[path] {
+			return d.errorf(last, "array %s can't be extended with [[%s]]", path, path)
+		}
+		array = append(value, table)
+	default:
+		return d.errorf(last, "key %s is already defined", path)
+	}
+	parent[string(last.Data)] = array
+
+	d.table = table
+	d.tablePath = fmt.Sprintf("%s[%d]", path, len(array)-1)
+	d.doc.positions[d.tablePath] = d.position(keys[0])
+	return nil
+}
+
+// setKeyValue adds the key/value kv to table, at tablePath.
+func (d *tomlDecoder) setKeyValue(table map[string]any, tablePath string, kv *unstable.Node) error {
+	keys := tomlKeyNodes(kv)
+	parent, _, err := d.descend(table, tablePath, keys[:len(keys)-1], true)
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = string(key.Data)
+	}
+	path := joinTOMLPath(tablePath, strings.Join(names, "."))
+
+	last := keys[len(keys)-1]
+	if _, ok := parent[string(last.Data)]; ok {
+		return d.errorf(last, "key %s is already defined", path)
+	}
+	d.doc.positions[path] = d.position(keys[0])
+
+	value, err := d.value(path, kv.Value())
+	if err != nil {
+		return err
+	}
+	parent[string(last.Data)] = value
+	if kv.Value().Kind == unstable.InlineTable {
+		d.inline[path] = true
+	}
+	return nil
+}
+
+func (d *tomlDecoder) value(path string, node *unstable.Node) (any, error) {
+	switch node.Kind {
+	case unstable.String:
+		return string(node.Data), nil
+	case unstable.Bool:
+		return string(node.Data) == "true", nil
+	case unstable.Integer:
+		n, err := parseTOMLInteger(string(node.Data))
+		if err != nil {
+			return nil, d.errorf(node, "%s", err)
+		}
+		return n, nil
+	case unstable.Float:
+		f, err := parseTOMLFloat(string(node.Data))
+		if err != nil {
+			return nil, d.errorf(node, "%s", err)
+		}
+		return f, nil
+	case unstable.LocalDate:
+		var date toml.LocalDate
+		if err := date.UnmarshalText(node.Data); err != nil {
+			return nil, d.errorf(node, "%s", err)
+		}
+		return date, nil
+	case unstable.LocalTime:
+		var t toml.LocalTime
+		if err := t.UnmarshalText(node.Data); err != nil {
+			return nil, d.errorf(node, "%s", err)
+		}
+		return t, nil
+	case unstable.LocalDateTime:
+		var dt toml.LocalDateTime
+		if err := dt.UnmarshalText(node.Data); err != nil {
+			return nil, d.errorf(node, "%s", err)
+		}
+		return dt, nil
+	case unstable.DateTime:
+		t, err := parseTOMLDateTime(string(node.Data))
+		if err != nil {
+			return nil, d.errorf(node, "%s", err)
+		}
+		return t, nil
+	case unstable.Array:
+		values := []any{}
+		it := node.Children()
+		for it.Next() {
+			elem := it.Node()
+			if elem.Kind == unstable.Comment {
+				continue
+			}
+			elemPath := fmt.Sprintf("%s[%d]", path, len(values))
+			if elem.Kind == unstable.InlineTable {
+				d.doc.positions[elemPath] = d.position(elem)
+			}
+			value, err := d.value(elemPath, elem)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case unstable.InlineTable:
+		table := map[string]any{}
+		it := node.Children()
+		for it.Next() {
+			if kv := it.Node(); kv.Kind == unstable.KeyValue {
+				if err := d.setKeyValue(table, path, kv); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return table, nil
+	default:
+		return nil, d.errorf(node, "unexpected %s", node.Kind)
+	}
+}
+
+// tomlKeyNodes returns the parts of the key of a table or key/value node.
+func tomlKeyNodes(node *unstable.Node) []*unstable.Node {
+	var keys []*unstable.Node
+	it := node.Key()
+	for it.Next() {
+		keys = append(keys, it.Node())
+	}
+	return keys
+}
+
+func parseTOMLInteger(raw string) (int64, error) {
+	digits := "0123456789"
+	if len(raw) > 2 && raw[0] == '0' && raw[1] == 'x' {
+		digits += "abcdefABCDEF"
+	}
+	cleaned, err := removeTOMLUnderscores(raw, digits)
+	if err != nil {
+		return 0, err
+	}
+	if len(cleaned) > 2 && cleaned[0] == '0' && strings.ContainsRune("xob", rune(cleaned[1])) {
+		// Base 0 understands the 0x, 0o and 0b prefixes.
+		return strconv.ParseInt(cleaned, 0, 64)
+	}
+	if digits := strings.TrimLeft(cleaned, "+-"); len(digits) > 1 && digits[0] == '0' {
+		return 0, errors.New("leading zero not allowed on decimal number")
+	}
+	return strconv.ParseInt(cleaned, 10, 64)
+}
+
+func parseTOMLFloat(raw string) (float64, error) {
+	if strings.TrimLeft(raw, "+-") == "nan" {
+		return math.NaN(), nil
+	}
+	cleaned, err := removeTOMLUnderscores(raw, "0123456789")
+	if err != nil {
+		return 0, err
+	}
+	for i, c := range cleaned {
+		if c == '.' && (i == 0 || i == len(cleaned)-1 || !isTOMLDigit(cleaned[i-1]) || !isTOMLDigit(cleaned[i+1])) {
+			return 0, errors.New("float decimal point must be between digits")
+		}
+	}
+	if digits := strings.TrimLeft(cleaned, "+-"); len(digits) > 1 && digits[0] == '0' && isTOMLDigit(digits[1]) {
+		return 0, errors.New("float integer part cannot have leading zeroes")
+	}
+	return strconv.ParseFloat(cleaned, 64)
+}
+
+// removeTOMLUnderscores removes the underscores between the digits of a number, which are each
+// required to have one of digits on both sides.
+func removeTOMLUnderscores(raw, digits string) (string, error) {
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '_' && (i == 0 || i == len(raw)-1 || !strings.ContainsRune(digits, rune(raw[i-1])) || !strings.ContainsRune(digits, rune(raw[i+1]))) {
+			return "", errors.New("number underscores must be between digits")
+		}
+	}
+	return strings.ReplaceAll(raw, "_", ""), nil
+}
+
+func isTOMLDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parseTOMLDateTime parses an offset date-time, which may use a space or a lowercase t between
+// the date and the time, and a lowercase z for UTC.
+func parseTOMLDateTime(raw string) (time.Time, error) {
+	b := []byte(strings.ToUpper(raw))
+	if len(b) > 10 && b[10] == ' ' {
+		b[10] = 'T'
+	}
+	return time.Parse(time.RFC3339Nano, string(b))
+}
@@ -0,0 +1,187 @@
+package appconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTOMLDocumentEntries(t *testing.T) {
+	doc, err := parseTOMLDocument([]byte(`# The app's name.
+app = "foo" # can't be changed
+
+# Build settings
+[build]
+  args = [
+    "a", # first
+    "b",
+  ] # passed to docker
+  image.tag = "latest"
+
+[[services]]
+  internal_port = 8080
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []tomlEntry{
+		{key: []string{"app"}, start: 1, end: 2, valueColumn: 6, comment: tomlComment{Above: []string{"# The app's name."}, Inline: "# can't be changed"}},
+		{table: []string{"build"}, header: true, start: 4, end: 5, comment: tomlComment{Above: []string{"# Build settings"}}},
+		{table: []string{"build"}, key: []string{"args"}, start: 5, end: 9, valueColumn: 9, comment: tomlComment{Inline: "# passed to docker"}},
+		{table: []string{"build"}, key: []string{"image", "tag"}, start: 9, end: 10, valueColumn: 14},
+		{table: []string{"services"}, arrayTable: true, header: true, start: 11, end: 12},
+		{table: []string{"services"}, arrayTable: true, key: []string{"internal_port"}, start: 12, end: 13, valueColumn: 18},
+	}, doc.entries)
+	assert.Equal(t, []string{"build", "image", "tag"}, doc.entries[3].path())
+}
+
+// entryComments returns the comments of the entries of doc that have some, by their dotted path.
+func entryComments(doc *tomlDocument) map[string]tomlComment {
+	comments := map[string]tomlComment{}
+	for _, entry := range doc.entries {
+		if len(entry.comment.Above) > 0 || entry.comment.Inline != "" {
+			comments[strings.Join(entry.path(), ".")] = entry.comment
+		}
+	}
+	return comments
+}
+
+func TestParseTOMLDocumentComments(t *testing.T) {
+	buf, err := os.ReadFile("./testdata/commented.toml")
+	require.NoError(t, err)
+	doc, err := parseTOMLDocument(buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]tomlComment{
+		"app":                        {Above: []string{"# Hand-annotated config, keep these notes around."}},
+		"primary_region":             {Inline: "# closest to our database"},
+		"build":                      {Above: []string{"# Runs out of the local Dockerfile."}},
+		"env.LOG_LEVEL":              {Above: []string{"# Quiet by default, crank it up when debugging."}},
+		"http_service":               {Above: []string{"# Public traffic."}},
+		"http_service.internal_port": {Inline: "# matches the app's PORT"},
+	}, entryComments(doc))
+}
+
+func TestParseTOMLDocumentCommentsInArrays(t *testing.T) {
+	doc, err := parseTOMLDocument([]byte(`# Generated, edit with care.
+
+# Only the API for now.
+[[services]] # api
+  internal_port = 8080
+  ports = [
+    # The usual.
+    { port = 443, handlers = ["tls", "http"] }, # TLS
+  ]
+
+  # Health.
+  [[services.tcp_checks]]
+    interval = "15s"
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]tomlComment{
+		"services":            {Above: []string{"# Only the API for now."}, Inline: "# api"},
+		"services.tcp_checks": {Above: []string{"# Health."}},
+	}, entryComments(doc))
+	assert.Equal(t, []any{map[string]any{
+		"internal_port": int64(8080),
+		"ports": []any{map[string]any{
+			"port":     int64(443),
+			"handlers": []any{"tls", "http"},
+		}},
+		"tcp_checks": []any{map[string]any{"interval": "15s"}},
+	}}, doc.values["services"])
+	assert.Equal(t, 8, doc.positions["services[0].ports[0]"].Line)
+}
+
+// The document decoder has to produce exactly what toml.Unmarshal does for the patches to keep working.
+func TestParseTOMLDocumentMatchesUnmarshal(t *testing.T) {
+	paths, err := filepath.Glob("./testdata/*.toml")
+	require.NoError(t, err)
+
+	for _, path := range paths {
+		buf, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		want := map[string]any{}
+		if toml.Unmarshal(buf, &want) != nil {
+			continue
+		}
+		doc, err := parseTOMLDocument(buf)
+		require.NoError(t, err, path)
+		assert.Equal(t, want, doc.values, path)
+	}
+
+	buf := []byte(`
+hex = 0x1F
+big = 1_000_000
+float = -3.5e2
+infinity = inf
+offset = 1979-05-27 07:32:00-07:00
+date = 1979-05-27
+time = 07:32:00
+local = 1979-05-27T07:32:00
+a.b.c = [1, [2, 3], { d = 'literal' }]
+[[p.q]]
+  r = 1
+[[p.q]]
+  r = 2
+  [p.q.s]
+    t = true
+`)
+	want := map[string]any{}
+	require.NoError(t, toml.Unmarshal(buf, &want))
+	doc, err := parseTOMLDocument(buf)
+	require.NoError(t, err)
+	assert.Equal(t, want, doc.values)
+}
+
+func TestParseTOMLDocumentErrors(t *testing.T) {
+	cases := []struct {
+		doc    string
+		line   int
+		column int
+		msg    string
+	}{
+		{"app = 'foo'\napp = 'bar'\n", 2, 1, "key app is already defined"},
+		{"[env]\nA = '1'\n[env]\nB = '2'\n", 3, 2, "table env is already defined"},
+		{"[build]\nimage = 'foo'\n[build.image]\n", 3, 8, "key build.image is already defined"},
+		{"kill_timeout = 05\n", 1, 17, ""},
+		{"[env]\n  LOG_LEVEL = \"info\n", 2, 20, "basic strings cannot have new lines"},
+		{"env = { A = '1' }\n[env]\nB = '2'\n", 2, 2, "table env is defined inline, so it can't be extended"},
+		{"env = { A = '1' }\nenv.B = '2'\n", 2, 1, "table env is defined inline, so it can't be extended"},
+		{"[build]\nargs = { A = '1' }\n[build.args.nested]\n", 3, 8, "table build.args is defined inline, so it can't be extended"},
+		{"env.A = '1'\n[env]\nB = '2'\n", 2, 2, "table env is already defined"},
+		{"kill_timeout = 1__0\n", 1, 16, "number underscores must be between digits"},
+		{"[[vm]]\n  cpus = 2_\n", 2, 10, "number underscores must be between digits"},
+		{"swap_size_mb = 1.\n", 1, 16, "float decimal point must be between digits"},
+		{"swap_size_mb = .5\n", 1, 16, ""},
+	}
+	for _, tc := range cases {
+		_, err := parseTOMLDocument([]byte(tc.doc))
+		var derr *tomlDecodeError
+		require.ErrorAs(t, err, &derr, tc.doc)
+		assert.Equal(t, tc.line, derr.Line, tc.doc)
+		assert.Equal(t, tc.column, derr.Column, tc.doc)
+		assert.Contains(t, derr.Message, tc.msg, tc.doc)
+	}
+}
+
+func TestParseTOMLDocumentDottedKeys(t *testing.T) {
+	// Tables made by dotted keys can be extended by more dotted keys, and get sub-tables.
+	buf := []byte(`
+[build]
+args.A = "1"
+args.B = "2"
+[build.args.nested]
+C = "3"
+`)
+	want := map[string]any{}
+	require.NoError(t, toml.Unmarshal(buf, &want))
+	doc, err := parseTOMLDocument(buf)
+	require.NoError(t, err)
+	assert.Equal(t, want, doc.values)
+}
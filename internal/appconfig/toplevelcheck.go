@@ -6,7 +6,6 @@ import (
 	"slices"
 	"strings"
 
-	"github.com/samber/lo"
 	fly "github.com/superfly/fly-go"
 	"github.com/superfly/flyctl/internal/sentry"
 )
@@ -71,10 +70,7 @@ func (chk *ToplevelCheck) toMachineCheck() (*fly.MachineCheck, error) {
 		res.HTTPMethod = fly.Pointer(strings.ToUpper(*chk.HTTPMethod))
 	}
 	if len(chk.HTTPHeaders) > 0 {
-		res.HTTPHeaders = lo.MapToSlice(
-			chk.HTTPHeaders, func(k string, v string) fly.MachineHTTPHeader {
-				return fly.MachineHTTPHeader{Name: k, Values: []string{v}}
-			})
+		res.HTTPHeaders = machineHTTPHeaders(chk.HTTPHeaders)
 	}
 	return res, nil
 }
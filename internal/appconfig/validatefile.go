@@ -0,0 +1,239 @@
+package appconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+	fly "github.com/superfly/fly-go"
+)
+
+// KnownServiceHandlers are the handlers fly-proxy can apply to a service port.
+var KnownServiceHandlers = []string{"http", "https", "tls", "pg_tls", "proxy_proto"}
+
+// FileError is a problem found in an app config file, located by the line and column of the
+// key or table it concerns.
+type FileError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e FileError) Error() string {
+	return fmt.Sprintf("line %d column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// ValidateFile checks the TOML app config at path for syntax errors and the mistakes that
+// otherwise only show up as errors without a location once it's loaded: services without
+// an internal_port, concurrency of the wrong type, durations that don't parse and unknown
+// port handlers. It returns every problem found, in document order. Configs in other formats
+// aren't checked.
+func ValidateFile(path string) ([]FileError, error) {
+	if configFormat(path) != "toml" {
+		return nil, nil
+	}
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := parseTOMLDocument(doc)
+	if err != nil {
+		var derr *tomlDecodeError
+		if errors.As(err, &derr) {
+			return []FileError{{Line: derr.Line, Column: derr.Column, Message: derr.Message}}, nil
+		}
+		return nil, err
+	}
+
+	v := &fileValidator{positions: parsed.positions}
+	v.validate(parsed.values)
+	slices.SortStableFunc(v.errors, func(a, b FileError) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	return v.errors, nil
+}
+
+type fileValidator struct {
+	positions map[string]unstable.Position
+	errors    []FileError
+}
+
+// addf records a problem with the value at path, located at the closest enclosing key
+// that appears in the document.
+func (v *fileValidator) addf(path string, format string, a ...any) {
+	pos := unstable.Position{Line: 1, Column: 1}
+	for p := path; p != ""; p = parentTOMLPath(p) {
+		if found, ok := v.positions[p]; ok {
+			pos = found
+			break
+		}
+	}
+	v.errors = append(v.errors, FileError{
+		Line:    pos.Line,
+		Column:  pos.Column,
+		Message: fmt.Sprintf("%s: ", path) + fmt.Sprintf(format, a...),
+	})
+}
+
+func (v *fileValidator) validate(cfgMap map[string]any) {
+	if service, ok := cfgMap["http_service"].(map[string]any); ok {
+		v.validateService("http_service", service)
+	}
+	for i, service := range tomlTables(cfgMap["services"]) {
+		path := fmt.Sprintf("services[%d]", i)
+		v.validateService(path, service)
+		for j, port := range tomlTables(service["ports"]) {
+			v.validateHandlers(fmt.Sprintf("%s.ports[%d].handlers", path, j), port["handlers"])
+		}
+	}
+	v.validateDurations("", cfgMap)
+}
+
+func (v *fileValidator) validateService(path string, service map[string]any) {
+	if _, ok := service["internal_port"]; !ok {
+		v.addf(path, "missing internal_port")
+	}
+	switch concurrency := service["concurrency"].(type) {
+	case nil, map[string]any:
+	case string:
+		// The old "{soft},{hard}" form
+		if _, err := _patchService(map[string]any{"concurrency": concurrency}); err != nil {
+			v.addf(path+".concurrency", "%s", err)
+		}
+	default:
+		v.addf(path+".concurrency", "concurrency must be a table, got %s", tomlTypeName(concurrency))
+	}
+}
+
+func (v *fileValidator) validateHandlers(path string, raw any) {
+	handlers, ok := raw.([]any)
+	if raw != nil && !ok {
+		v.addf(path, "handlers must be an array of strings, got %s", tomlTypeName(raw))
+		return
+	}
+	for _, handler := range handlers {
+		if name, ok := handler.(string); !ok || !slices.Contains(KnownServiceHandlers, name) {
+			v.addf(path, "unknown handler %v, expected one of %s", handler, strings.Join(KnownServiceHandlers, ", "))
+		}
+	}
+}
+
+func (v *fileValidator) validateDurations(path string, raw any) {
+	switch cast := raw.(type) {
+	case map[string]any:
+		for key, value := range cast {
+			keyPath := joinTOMLPath(path, key)
+			if s, ok := value.(string); ok && s != "" && durationKeys[key] {
+				if _, err := time.ParseDuration(s); err != nil {
+					v.addf(keyPath, "invalid duration '%s'", s)
+				}
+				continue
+			}
+			v.validateDurations(keyPath, value)
+		}
+	case []any:
+		for i, value := range cast {
+			v.validateDurations(fmt.Sprintf("%s[%d]", path, i), value)
+		}
+	case []map[string]any:
+		for i, value := range cast {
+			v.validateDurations(fmt.Sprintf("%s[%d]", path, i), value)
+		}
+	}
+}
+
+// durationKeys holds the keys of every duration in the app config.
+var durationKeys = func() map[string]bool {
+	keys := map[string]bool{}
+	durationType := reflect.TypeOf(fly.Duration{})
+	seen := map[reflect.Type]bool{}
+
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || t == durationType || seen[t] {
+			return
+		}
+		seen[t] = true
+		for _, field := range reflect.VisibleFields(t) {
+			name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			ft := field.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft == durationType {
+				keys[name] = true
+			} else {
+				walk(field.Type)
+			}
+		}
+	}
+	walk(reflect.TypeOf(Config{}))
+	return keys
+}()
+
+func joinTOMLPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// parentTOMLPath strips the last key or array index from path.
+func parentTOMLPath(path string) string {
+	if strings.HasSuffix(path, "]") {
+		if i := strings.LastIndexByte(path, '['); i >= 0 {
+			return path[:i]
+		}
+	}
+	if i := strings.LastIndexByte(path, '.'); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// tomlTables returns the tables of an array of tables, skipping anything else.
+func tomlTables(raw any) []map[string]any {
+	switch cast := raw.(type) {
+	case []map[string]any:
+		return cast
+	case []any:
+		var tables []map[string]any
+		for _, item := range cast {
+			if table, ok := item.(map[string]any); ok {
+				tables = append(tables, table)
+			}
+		}
+		return tables
+	}
+	return nil
+}
+
+func tomlTypeName(value any) string {
+	switch value.(type) {
+	case string:
+		return "a string"
+	case int64, float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	case []any:
+		return "an array"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,35 @@
+package appconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFile(t *testing.T) {
+	errs, err := ValidateFile("./testdata/validate-file-services.toml")
+	require.NoError(t, err)
+	require.Len(t, errs, 5)
+
+	assert.Equal(t, FileError{Line: 3, Column: 2, Message: "http_service: missing internal_port"}, errs[0])
+	assert.Equal(t, FileError{Line: 9, Column: 3, Message: "services[0].concurrency: concurrency must be a table, got a number"}, errs[1])
+	assert.Equal(t, 13, errs[2].Line)
+	assert.Contains(t, errs[2].Message, "services[0].ports[0].handlers: unknown handler htttp")
+	assert.Equal(t, FileError{Line: 15, Column: 3, Message: "services[1]: missing internal_port"}, errs[3])
+	assert.Equal(t, FileError{Line: 19, Column: 5, Message: "services[1].tcp_checks[0].interval: invalid duration '15 seconds'"}, errs[4])
+	assert.Equal(t, "line 3 column 2: http_service: missing internal_port", errs[0].Error())
+}
+
+func TestValidateFileSyntaxError(t *testing.T) {
+	errs, err := ValidateFile("./testdata/validate-file-syntax.toml")
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Equal(t, 4, errs[0].Line)
+}
+
+func TestValidateFileValid(t *testing.T) {
+	errs, err := ValidateFile("./testdata/full-reference.toml")
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+}
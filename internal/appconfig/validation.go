@@ -4,13 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"mime"
+	"net/url"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/docker/go-units"
 	"github.com/google/shlex"
 	"github.com/logrusorgru/aurora"
+	"github.com/samber/lo"
 	fly "github.com/superfly/fly-go"
 	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/sentry"
@@ -21,6 +26,9 @@ var (
 	MachinesDeployStrategies = []string{"canary", "rolling", "immediate", "bluegreen"}
 )
 
+// regionCodeRegexp matches the three letter codes of Fly.io regions, like ord or ams.
+var regionCodeRegexp = regexp.MustCompile(`^[a-z]{3}$`)
+
 func (cfg *Config) Validate(ctx context.Context) (err error, extra_info string) {
 	if cfg == nil {
 		return errors.New("App config file not found"), ""
@@ -36,6 +44,7 @@ func (cfg *Config) Validate(ctx context.Context) (err error, extra_info string)
 		cfg.validateConsoleCommand,
 		cfg.validateMounts,
 		cfg.validateRestartPolicy,
+		cfg.validateStaticsOptions,
 	}
 
 	extra_info = fmt.Sprintf("Validating %s\n", cfg.ConfigFilePath())
@@ -189,6 +198,32 @@ func (cfg *Config) validateServicesSection() (extraInfo string, err error) {
 	return extraInfo, err
 }
 
+// checkForceHTTPS returns an error if a service port redirects to HTTPS, but none of the service's
+// ports serve TLS for it to redirect to. [http_service] always serves TLS on 443, so it isn't checked.
+func (cfg *Config) checkForceHTTPS() error {
+	for i, service := range cfg.Services {
+		servesTLS := lo.SomeBy(service.Ports, func(port fly.MachinePort) bool {
+			return slices.Contains(port.Handlers, "tls") || slices.Contains(port.Handlers, "https")
+		})
+		if servesTLS {
+			continue
+		}
+		for j, port := range service.Ports {
+			if !port.ForceHTTPS {
+				continue
+			}
+			portName := fmt.Sprintf("#%d", j+1)
+			if port.Port != nil {
+				portName = fmt.Sprint(*port.Port)
+			}
+			return fmt.Errorf("[[services]] #%d (internal_port %d) port %s sets force_https, but none of the service's ports have a 'tls' or 'https' handler to redirect to; "+
+				"add a port with handlers = [\"tls\", \"http\"] or remove force_https",
+				i+1, service.InternalPort, portName)
+		}
+	}
+	return nil
+}
+
 func validateServiceCheckDurations(interval, timeout, gracePeriod *fly.Duration, proto string) (extraInfo string) {
 	extraInfo += validateSingleServiceCheckDuration(interval, false, proto, "an interval")
 	extraInfo += validateSingleServiceCheckDuration(timeout, false, proto, "a timeout")
@@ -355,3 +390,85 @@ func (cfg *Config) validateRestartPolicy() (extraInfo string, err error) {
 
 	return
 }
+
+func (cfg *Config) validateStaticsOptions() (extraInfo string, err error) {
+	if cfg.StaticsOptions == nil {
+		return
+	}
+	if checkErr := cfg.checkStaticsOptions(); checkErr != nil {
+		extraInfo += checkErr.Error() + "\n"
+		err = ValidationError
+	}
+	if maxAge := cfg.StaticsOptions.MaxAge; maxAge != nil && maxAge.Duration < 0 {
+		extraInfo += fmt.Sprintf("statics_options.max_age must not be negative, got %s\n", maxAge)
+		err = ValidationError
+	}
+	for i, rule := range cfg.StaticsOptions.CacheRules {
+		if rule.Glob == "" || !doublestar.ValidatePattern(rule.Glob) {
+			extraInfo += fmt.Sprintf("statics_options.cache_rules[%d] has an invalid glob '%s'\n", i, rule.Glob)
+			err = ValidationError
+		}
+		if rule.MaxAge == nil || rule.MaxAge.Duration < 0 {
+			extraInfo += fmt.Sprintf("statics_options.cache_rules[%d] must set a non-negative max_age\n", i)
+			err = ValidationError
+		}
+	}
+	exts := lo.Keys(cfg.StaticsOptions.ContentTypes)
+	slices.Sort(exts)
+	for _, ext := range exts {
+		if len(ext) < 2 || !strings.HasPrefix(ext, ".") || strings.ContainsAny(ext, "/\\") {
+			extraInfo += fmt.Sprintf("statics_options.content_types has an invalid extension '%s', expected one like '.wasm'\n", ext)
+			err = ValidationError
+		}
+		if _, _, parseErr := mime.ParseMediaType(cfg.StaticsOptions.ContentTypes[ext]); parseErr != nil {
+			extraInfo += fmt.Sprintf("statics_options.content_types has an invalid content type '%s' for '%s'\n", cfg.StaticsOptions.ContentTypes[ext], ext)
+			err = ValidationError
+		}
+	}
+	if region := cfg.StaticsOptions.Region; region != "" && !regionCodeRegexp.MatchString(region) {
+		extraInfo += fmt.Sprintf("statics_options.region must be a region code like 'ord', got '%s'\n", region)
+		err = ValidationError
+	}
+	if prefix := cfg.StaticsOptions.Prefix; prefix != "" && !validStaticsPrefix(prefix) {
+		extraInfo += fmt.Sprintf("statics_options.prefix must be a key prefix like 'cdn/statics', got '%s'\n", prefix)
+		err = ValidationError
+	}
+	if storage := cfg.StaticsOptions.Storage; storage != nil {
+		if u, parseErr := url.Parse(storage.Endpoint); parseErr != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			extraInfo += fmt.Sprintf("statics_options.storage.endpoint must be an http(s) URL, got '%s'\n", storage.Endpoint)
+			err = ValidationError
+		}
+		if storage.Bucket == "" {
+			extraInfo += "statics_options.storage.bucket must be set\n"
+			err = ValidationError
+		}
+	}
+	return
+}
+
+// checkStaticsOptions returns an error if [statics_options] keeps fewer than one version,
+// which would prune the statics of the release being deployed.
+func (cfg *Config) checkStaticsOptions() error {
+	if cfg.StaticsOptions == nil {
+		return nil
+	}
+	if keep := cfg.StaticsOptions.KeepVersions; keep != nil && *keep < 1 {
+		return fmt.Errorf("statics_options.keep_versions must be at least 1, got %d", *keep)
+	}
+	return nil
+}
+
+// validStaticsPrefix reports whether prefix can start the keys of pushed statics: one or more
+// path segments, none of them empty, "." or "..".
+func validStaticsPrefix(prefix string) bool {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" || strings.ContainsAny(prefix, "\\ ") {
+		return false
+	}
+	for _, segment := range strings.Split(prefix, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
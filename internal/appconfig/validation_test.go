@@ -2,11 +2,14 @@ package appconfig
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"testing"
 
 	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
 	"github.com/superfly/flyctl/internal/cmdutil/preparers"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/logger"
@@ -24,9 +27,22 @@ func _getValidationContext(t *testing.T) context.Context {
 	return ctx
 }
 
-func TestConfig_ValidateGroups(t *testing.T) {
-	cfg, err := LoadConfig("./testdata/validate-groups.toml")
+// loadUncheckedConfig reads the config at path without the checks LoadConfig runs on fly.toml,
+// the way configs fetched from the platform reach Validate.
+func loadUncheckedConfig(t *testing.T, path string) *Config {
+	buf, err := os.ReadFile(path)
+	require.NoError(t, err)
+	cfg, err := unmarshalTOML(buf, os.LookupEnv)
 	require.NoError(t, err)
+	cfg.configFilePath = path
+	return cfg
+}
+
+func TestConfig_ValidateGroups(t *testing.T) {
+	_, err := LoadConfig("./testdata/validate-groups.toml")
+	require.ErrorContains(t, err, "[[mounts]] #1 (source 'foo') must set an absolute destination, got 'bar'")
+
+	cfg := loadUncheckedConfig(t, "./testdata/validate-groups.toml")
 	require.NoError(t, cfg.SetMachinesPlatform())
 	cfg.Deploy = &Deploy{Strategy: "canary"}
 
@@ -43,14 +59,17 @@ func TestConfig_ValidateGroups(t *testing.T) {
 }
 
 func TestConfig_ValidateMounts(t *testing.T) {
-	cfg, err := LoadConfig("./testdata/validate-mounts.toml")
-	require.NoError(t, err)
+	_, err := LoadConfig("./testdata/validate-mounts.toml")
+	require.ErrorContains(t, err, "[[mounts]] #2 (source 'data') has an invalid initial_size 'unparseable'")
+
+	cfg := loadUncheckedConfig(t, "./testdata/validate-mounts.toml")
 	require.NoError(t, cfg.SetMachinesPlatform())
 
 	ctx := _getValidationContext(t)
 	err, x := cfg.Validate(ctx)
 	require.Error(t, err, x)
 	require.Contains(t, x, "has an initial_size '15Mb' value which is smaller than 1GB")
+	require.Contains(t, x, "mount 'data' with initial_size 'unparseable' will fail because of")
 
 	err, x = cfg.ValidateGroups(ctx, []string{"app"})
 	require.Error(t, err, x)
@@ -71,3 +90,120 @@ func TestConfig_ValidateServices(t *testing.T) {
 	err, x = cfg.ValidateGroups(ctx, []string{"success"})
 	require.NoErrorf(t, err, x)
 }
+
+func TestConfig_ValidateStaticsOptions(t *testing.T) {
+	cfg := NewConfig()
+	assert.Equal(t, DefaultStaticsKeepVersions, cfg.StaticsKeepVersions())
+	_, err := cfg.validateStaticsOptions()
+	require.NoError(t, err)
+
+	cfg.StaticsOptions = &StaticsOptions{KeepVersions: fly.Pointer(10)}
+	assert.Equal(t, 10, cfg.StaticsKeepVersions())
+	_, err = cfg.validateStaticsOptions()
+	require.NoError(t, err)
+
+	cfg.StaticsOptions.KeepVersions = fly.Pointer(0)
+	x, err := cfg.validateStaticsOptions()
+	require.ErrorIs(t, err, ValidationError)
+	require.Contains(t, x, "statics_options.keep_versions must be at least 1, got 0")
+}
+
+func TestConfig_ValidateStaticsCacheRules(t *testing.T) {
+	cfg := NewConfig()
+	cfg.StaticsOptions = &StaticsOptions{
+		MaxAge: fly.MustParseDuration("1h"),
+		CacheRules: []StaticsCacheRule{
+			{Glob: "assets/*", MaxAge: fly.MustParseDuration("8760h")},
+		},
+	}
+	_, err := cfg.validateStaticsOptions()
+	require.NoError(t, err)
+
+	cfg.StaticsOptions.CacheRules = append(cfg.StaticsOptions.CacheRules,
+		StaticsCacheRule{Glob: "[", MaxAge: fly.MustParseDuration("1h")},
+		StaticsCacheRule{Glob: "*.html"},
+	)
+	x, err := cfg.validateStaticsOptions()
+	require.ErrorIs(t, err, ValidationError)
+	require.Contains(t, x, "statics_options.cache_rules[1] has an invalid glob '['")
+	require.Contains(t, x, "statics_options.cache_rules[2] must set a non-negative max_age")
+}
+
+func TestConfig_ValidateStaticsStorage(t *testing.T) {
+	cfg := NewConfig()
+	assert.Nil(t, cfg.StaticsStorage())
+
+	cfg.StaticsOptions = &StaticsOptions{Storage: &StaticsStorage{
+		Endpoint: "https://s3.us-east-1.amazonaws.com",
+		Bucket:   "my-statics",
+	}}
+	assert.Equal(t, "my-statics", cfg.StaticsStorage().Bucket)
+	_, err := cfg.validateStaticsOptions()
+	require.NoError(t, err)
+
+	cfg.StaticsOptions.Storage = &StaticsStorage{Endpoint: "s3.example.com"}
+	x, err := cfg.validateStaticsOptions()
+	require.ErrorIs(t, err, ValidationError)
+	require.Contains(t, x, "statics_options.storage.endpoint must be an http(s) URL, got 's3.example.com'")
+	require.Contains(t, x, "statics_options.storage.bucket must be set")
+}
+
+func TestConfig_ValidateStaticsContentTypes(t *testing.T) {
+	cfg := NewConfig()
+	assert.Nil(t, cfg.StaticsContentTypes())
+
+	cfg.StaticsOptions = &StaticsOptions{ContentTypes: map[string]string{
+		".WASM": "application/wasm",
+		".map":  "application/json; charset=utf-8",
+	}}
+	assert.Equal(t, map[string]string{
+		".wasm": "application/wasm",
+		".map":  "application/json; charset=utf-8",
+	}, cfg.StaticsContentTypes())
+	_, err := cfg.validateStaticsOptions()
+	require.NoError(t, err)
+
+	cfg.StaticsOptions.ContentTypes = map[string]string{
+		"wasm":  "application/wasm",
+		".bin":  "not a content type",
+		".good": "text/plain",
+	}
+	x, err := cfg.validateStaticsOptions()
+	require.ErrorIs(t, err, ValidationError)
+	require.Contains(t, x, "statics_options.content_types has an invalid extension 'wasm', expected one like '.wasm'")
+	require.Contains(t, x, "statics_options.content_types has an invalid content type 'not a content type' for '.bin'")
+	require.NotContains(t, x, ".good")
+}
+
+func TestConfig_ValidateStaticsRegion(t *testing.T) {
+	cfg := NewConfig()
+	cfg.PrimaryRegion = "ord"
+	assert.Equal(t, "ord", cfg.StaticsRegion())
+
+	cfg.StaticsOptions = &StaticsOptions{Region: "ams"}
+	assert.Equal(t, "ams", cfg.StaticsRegion())
+	_, err := cfg.validateStaticsOptions()
+	require.NoError(t, err)
+
+	cfg.StaticsOptions.Region = "Amsterdam"
+	x, err := cfg.validateStaticsOptions()
+	require.ErrorIs(t, err, ValidationError)
+	require.Contains(t, x, "statics_options.region must be a region code like 'ord', got 'Amsterdam'")
+}
+
+func TestConfig_ValidateStaticsPrefix(t *testing.T) {
+	cfg := NewConfig()
+	assert.Equal(t, "fly-statics", cfg.StaticsPrefix())
+
+	cfg.StaticsOptions = &StaticsOptions{Prefix: "/cdn/statics/"}
+	assert.Equal(t, "cdn/statics", cfg.StaticsPrefix())
+	_, err := cfg.validateStaticsOptions()
+	require.NoError(t, err)
+
+	for _, prefix := range []string{"cdn//statics", "../statics", "/", "my statics"} {
+		cfg.StaticsOptions.Prefix = prefix
+		x, err := cfg.validateStaticsOptions()
+		require.ErrorIs(t, err, ValidationError, prefix)
+		require.Contains(t, x, fmt.Sprintf("statics_options.prefix must be a key prefix like 'cdn/statics', got '%s'", prefix))
+	}
+}
@@ -0,0 +1,124 @@
+package imgsrc
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	dockerclient "github.com/docker/docker/client"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// ErrImagePathNotFound is returned by CopyDirFromImage when the image doesn't have the path.
+var ErrImagePathNotFound = errors.New("path not found in image")
+
+// CopyDirFromImage copies the directory srcPath out of imageRef into dest, a local directory
+// that's created if needed. It creates a container from the image without starting it, using the
+// local Docker daemon, and pulls the image from the Fly registry if the daemon doesn't have it.
+func CopyDirFromImage(ctx context.Context, imageRef, srcPath, dest string) error {
+	docker, err := NewLocalDockerClient()
+	if err != nil {
+		return fmt.Errorf("a local Docker daemon is needed to copy files out of the image: %w", err)
+	}
+	defer docker.Close() // skipcq: GO-S2307
+
+	if _, _, err := docker.ImageInspectWithRaw(ctx, imageRef); dockerclient.IsErrNotFound(err) {
+		terminal.Debugf("Pulling %s to copy %s out of it\n", imageRef, srcPath)
+		pull, err := docker.ImagePull(ctx, imageRef, image.PullOptions{
+			RegistryAuth: flyRegistryAuth(config.Tokens(ctx).Docker()),
+			Platform:     "linux/amd64",
+		})
+		if err != nil {
+			return fmt.Errorf("failed to pull %s: %w", imageRef, err)
+		}
+		_, err = io.Copy(io.Discard, pull)
+		pull.Close()
+		if err != nil {
+			return fmt.Errorf("failed to pull %s: %w", imageRef, err)
+		}
+	} else if err != nil {
+		return err
+	}
+
+	// The command is never run, it only has to be set for images that don't have one.
+	created, err := docker.ContainerCreate(ctx, &container.Config{Image: imageRef, Cmd: []string{"true"}}, nil, nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("failed to create a container from %s: %w", imageRef, err)
+	}
+	defer func() {
+		if err := docker.ContainerRemove(context.WithoutCancel(ctx), created.ID, container.RemoveOptions{Force: true}); err != nil {
+			terminal.Debugf("Failed to remove container %s: %v\n", created.ID, err)
+		}
+	}()
+
+	archive, stat, err := docker.CopyFromContainer(ctx, created.ID, srcPath)
+	if dockerclient.IsErrNotFound(err) {
+		return fmt.Errorf("%s: %w", srcPath, ErrImagePathNotFound)
+	} else if err != nil {
+		return fmt.Errorf("failed to copy %s out of %s: %w", srcPath, imageRef, err)
+	}
+	defer archive.Close() // skipcq: GO-S2307
+
+	if !stat.Mode.IsDir() {
+		return fmt.Errorf("%s is not a directory in %s", srcPath, imageRef)
+	}
+	return untarDir(archive, stat.Name, dest)
+}
+
+// untarDir writes the contents of the directory root of a tar archive to dest.
+func untarDir(r io.Reader, root, dest string) error {
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return err
+	}
+
+	archive := tar.NewReader(r)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		name, ok := strings.CutPrefix(filepath.ToSlash(filepath.Clean(header.Name)), root+"/")
+		if !ok || name == ".." || strings.HasPrefix(name, "../") {
+			continue
+		}
+		target := filepath.Join(dest, filepath.FromSlash(name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0o755)
+		case tar.TypeReg:
+			err = writeTarFile(archive, target)
+		case tar.TypeSymlink:
+			err = os.Symlink(header.Linkname, target)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func writeTarFile(r io.Reader, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
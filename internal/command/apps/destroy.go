@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/superfly/flyctl/gql"
 	"github.com/superfly/flyctl/internal/command/deploy/statics"
 	"github.com/superfly/flyctl/internal/flag/completion"
 	"github.com/superfly/flyctl/internal/flyutil"
@@ -78,17 +77,12 @@ func RunDestroy(ctx context.Context) error {
 			return err
 		}
 
-		bucket, err := statics.FindBucket(ctx, app, org)
-		if err != nil {
-			return err
-		}
-
-		if bucket != nil {
-			_, err = gql.DeleteAddOn(ctx, client.GenqClient(), bucket.Name)
-			if err != nil {
-				return err
-			}
-			fmt.Fprintf(io.Out, "Destroyed statics bucket %s\n", bucket.Name)
+		// Failing to clean up the statics bucket shouldn't keep the app from being destroyed
+		switch bucket, err := statics.DeleteBucket(ctx, app, org); {
+		case err != nil:
+			fmt.Fprintf(io.ErrOut, "Warning: failed to destroy the statics bucket of app %s, you may need to delete it with `fly storage destroy`: %v\n", appName, err)
+		case bucket != "":
+			fmt.Fprintf(io.Out, "Destroyed statics bucket %s\n", bucket)
 		}
 
 		if err := client.DeleteApp(ctx, appName); err != nil {
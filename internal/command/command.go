@@ -99,6 +99,7 @@ func newRunE(fn Runner, preparers ...preparers.Preparer) func(*cobra.Command, []
 		ctx := cmd.Context()
 		ctx = NewContext(ctx, cmd)
 		ctx = flag.NewContext(ctx, cmd.Flags())
+		ctx = appconfig.WithRemoteConfigCache(ctx)
 
 		// run the common preparers
 		if ctx, err = prepare(ctx, commonPreparers...); err != nil {
@@ -626,8 +627,9 @@ func LoadAppConfigIfPresent(ctx context.Context) (context.Context, error) {
 	}
 
 	logger := logger.FromContext(ctx)
+	overlay := env.First(appconfig.OverlayEnvKey)
 	for _, path := range appConfigFilePaths(ctx) {
-		switch cfg, err := appconfig.LoadConfig(path); {
+		switch cfg, err := appconfig.LoadConfigWithOverlay(path, overlay); {
 		case err == nil:
 			logger.Debugf("app config loaded from %s", path)
 			if err := cfg.SetMachinesPlatform(); err != nil {
@@ -661,6 +663,7 @@ func appConfigFilePaths(ctx context.Context) (paths []string) {
 		filepath.Join(wd, appconfig.DefaultConfigFileName),
 		filepath.Join(wd, strings.Replace(appconfig.DefaultConfigFileName, ".toml", ".json", 1)),
 		filepath.Join(wd, strings.Replace(appconfig.DefaultConfigFileName, ".toml", ".yaml", 1)),
+		filepath.Join(wd, strings.Replace(appconfig.DefaultConfigFileName, ".toml", ".yml", 1)),
 	)
 
 	return
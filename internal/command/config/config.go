@@ -19,6 +19,8 @@ func New() (cmd *cobra.Command) {
 		newSave(),
 		newValidate(),
 		newEnv(),
+		newSecretsRefs(),
+		newSchema(),
 	)
 	return
 }
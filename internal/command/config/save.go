@@ -42,6 +42,10 @@ retrieved from the Fly service and saved in TOML format.`
 			Name:        "yaml",
 			Description: "Output the configuration in YAML format",
 		},
+		flag.Bool{
+			Name:        "redact",
+			Description: fmt.Sprintf("Replace the raw values of files and env values that look like secrets with '%s'", appconfig.RedactedPlaceholder),
+		},
 	)
 	return
 }
@@ -97,7 +101,11 @@ func runSave(ctx context.Context) error {
 		return err
 	}
 
-	return cfg.WriteToDisk(ctx, configfilename)
+	var opts []appconfig.WriteOption
+	if flag.GetBool(ctx, "redact") {
+		opts = append(opts, appconfig.RedactSecrets())
+	}
+	return cfg.WriteToDisk(ctx, configfilename, opts...)
 }
 
 func keepPrevSections(ctx context.Context, currentCfg *appconfig.Config, configPath string) error {
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newSchema() (cmd *cobra.Command) {
+	const (
+		short = "Print a JSON Schema for fly.toml"
+		long  = `Prints a JSON Schema describing the settings of fly.toml. Editors that support
+JSON Schema for TOML files can use it to complete and check fly.toml as you type.`
+	)
+	cmd = command.New("schema", short, long, runSchema)
+	cmd.Args = cobra.NoArgs
+	return
+}
+
+func runSchema(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+	return render.JSON(io.Out, appconfig.JSONSchema())
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyutil"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newSecretsRefs() (cmd *cobra.Command) {
+	const (
+		short = "List the secrets referenced by an app's config file"
+		long  = `Lists every secret referenced by an application's config file, such as
+files whose contents come from a secret. Use --check to verify that each
+referenced secret is set on the app.`
+	)
+	cmd = command.New("secrets-refs", short, long, runSecretsRefs,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+	cmd.Args = cobra.NoArgs
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Bool{
+			Name:        "check",
+			Description: "Check that every referenced secret is set on the app",
+		},
+	)
+	return
+}
+
+func runSecretsRefs(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+	cfg := appconfig.ConfigFromContext(ctx)
+	if cfg == nil {
+		return fmt.Errorf("no app config found, use --config to point at one")
+	}
+
+	refs := cfg.SecretRefs()
+
+	if !flag.GetBool(ctx, "check") {
+		rows := lo.Map(refs, func(ref appconfig.SecretRef, _ int) []string {
+			return []string{ref.Name, ref.GuestPath, strings.Join(ref.Processes, ", ")}
+		})
+		return render.Table(io.Out, "", rows, "Secret", "Guest Path", "Processes")
+	}
+
+	apiClient := flyutil.ClientFromContext(ctx)
+	secrets, err := apiClient.GetAppSecrets(ctx, appconfig.NameFromContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	missing := missingSecretRefs(refs, secrets)
+	rows := lo.Map(refs, func(ref appconfig.SecretRef, _ int) []string {
+		status := "set"
+		if lo.Contains(missing, ref.Name) {
+			status = "missing"
+		}
+		return []string{ref.Name, ref.GuestPath, strings.Join(ref.Processes, ", "), status}
+	})
+	if err := render.Table(io.Out, "", rows, "Secret", "Guest Path", "Processes", "Status"); err != nil {
+		return err
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("the app config references secrets that aren't set: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// missingSecretRefs returns the names of referenced secrets that aren't set on the app.
+func missingSecretRefs(refs []appconfig.SecretRef, secrets []fly.Secret) []string {
+	set := lo.SliceToMap(secrets, func(s fly.Secret) (string, bool) {
+		return s.Name, true
+	})
+
+	var missing []string
+	for _, ref := range refs {
+		if !set[ref.Name] && !lo.Contains(missing, ref.Name) {
+			missing = append(missing, ref.Name)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestMissingSecretRefs(t *testing.T) {
+	cfg := appconfig.NewConfig()
+	cfg.Files = []appconfig.File{
+		{GuestPath: "/etc/tls/key.pem", SecretName: "TLS_KEY"},
+		{GuestPath: "/etc/tls/cert.pem", SecretName: "TLS_CERT"},
+		{GuestPath: "/etc/worker/key.pem", SecretName: "TLS_KEY", Processes: []string{"worker"}},
+	}
+
+	refs := cfg.SecretRefs()
+	assert.Equal(t, []string{"TLS_KEY", "TLS_CERT", "TLS_KEY"}, []string{refs[0].Name, refs[1].Name, refs[2].Name})
+
+	secrets := []fly.Secret{{Name: "TLS_CERT"}, {Name: "UNRELATED"}}
+	assert.Equal(t, []string{"TLS_KEY"}, missingSecretRefs(refs, secrets))
+
+	secrets = append(secrets, fly.Secret{Name: "TLS_KEY"})
+	assert.Empty(t, missingSecretRefs(refs, secrets))
+}
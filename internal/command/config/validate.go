@@ -2,8 +2,11 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 
+	"github.com/logrusorgru/aurora"
 	"github.com/spf13/cobra"
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/command"
@@ -30,6 +33,21 @@ func runValidate(ctx context.Context) error {
 	io := iostreams.FromContext(ctx)
 	cfg := appconfig.ConfigFromContext(ctx)
 
+	// Point at the offending lines first, loading errors don't say where they come from.
+	if cfg != nil {
+		problems, err := appconfig.ValidateFile(cfg.ConfigFilePath())
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if len(problems) > 0 {
+			fmt.Fprintf(io.Out, "Validating %s\n", cfg.ConfigFilePath())
+			for _, problem := range problems {
+				fmt.Fprintf(io.Out, "   %s%s\n", aurora.Red("✘"), problem)
+			}
+			return errors.New("App configuration is not valid")
+		}
+	}
+
 	if err := cfg.SetMachinesPlatform(); err != nil {
 		return err
 	}
@@ -2,6 +2,7 @@ package deploy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -16,6 +17,7 @@ import (
 	"github.com/superfly/flyctl/internal/buildinfo"
 	"github.com/superfly/flyctl/internal/cmdutil"
 	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/deploy/statics"
 	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/ctrlc"
 	"github.com/superfly/flyctl/internal/flag"
@@ -23,6 +25,7 @@ import (
 	"github.com/superfly/flyctl/internal/flyutil"
 	"github.com/superfly/flyctl/internal/launchdarkly"
 	"github.com/superfly/flyctl/internal/metrics"
+	"github.com/superfly/flyctl/internal/prompt"
 	"github.com/superfly/flyctl/internal/render"
 	"github.com/superfly/flyctl/internal/sentry"
 	"github.com/superfly/flyctl/internal/tracing"
@@ -174,6 +177,42 @@ var CommonFlags = flag.Set{
 		Description: "Number of times to retry a deployment if it fails",
 		Default:     "auto",
 	},
+	flag.String{
+		Name:        "statics-notify-url",
+		Description: "URL to POST a JSON summary to after statics have been pushed to Tigris",
+	},
+	flag.String{
+		Name:        "statics-key-case",
+		Description: "Normalize statics object keys for case-insensitive hosts: 'check' fails when two files differ only by case, 'lower' also lowercases every key",
+	},
+	flag.String{
+		Name:        "statics-asset-index",
+		Description: "Upload a JSON index of every static path, size and content type to this key in the release's statics, e.g. _assets.json",
+	},
+	flag.String{
+		Name:        "statics-manifest",
+		Description: "Write a JSON manifest of the statics pushed to Tigris, with the key, size, content type and hash of each file, to this path",
+	},
+	flag.Bool{
+		Name:        "statics-staged",
+		Description: "Upload statics to a staging location first, and only publish them once every file has been uploaded",
+	},
+	flag.Bool{
+		Name:        "statics-from-image",
+		Description: "Copy [[statics]] with an absolute guest_path out of the built image and push them to Tigris, instead of serving them from the image",
+	},
+	flag.Bool{
+		Name:        "statics-resume",
+		Description: "Keep the statics uploaded by a deploy that fails, and resume an interrupted statics push instead of uploading every file again",
+	},
+	flag.Bool{
+		Name:        "no-statics",
+		Description: "Don't push [[statics]] to Tigris, and keep serving the statics of the previous deploy",
+	},
+	flag.Bool{
+		Name:        "statics-dry-run",
+		Description: "Show which statics would be pushed to Tigris and how much would be uploaded, then exit without deploying",
+	},
 }
 
 type Command struct {
@@ -344,6 +383,19 @@ func DeployWithConfig(ctx context.Context, appConfig *appconfig.Config, userID i
 		}
 	}
 
+	if flag.GetBool(ctx, "statics-dry-run") {
+		return printStaticsPlan(ctx, appConfig, appCompact)
+	}
+
+	if err := confirmRootStatics(ctx, appConfig, forceYes); err != nil {
+		return err
+	}
+	if !flag.GetBool(ctx, "no-statics") {
+		if err := confirmLargeStatics(ctx, appConfig, forceYes); err != nil {
+			return err
+		}
+	}
+
 	httpFailover := flag.GetHTTPSFailover(ctx)
 	usingWireguard := flag.GetWireguard(ctx)
 	recreateBuilder := flag.GetRecreateBuilder(ctx)
@@ -586,6 +638,14 @@ func deployToMachines(
 		ProcessGroups:         processGroups,
 		DeployRetries:         deployRetries,
 		BuildID:               img.BuildID,
+		StaticsNotifyURL:      flag.GetString(ctx, "statics-notify-url"),
+		StaticsKeyCase:        flag.GetString(ctx, "statics-key-case"),
+		StaticsAssetIndex:     flag.GetString(ctx, "statics-asset-index"),
+		StaticsStaged:         flag.GetBool(ctx, "statics-staged"),
+		StaticsManifest:       flag.GetString(ctx, "statics-manifest"),
+		StaticsFromImage:      flag.GetBool(ctx, "statics-from-image"),
+		NoStatics:             flag.GetBool(ctx, "no-statics"),
+		StaticsResume:         flag.GetBool(ctx, "statics-resume"),
 	}
 
 	var path = flag.GetString(ctx, "export-manifest")
@@ -621,6 +681,68 @@ func deployToMachines(
 	return err
 }
 
+// confirmRootStatics makes sure the user meant to serve statics from the root
+// URL prefix of an app that also has services, since those statics would answer
+// every request instead of the app's machines.
+func confirmRootStatics(ctx context.Context, appConfig *appconfig.Config, forceYes bool) error {
+	statics := appConfig.RootStatics()
+	if len(statics) == 0 || forceYes {
+		return nil
+	}
+
+	io := iostreams.FromContext(ctx)
+	for _, static := range statics {
+		fmt.Fprintf(io.ErrOut, "%s statics from '%s' are served at url_prefix '%s', which shadows every route served by the app's services\n", aurora.Yellow("WARN"), static.GuestPath, static.UrlPrefix)
+	}
+
+	switch confirmed, err := prompt.Confirm(ctx, "Are you sure you want to serve statics from the root of the app?"); {
+	case err == nil:
+		if !confirmed {
+			return errors.New("deploy aborted, statics would shadow the app's services")
+		}
+		return nil
+	case prompt.IsNonInteractive(err):
+		return prompt.NonInteractiveError("yes flag must be specified when not running interactively and statics are served at the root url_prefix")
+	default:
+		return err
+	}
+}
+
+// confirmLargeStatics warns when the statics that would be pushed to tigris are unusually
+// large, and asks whether to push them anyway. Without a terminal to ask on, the deploy
+// carries on after the warning.
+func confirmLargeStatics(ctx context.Context, appConfig *appconfig.Config, forceYes bool) error {
+	if len(appConfig.Statics) == 0 {
+		return nil
+	}
+	plan, err := statics.Plan(appConfig)
+	if err != nil {
+		return err
+	}
+	warning, err := statics.LargePushWarning(plan)
+	if err != nil || warning == "" {
+		return err
+	}
+
+	io := iostreams.FromContext(ctx)
+	fmt.Fprintf(io.ErrOut, "%s %s\n", aurora.Yellow("WARN"), warning)
+	if forceYes {
+		return nil
+	}
+
+	switch confirmed, err := prompt.Confirm(ctx, "Push these statics anyway?"); {
+	case err == nil:
+		if !confirmed {
+			return errors.New("deploy aborted, statics are larger than expected")
+		}
+		return nil
+	case prompt.IsNonInteractive(err):
+		return nil
+	default:
+		return err
+	}
+}
+
 // determineAppConfig fetches the app config from a local file, or in its absence, from the API
 func determineAppConfig(ctx context.Context) (cfg *appconfig.Config, err error) {
 	io := iostreams.FromContext(ctx)
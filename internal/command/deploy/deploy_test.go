@@ -9,11 +9,15 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/flapsutil"
 	"github.com/superfly/flyctl/internal/flyutil"
 	"github.com/superfly/flyctl/internal/inmem"
 	"github.com/superfly/flyctl/internal/logger"
+	"github.com/superfly/flyctl/internal/prompt"
 	"github.com/superfly/flyctl/internal/task"
 	"github.com/superfly/flyctl/iostreams"
 )
@@ -63,6 +67,27 @@ func TestCommand_Execute(t *testing.T) {
 	}
 }
 
+func TestConfirmRootStatics(t *testing.T) {
+	ios, _, _, errOut := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+
+	cfg := appconfig.NewConfig()
+	cfg.Statics = []appconfig.Static{{GuestPath: "/app/public", UrlPrefix: "/"}}
+
+	// Static-only apps can serve from the root
+	require.NoError(t, confirmRootStatics(ctx, cfg, false))
+	assert.Empty(t, errOut.String())
+
+	// Apps with services need confirmation
+	cfg.HTTPService = &appconfig.HTTPService{InternalPort: 8080}
+	err := confirmRootStatics(ctx, cfg, false)
+	require.Error(t, err)
+	assert.True(t, prompt.IsNonInteractive(err))
+	assert.Contains(t, errOut.String(), "/app/public")
+
+	require.NoError(t, confirmRootStatics(ctx, cfg, true))
+}
+
 // copyFS writes the contents of a file system to a destination path on disk.
 func copyFS(fsys fs.FS, dst string) error {
 	return fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
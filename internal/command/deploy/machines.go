@@ -76,6 +76,14 @@ type MachineDeploymentArgs struct {
 	RestartMaxRetries     int
 	DeployRetries         int
 	BuildID               string
+	StaticsNotifyURL      string
+	StaticsKeyCase        string
+	StaticsAssetIndex     string
+	StaticsStaged         bool
+	StaticsManifest       string
+	StaticsFromImage      bool
+	NoStatics             bool
+	StaticsResume         bool
 }
 
 func argsFromManifest(manifest *DeployManifest, app *fly.AppCompact) MachineDeploymentArgs {
@@ -109,6 +117,14 @@ func argsFromManifest(manifest *DeployManifest, app *fly.AppCompact) MachineDepl
 		RestartPolicy:         manifest.RestartPolicy,
 		RestartMaxRetries:     manifest.RestartMaxRetries,
 		DeployRetries:         manifest.DeployRetries,
+		StaticsNotifyURL:      manifest.StaticsNotifyURL,
+		StaticsKeyCase:        manifest.StaticsKeyCase,
+		StaticsAssetIndex:     manifest.StaticsAssetIndex,
+		StaticsStaged:         manifest.StaticsStaged,
+		StaticsManifest:       manifest.StaticsManifest,
+		StaticsFromImage:      manifest.StaticsFromImage,
+		NoStatics:             manifest.NoStatics,
+		StaticsResume:         manifest.StaticsResume,
 	}
 }
 
@@ -152,6 +168,14 @@ type machineDeployment struct {
 	tigrisStatics         *statics.DeployerState
 	deployRetries         int
 	buildID               string
+	staticsNotifyURL      string
+	staticsKeyCase        string
+	staticsAssetIndex     string
+	staticsStaged         bool
+	staticsManifest       string
+	staticsFromImage      bool
+	noStatics             bool
+	staticsResume         bool
 }
 
 func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (_ MachineDeployment, err error) {
@@ -279,6 +303,14 @@ func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (_ Ma
 		processGroups:         args.ProcessGroups,
 		deployRetries:         args.DeployRetries,
 		buildID:               args.BuildID,
+		staticsNotifyURL:      args.StaticsNotifyURL,
+		staticsKeyCase:        args.StaticsKeyCase,
+		staticsAssetIndex:     args.StaticsAssetIndex,
+		staticsStaged:         args.StaticsStaged,
+		staticsManifest:       args.StaticsManifest,
+		staticsFromImage:      args.StaticsFromImage,
+		noStatics:             args.NoStatics,
+		staticsResume:         args.StaticsResume,
 	}
 	if err := md.setStrategy(); err != nil {
 		tracing.RecordError(span, err, "failed to set strategy")
@@ -50,27 +50,15 @@ func (md *machineDeployment) DeployMachinesApp(ctx context.Context) error {
 
 	onInterruptContext := context.WithoutCancel(ctx)
 
-	// TODO(allison): Ensure that if we *aren't* using tigris here, we remove the previously attached bucket from
-	//                the app's services (if one exists).
-	if md.staticsUseTigris(ctx) {
-
-		fullApp, err := md.apiClient.GetApp(ctx, md.app.Name)
-		if err != nil {
-			return err
-		}
-		fullOrg, err := md.apiClient.GetOrganizationBySlug(ctx, md.app.Organization.Slug)
-		if err != nil {
-			return err
-		}
-
-		md.tigrisStatics = statics.Deployer(md.appConfig, fullApp, fullOrg, md.releaseVersion)
-		if err := md.tigrisStatics.Configure(ctx); err != nil {
-			return err
-		}
+	if err := md.staticsInitialize(ctx); err != nil {
+		return err
 	}
 
 	if err := md.updateReleaseInBackend(ctx, "running", nil); err != nil {
 		tracing.RecordError(span, err, "failed to update release")
+		if md.tigrisStatics != nil {
+			md.tigrisStatics.RestoreOriginalStatics()
+		}
 		return fmt.Errorf("failed to set release status to 'running': %w", err)
 	}
 
@@ -1404,13 +1392,97 @@ func (md *machineDeployment) checkDNS(ctx context.Context) error {
 	}
 }
 
-func (md *machineDeployment) staticsUseTigris(ctx context.Context) bool {
+// staticsInitialize sets up the push of the app's statics to Tigris, if any are pushed.
+// With --no-statics, the statics the app's machines already serve from Tigris are kept instead.
+func (md *machineDeployment) staticsInitialize(ctx context.Context) error {
+	if md.noStatics {
+		md.keepDeployedStatics()
+		return nil
+	}
+
+	// TODO(allison): Ensure that if we *aren't* using tigris here, we remove the previously attached bucket from
+	//                the app's services (if one exists).
+	if !md.staticsUseTigris(ctx) {
+		return nil
+	}
+
+	fullApp, err := md.apiClient.GetApp(ctx, md.app.Name)
+	if err != nil {
+		return err
+	}
+	fullOrg, err := md.apiClient.GetOrganizationBySlug(ctx, md.app.Organization.Slug)
+	if err != nil {
+		return err
+	}
 
-	for _, static := range md.appConfig.Statics {
-		if statics.StaticIsCandidateForTigrisPush(static) {
-			return true
+	md.tigrisStatics = statics.Deployer(md.appConfig, fullApp, fullOrg, md.releaseVersion, statics.Options{
+		NotifyURL:     md.staticsNotifyURL,
+		KeyCase:       statics.KeyCase(md.staticsKeyCase),
+		AssetIndexKey: md.staticsAssetIndex,
+		Staged:        md.staticsStaged,
+		ManifestPath:  md.staticsManifest,
+		Image:         md.staticsImage(),
+		Resume:        md.staticsResume,
+	})
+	if err := md.tigrisStatics.Configure(ctx); err != nil {
+		return err
+	}
+	return md.tigrisStatics.SkipPushedVersions(ctx)
+}
+
+// keepDeployedStatics swaps the statics that would be pushed to Tigris for the ones the app's machines
+// already serve from it, so that a deploy that doesn't push statics leaves them as they are.
+func (md *machineDeployment) keepDeployedStatics() {
+	kept := lo.Filter(md.appConfig.Statics, func(static appconfig.Static, _ int) bool {
+		return !statics.StaticIsCandidateForTigrisPush(static, md.staticsFromImage)
+	})
+	if len(kept) == len(md.appConfig.Statics) {
+		return
+	}
+
+	// Pushed statics are served from under the statics prefix of the app's bucket.
+	prefix := "/" + md.appConfig.StaticsPrefix() + "/"
+	var deployed []appconfig.Static
+	for _, lm := range md.machineSet.GetMachines() {
+		if config := lm.Machine().Config; config != nil {
+			for _, static := range config.Statics {
+				if static.TigrisBucket != "" && strings.HasPrefix(static.GuestPath, prefix) {
+					deployed = append(deployed, appconfig.Static{
+						GuestPath:     static.GuestPath,
+						UrlPrefix:     static.UrlPrefix,
+						TigrisBucket:  static.TigrisBucket,
+						IndexDocument: static.IndexDocument,
+					})
+				}
+			}
 		}
+		if len(deployed) > 0 {
+			break
+		}
+	}
+	if len(deployed) == 0 {
+		fmt.Fprintf(md.io.ErrOut, "%s Statics aren't pushed to Tigris with --no-statics, and no machine serves any pushed before, so they won't be served\n", md.colorize.Yellow("WARNING"))
 	}
+	md.appConfig.Statics = append(kept, deployed...)
+}
 
-	return false
+func (md *machineDeployment) staticsUseTigris(ctx context.Context) bool {
+
+	err := statics.CheckCandidates(md.appConfig.Statics, md.staticsFromImage)
+	var noCandidates *statics.NoCandidatesError
+	if errors.As(err, &noCandidates) {
+		// Nothing is pushed, but say why rather than leaving the statics silently unserved from tigris.
+		fmt.Fprintf(md.io.ErrOut, "%s %v\n", md.colorize.Yellow("WARNING"), noCandidates)
+		return false
+	}
+
+	return len(md.appConfig.Statics) > 0
+}
+
+// staticsImage is the image that statics with an absolute guest_path are copied out of, if they are.
+func (md *machineDeployment) staticsImage() string {
+	if !md.staticsFromImage {
+		return ""
+	}
+	return md.img
 }
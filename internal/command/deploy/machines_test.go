@@ -1,6 +1,7 @@
 package deploy
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -9,6 +10,7 @@ import (
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/buildinfo"
 	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
 )
 
 func stabMachineDeployment(appConfig *appconfig.Config) (*machineDeployment, error) {
@@ -387,3 +389,50 @@ func Test_resolveUpdatedMachineConfig_restartOnlyProcessGroup(t *testing.T) {
 		},
 	}, got)
 }
+
+func TestStaticsInitializeNoStatics(t *testing.T) {
+	appConfig := &appconfig.Config{
+		AppName: "my-cool-app",
+		Statics: []appconfig.Static{
+			{GuestPath: "public", UrlPrefix: "/"},
+			{GuestPath: "/app/docs", UrlPrefix: "/docs"},
+		},
+	}
+	md, err := stabMachineDeployment(appConfig)
+	require.NoError(t, err)
+	md.noStatics = true
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+	md.machineSet = machine.NewMachineSet(nil, ios, []*fly.Machine{{
+		ID: "m1",
+		Config: &fly.MachineConfig{Statics: []*fly.Static{
+			{GuestPath: "/fly-statics/my-cool-app/4/0/", UrlPrefix: "/", TigrisBucket: "my-bucket"},
+			{GuestPath: "/app/docs", UrlPrefix: "/docs"},
+		}},
+	}}, true)
+
+	// There's no API client, so looking up the app or org to set up the push would panic.
+	require.NoError(t, md.staticsInitialize(ctx))
+	assert.Nil(t, md.tigrisStatics)
+	assert.Equal(t, []appconfig.Static{
+		{GuestPath: "/app/docs", UrlPrefix: "/docs"},
+		{GuestPath: "/fly-statics/my-cool-app/4/0/", UrlPrefix: "/", TigrisBucket: "my-bucket"},
+	}, md.appConfig.Statics)
+}
+
+func TestStaticsInitializeNoStaticsFirstDeploy(t *testing.T) {
+	md, err := stabMachineDeployment(&appconfig.Config{
+		AppName: "my-cool-app",
+		Statics: []appconfig.Static{{GuestPath: "public", UrlPrefix: "/"}},
+	})
+	require.NoError(t, err)
+	md.noStatics = true
+	ios, _, _, errOut := iostreams.Test()
+	md.io = ios
+	md.colorize = ios.ColorScheme()
+
+	require.NoError(t, md.staticsInitialize(context.Background()))
+	assert.Nil(t, md.tigrisStatics)
+	assert.Empty(t, md.appConfig.Statics)
+	assert.Contains(t, errOut.String(), "no machine serves any pushed before")
+}
@@ -49,6 +49,14 @@ type DeployManifest struct {
 	RestartPolicy         *fly.MachineRestartPolicy `json:"restart_policy,omitempty"`
 	RestartMaxRetries     int                       `json:"restart_max_retrie,omitempty"`
 	DeployRetries         int                       `json:"deploy_retries,omitempty"`
+	StaticsNotifyURL      string                    `json:"statics_notify_url,omitempty"`
+	StaticsKeyCase        string                    `json:"statics_key_case,omitempty"`
+	StaticsAssetIndex     string                    `json:"statics_asset_index,omitempty"`
+	StaticsStaged         bool                      `json:"statics_staged,omitempty"`
+	StaticsManifest       string                    `json:"statics_manifest,omitempty"`
+	StaticsFromImage      bool                      `json:"statics_from_image,omitempty"`
+	NoStatics             bool                      `json:"no_statics,omitempty"`
+	StaticsResume         bool                      `json:"statics_resume,omitempty"`
 }
 
 func NewManifest(AppName string, config *appconfig.Config, args MachineDeploymentArgs) *DeployManifest {
@@ -83,6 +91,14 @@ func NewManifest(AppName string, config *appconfig.Config, args MachineDeploymen
 		RestartPolicy:         args.RestartPolicy,
 		RestartMaxRetries:     args.RestartMaxRetries,
 		DeployRetries:         args.DeployRetries,
+		StaticsNotifyURL:      args.StaticsNotifyURL,
+		StaticsKeyCase:        args.StaticsKeyCase,
+		StaticsAssetIndex:     args.StaticsAssetIndex,
+		StaticsStaged:         args.StaticsStaged,
+		StaticsManifest:       args.StaticsManifest,
+		StaticsFromImage:      args.StaticsFromImage,
+		NoStatics:             args.NoStatics,
+		StaticsResume:         args.StaticsResume,
 	}
 }
 
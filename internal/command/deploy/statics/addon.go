@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"maps"
 	"strconv"
 	"strings"
 
@@ -13,6 +14,7 @@ import (
 	extensions "github.com/superfly/flyctl/internal/command/extensions/core"
 	"github.com/superfly/flyctl/internal/flyutil"
 	"github.com/superfly/flyctl/internal/haikunator"
+	"github.com/superfly/flyctl/internal/logger"
 	"github.com/superfly/flyctl/iostreams"
 	"github.com/superfly/macaroon/flyio"
 	"github.com/superfly/macaroon/resset"
@@ -48,31 +50,48 @@ func FindBucket(ctx context.Context, app *fly.App, org *fly.Organization) (*gql.
 	return nil, nil
 }
 
-func (deployer *DeployerState) ensureBucketCreated(ctx context.Context) (tokenizedAuth string, retErr error) {
-
-	client := flyutil.ClientFromContext(ctx)
-
-	bucket, err := FindBucket(ctx, deployer.app, deployer.org)
-	if err != nil {
+// DeleteBucket deletes the statics bucket that flyctl created for the app, returning its name.
+// Returns "", nil if the app has no statics bucket.
+func DeleteBucket(ctx context.Context, app *fly.App, org *fly.Organization) (string, error) {
+	bucket, err := FindBucket(ctx, app, org)
+	if err != nil || bucket == nil {
 		return "", err
 	}
-	if bucket != nil {
-		meta := bucket.Metadata.(map[string]interface{})
-		deployer.bucket = meta[staticsMetaBucketName].(string)
-		return meta[staticsMetaTokenizedAuth].(string), nil
+	if !isManagedBucket(bucket.Metadata) {
+		return "", fmt.Errorf("storage add-on %s wasn't created by flyctl for statics, refusing to delete it", bucket.Name)
 	}
 
-	// Using string comparison here because we might want to use BigInt app IDs in the future.
-	internalAppIdStr := strconv.FormatUint(uint64(deployer.app.InternalNumericID), 10)
+	client := flyutil.ClientFromContext(ctx)
+	if _, err := gql.DeleteAddOn(ctx, client.GenqClient(), bucket.Name); err != nil {
+		return "", err
+	}
+	return bucket.Name, nil
+}
 
-	extName := fmt.Sprintf("%s-statics-%s", deployer.appConfig.AppName, haikunator.Haikunator().String())
+// isManagedBucket reports whether the add-on metadata is the metadata that flyctl writes
+// when it creates a statics bucket, so that buckets attached by users are never touched.
+func isManagedBucket(metadata interface{}) bool {
+	meta, ok := metadata.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for _, key := range []string{staticsMetaKeyAppId, staticsMetaTokenizedAuth, staticsMetaBucketName} {
+		if value, ok := meta[key].(string); !ok || value == "" {
+			return false
+		}
+	}
+	return true
+}
 
+// bucketExtensionParams describes the Tigris extension to provision as the statics bucket, in the
+// region set in statics_options, or else the app's primary region.
+func (deployer *DeployerState) bucketExtensionParams(extName string) extensions.ExtensionParams {
 	params := extensions.ExtensionParams{
 		Organization:         deployer.org,
 		Provider:             "tigris",
 		Options:              gql.AddOnOptions{},
 		ErrorCaptureCallback: nil,
-		OverrideRegion:       deployer.appConfig.PrimaryRegion,
+		OverrideRegion:       deployer.appConfig.StaticsRegion(),
 		OverrideName:         &extName,
 	}
 	params.Options["website"] = map[string]interface{}{
@@ -81,6 +100,33 @@ func (deployer *DeployerState) ensureBucketCreated(ctx context.Context) (tokeniz
 	params.Options["accelerate"] = false
 	// TODO(allison): Make sure we still need this when virtual services drop :)
 	params.Options["public"] = true
+	return params
+}
+
+func (deployer *DeployerState) ensureBucketCreated(ctx context.Context) (tokenizedAuth string, retErr error) {
+
+	client := flyutil.ClientFromContext(ctx)
+
+	bucket, err := FindBucket(ctx, deployer.app, deployer.org)
+	if err != nil {
+		return "", err
+	}
+	if bucket != nil {
+		meta := bucket.Metadata.(map[string]interface{})
+		deployer.bucket = meta[staticsMetaBucketName].(string)
+		deployer.logEvent(logger.Debug, "bucket_found", "addon", bucket.Name)
+		if deployer.sealedForOrg(meta) {
+			return meta[staticsMetaTokenizedAuth].(string), nil
+		}
+		return deployer.resealBucketAuth(ctx, bucket.Name)
+	}
+
+	// Using string comparison here because we might want to use BigInt app IDs in the future.
+	internalAppIdStr := strconv.FormatUint(uint64(deployer.app.InternalNumericID), 10)
+
+	extName := fmt.Sprintf("%s-statics-%s", deployer.appConfig.AppName, haikunator.Haikunator().String())
+
+	params := deployer.bucketExtensionParams(extName)
 
 	extCtx := iostreams.NewContext(ctx, &iostreams.IOStreams{
 		In:     io.NopCloser(&bytes.Buffer{}),
@@ -120,20 +166,19 @@ func (deployer *DeployerState) ensureBucketCreated(ctx context.Context) (tokeniz
 	secrets := ext.Data.Environment.(map[string]interface{})
 
 	deployer.bucket = secrets["BUCKET_NAME"].(string)
+	deployer.logEvent(logger.Info, "bucket_provisioned", "addon", extName)
 
 	tokenizedKey, err := deployer.tokenizeTigrisSecrets(secrets)
 	if err != nil {
 		return "", err
 	}
 
-	// TODO(allison): I'd really like ProvisionExtension to return the extension's ID, but for now we can just refetch it
-	extFull, err := gql.GetAddOn(ctx, client.GenqClient(), extName, string(gql.AddOnTypeTigris))
-
 	// Update the addon with the tokenized key and the name of the app
-	_, err = gql.UpdateAddOn(ctx, client.GenqClient(), extFull.AddOn.Id, extFull.AddOn.AddOnPlan.Id, []string{}, extFull.AddOn.Options, map[string]interface{}{
+	_, err = gql.UpdateAddOn(ctx, client.GenqClient(), ext.ID, ext.PlanID, []string{}, ext.Options, map[string]interface{}{
 		staticsMetaKeyAppId:      internalAppIdStr,
 		staticsMetaTokenizedAuth: tokenizedKey,
 		staticsMetaBucketName:    deployer.bucket,
+		staticsMetaOrgId:         deployer.org.InternalNumericID,
 	})
 	if err != nil {
 		return "", err
@@ -141,6 +186,55 @@ func (deployer *DeployerState) ensureBucketCreated(ctx context.Context) (tokeniz
 	return tokenizedKey, nil
 }
 
+// sealedForOrg reports whether the bucket's tokenized auth was sealed for the deployer's org.
+// The org ID is baked into the sealed token, so it stops validating once the app moves to another org.
+// Buckets created before the org ID was recorded are treated as stale.
+func (deployer *DeployerState) sealedForOrg(meta map[string]interface{}) bool {
+	orgId, _ := meta[staticsMetaOrgId].(string)
+	return orgId != "" && orgId == deployer.org.InternalNumericID
+}
+
+// resealBucketAuth seals a fresh token for the bucket's credentials, for the deployer's current org,
+// and stores it in the add-on's metadata.
+func (deployer *DeployerState) resealBucketAuth(ctx context.Context, addOnName string) (string, error) {
+	client := flyutil.ClientFromContext(ctx).GenqClient()
+
+	resp, err := gql.GetAddOn(ctx, client, addOnName, string(gql.AddOnTypeTigris))
+	if err != nil {
+		return "", err
+	}
+	secrets, ok := resp.AddOn.Environment.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("statics bucket %s has no credentials to re-seal for org %s", addOnName, deployer.org.Slug)
+	}
+	meta, _ := resp.AddOn.Metadata.(map[string]interface{})
+
+	meta, err = deployer.resealedMetadata(meta, secrets)
+	if err != nil {
+		return "", err
+	}
+	_, err = gql.UpdateAddOn(ctx, client, resp.AddOn.Id, resp.AddOn.AddOnPlan.Id, resp.AddOn.ReadRegions, resp.AddOn.Options, meta)
+	if err != nil {
+		return "", fmt.Errorf("failed to update statics bucket %s with re-sealed auth: %w", addOnName, err)
+	}
+	return meta[staticsMetaTokenizedAuth].(string), nil
+}
+
+// resealedMetadata returns a copy of the bucket's add-on metadata, with its tokenized auth re-sealed for the deployer's org.
+func (deployer *DeployerState) resealedMetadata(meta, secrets map[string]interface{}) (map[string]interface{}, error) {
+	tokenizedKey, err := deployer.tokenizeTigrisSecrets(secrets)
+	if err != nil {
+		return nil, err
+	}
+	resealed := maps.Clone(meta)
+	if resealed == nil {
+		resealed = map[string]interface{}{}
+	}
+	resealed[staticsMetaTokenizedAuth] = tokenizedKey
+	resealed[staticsMetaOrgId] = deployer.org.InternalNumericID
+	return resealed, nil
+}
+
 func (deployer *DeployerState) tokenizeTigrisSecrets(secrets map[string]interface{}) (string, error) {
 
 	orgId, err := strconv.ParseUint(deployer.org.InternalNumericID, 10, 64)
@@ -161,5 +255,15 @@ func (deployer *DeployerState) tokenizeTigrisSecrets(secrets map[string]interfac
 		RequestValidators: []tokenizer.RequestValidator{tokenizer.AllowHosts(fmt.Sprintf("%s.%s", deployer.bucket, tigrisHostname))},
 	}
 
-	return secret.Seal(tokenizerSealKey)
+	tokenizerCfg, err := tokenizerConfigFromEnv()
+	if err != nil {
+		return "", err
+	}
+	// Only where the credentials were sealed for is logged, never the sealed token itself.
+	sealed, err := secret.Seal(tokenizerCfg.sealKey)
+	if err != nil {
+		return "", err
+	}
+	deployer.logEvent(logger.Debug, "auth_sealed", "org", deployer.org.Slug, "tokenizer", tokenizerCfg.url.Host)
+	return sealed, nil
 }
@@ -0,0 +1,79 @@
+package statics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestIsManagedBucket(t *testing.T) {
+	assert.True(t, isManagedBucket(map[string]interface{}{
+		staticsMetaKeyAppId:      "1234",
+		staticsMetaTokenizedAuth: "sealed",
+		staticsMetaBucketName:    "my-app-statics",
+	}))
+
+	// Buckets that only share the app ID weren't created for statics
+	assert.False(t, isManagedBucket(map[string]interface{}{
+		staticsMetaKeyAppId: "1234",
+	}))
+	assert.False(t, isManagedBucket(map[string]interface{}{
+		staticsMetaKeyAppId:      "1234",
+		staticsMetaTokenizedAuth: "",
+		staticsMetaBucketName:    "my-app-statics",
+	}))
+	assert.False(t, isManagedBucket(nil))
+}
+
+func TestResealAfterOrgMove(t *testing.T) {
+	meta := map[string]interface{}{
+		staticsMetaKeyAppId:      "1234",
+		staticsMetaTokenizedAuth: "sealed-for-old-org",
+		staticsMetaBucketName:    "my-app-statics",
+		staticsMetaOrgId:         "100",
+	}
+	deployer := &DeployerState{
+		app:    &fly.App{InternalNumericID: 1234},
+		org:    &fly.Organization{Slug: "new-org", InternalNumericID: "200"},
+		bucket: "my-app-statics",
+	}
+	assert.False(t, deployer.sealedForOrg(meta))
+
+	resealed, err := deployer.resealedMetadata(meta, map[string]interface{}{
+		"AWS_ACCESS_KEY_ID":     "tid_access",
+		"AWS_SECRET_ACCESS_KEY": "tsec_secret",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "200", resealed[staticsMetaOrgId])
+	assert.NotEmpty(t, resealed[staticsMetaTokenizedAuth])
+	assert.NotEqual(t, "sealed-for-old-org", resealed[staticsMetaTokenizedAuth])
+	assert.Equal(t, "my-app-statics", resealed[staticsMetaBucketName])
+	assert.True(t, deployer.sealedForOrg(resealed))
+	assert.True(t, isManagedBucket(resealed))
+
+	// The original metadata is left alone
+	assert.Equal(t, "100", meta[staticsMetaOrgId])
+
+	// Buckets from before the org ID was recorded are re-sealed too
+	delete(meta, staticsMetaOrgId)
+	assert.False(t, deployer.sealedForOrg(meta))
+}
+
+func TestBucketExtensionParamsRegion(t *testing.T) {
+	cfg := appconfig.NewConfig()
+	cfg.AppName = "my-app"
+	cfg.PrimaryRegion = "ord"
+	deployer := &DeployerState{appConfig: cfg, org: &fly.Organization{Slug: "personal"}}
+
+	params := deployer.bucketExtensionParams("my-app-statics")
+	assert.Equal(t, "ord", params.OverrideRegion)
+	assert.Equal(t, "tigris", params.Provider)
+	assert.Equal(t, "my-app-statics", *params.OverrideName)
+
+	cfg.StaticsOptions = &appconfig.StaticsOptions{Region: "ams"}
+	params = deployer.bucketExtensionParams("my-app-statics")
+	assert.Equal(t, "ams", params.OverrideRegion)
+}
@@ -0,0 +1,35 @@
+package statics
+
+import (
+	"fmt"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+// cacheControl returns the Cache-Control header for a file, given its slash-separated
+// path relative to the static's directory, or nil if no caching rule applies.
+// Rules are checked in order and the first match wins, falling back to the default max-age.
+// Globs are matched against the whole path, with "**" matching any number of directories.
+func cacheControl(cfg *appconfig.Config, file string) *string {
+	if cfg == nil || cfg.StaticsOptions == nil {
+		return nil
+	}
+	opts := cfg.StaticsOptions
+	maxAge := opts.MaxAge
+	for _, rule := range opts.CacheRules {
+		if matched, _ := doublestar.Match(rule.Glob, file); matched {
+			maxAge = rule.MaxAge
+			break
+		}
+	}
+	if maxAge == nil {
+		return nil
+	}
+
+	header := "no-cache"
+	if seconds := int64(maxAge.Seconds()); seconds > 0 {
+		header = fmt.Sprintf("public, max-age=%d", seconds)
+	}
+	return &header
+}
@@ -0,0 +1,77 @@
+package statics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestCacheControl(t *testing.T) {
+	cfg := appconfig.NewConfig()
+	assert.Nil(t, cacheControl(cfg, "index.html"))
+
+	cfg.StaticsOptions = &appconfig.StaticsOptions{
+		MaxAge: fly.MustParseDuration("1h"),
+		CacheRules: []appconfig.StaticsCacheRule{
+			{Glob: "assets/*", MaxAge: fly.MustParseDuration("8760h")},
+			{Glob: "*.html", MaxAge: fly.MustParseDuration("0s")},
+			{Glob: "assets/*.css", MaxAge: fly.MustParseDuration("1m")},
+		},
+	}
+	assert.Equal(t, "public, max-age=31536000", lo.FromPtr(cacheControl(cfg, "assets/app.css")))
+	assert.Equal(t, "no-cache", lo.FromPtr(cacheControl(cfg, "index.html")))
+	assert.Equal(t, "public, max-age=3600", lo.FromPtr(cacheControl(cfg, "docs/index.html")))
+	assert.Equal(t, "public, max-age=3600", lo.FromPtr(cacheControl(cfg, "robots.txt")))
+
+	cfg.StaticsOptions.MaxAge = nil
+	assert.Nil(t, cacheControl(cfg, "robots.txt"))
+}
+
+func TestCacheControlHashedAssets(t *testing.T) {
+	cfg := appconfig.NewConfig()
+	cfg.StaticsOptions = &appconfig.StaticsOptions{
+		MaxAge: fly.MustParseDuration("1h"),
+		CacheRules: []appconfig.StaticsCacheRule{
+			{Glob: "**/*.html", MaxAge: fly.MustParseDuration("0s")},
+			{Glob: "**/*.[0-9a-f][0-9a-f][0-9a-f][0-9a-f][0-9a-f][0-9a-f].{js,css}", MaxAge: fly.MustParseDuration("8760h")},
+		},
+	}
+	assert.Equal(t, "public, max-age=31536000", lo.FromPtr(cacheControl(cfg, "app.abc123.js")))
+	assert.Equal(t, "public, max-age=31536000", lo.FromPtr(cacheControl(cfg, "assets/css/site.0f9e8d.css")))
+	assert.Equal(t, "no-cache", lo.FromPtr(cacheControl(cfg, "index.html")))
+	assert.Equal(t, "no-cache", lo.FromPtr(cacheControl(cfg, "docs/guide/index.html")))
+	// Unhashed assets fall back to the default.
+	assert.Equal(t, "public, max-age=3600", lo.FromPtr(cacheControl(cfg, "app.js")))
+}
+
+func TestUploadDirectoryCacheControl(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "assets"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte("<html></html>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "assets", "app.js"), []byte("1"), 0o644))
+
+	cfg := appconfig.NewConfig()
+	cfg.StaticsOptions = &appconfig.StaticsOptions{
+		MaxAge:     fly.MustParseDuration("0s"),
+		CacheRules: []appconfig.StaticsCacheRule{{Glob: "assets/*", MaxAge: fly.MustParseDuration("24h")}},
+	}
+	client := newMockS3()
+	deployer := &DeployerState{s3: client, bucket: "bucket", appConfig: cfg}
+	require.NoError(t, deployer.uploadDirectory(context.Background(), "root/0/", "", dir))
+
+	headers := map[string]string{}
+	for _, put := range client.puts {
+		headers[*put.Key] = lo.FromPtr(put.CacheControl)
+	}
+	assert.Equal(t, map[string]string{
+		"root/0/index.html":    "no-cache",
+		"root/0/assets/app.js": "public, max-age=86400",
+	}, headers)
+}
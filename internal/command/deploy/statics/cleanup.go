@@ -0,0 +1,62 @@
+package statics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/env"
+)
+
+const (
+	cleanupTimeoutEnvKey = "FLY_STATICS_CLEANUP_TIMEOUT"
+
+	// Cleaning up a failed push gets baseCleanupTimeout, plus cleanupTimeoutPerThousand
+	// for every thousand files that were uploaded.
+	baseCleanupTimeout        = 5 * time.Second
+	cleanupTimeoutPerThousand = 5 * time.Second
+)
+
+// defaultCleanupTimeout is how long to spend removing a failed push of uploaded files.
+func defaultCleanupTimeout(uploaded int64) time.Duration {
+	return baseCleanupTimeout + time.Duration(uploaded/1000)*cleanupTimeoutPerThousand
+}
+
+// cleanupTimeoutFromEnv returns how long to spend removing a failed push of uploaded files.
+func cleanupTimeoutFromEnv(uploaded int64) (time.Duration, error) {
+	value := env.First(cleanupTimeoutEnvKey)
+	if value == "" {
+		return defaultCleanupTimeout(uploaded), nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid %s value '%s', expected a positive duration like 30s", cleanupTimeoutEnvKey, value)
+	}
+	return timeout, nil
+}
+
+// countObjects counts the objects left under the given prefixes.
+func (deployer *DeployerState) countObjects(ctx context.Context, prefixes []string) (int, error) {
+	count := 0
+	for _, prefix := range prefixes {
+		if !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+		paginator := s3.NewListObjectsV2Paginator(deployer.s3, &s3.ListObjectsV2Input{
+			Bucket: &deployer.bucket,
+			Prefix: fly.Pointer(prefix),
+		})
+		for paginator.HasMorePages() {
+			listOutput, err := paginator.NextPage(ctx)
+			if err != nil {
+				return 0, err
+			}
+			count += len(listOutput.Contents)
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,118 @@
+package statics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestCleanupTimeoutFromEnv(t *testing.T) {
+	t.Setenv(cleanupTimeoutEnvKey, "")
+	got, err := cleanupTimeoutFromEnv(0)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, got)
+	got, err = cleanupTimeoutFromEnv(12_345)
+	require.NoError(t, err)
+	assert.Equal(t, 65*time.Second, got)
+
+	t.Setenv(cleanupTimeoutEnvKey, "2m")
+	got, err = cleanupTimeoutFromEnv(12_345)
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, got)
+
+	for _, value := range []string{"0s", "-1m", "soon"} {
+		t.Setenv(cleanupTimeoutEnvKey, value)
+		_, err := cleanupTimeoutFromEnv(0)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestCleanupAfterFailureManyObjects(t *testing.T) {
+	client := newMockS3()
+	for i := 0; i < 2500; i++ {
+		client.addObject(fmt.Sprintf("fly-statics/my-app/7/0/file-%04d.txt", i), "hello")
+	}
+	for i := 0; i < 1200; i++ {
+		client.addObject(fmt.Sprintf("fly-statics-staging/my-app/7/0/file-%04d.txt", i), "hello")
+	}
+	client.addObject("fly-statics/my-app/6/0/index.html", "previous release")
+
+	deployer := &DeployerState{
+		appConfig:      &appconfig.Config{AppName: "my-app"},
+		releaseVersion: 7,
+		opts:           Options{Staged: true},
+		s3:             client,
+		bucket:         "bucket",
+		root:           "fly-statics/my-app/7",
+	}
+
+	// A canceled push is still cleaned up.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	deployer.CleanupAfterFailure(ctx)
+
+	assert.Equal(t, []string{"fly-statics/my-app/6/0/index.html"}, client.keys())
+	assert.Equal(t, int64(3700), deployer.deletedObjects.Load())
+	for _, batch := range client.deletes {
+		assert.LessOrEqual(t, len(batch.Delete.Objects), 1000)
+	}
+}
+
+func TestCleanupAfterFailureRestoresStatics(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("<html></html>"), 0o644))
+	chdir(t, dir)
+
+	original := []appconfig.Static{
+		{GuestPath: "/app/docs", UrlPrefix: "/docs"},
+		{GuestPath: "public", UrlPrefix: "/", IndexDocument: "index.html"},
+	}
+	client := newMockS3()
+	client.PutObjectFunc = func(ctx context.Context, params *s3.PutObjectInput) error {
+		return statusError(403)
+	}
+	deployer := &DeployerState{
+		appConfig:      &appconfig.Config{AppName: "my-app", Statics: original},
+		releaseVersion: 2,
+		s3:             client,
+		bucket:         "bucket",
+		root:           "fly-statics/my-app/2",
+	}
+	deployer.takeOverStatics()
+	assert.Equal(t, original[:1], deployer.appConfig.Statics)
+
+	require.Error(t, deployer.Push(context.Background()))
+	assert.Equal(t, original, deployer.appConfig.Statics)
+}
+
+func TestRestoreOriginalStaticsAfterPush(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("<html></html>"), 0o644))
+	chdir(t, dir)
+
+	original := []appconfig.Static{{GuestPath: "public", UrlPrefix: "/"}}
+	deployer := &DeployerState{
+		appConfig:      &appconfig.Config{AppName: "my-app", Statics: original},
+		releaseVersion: 2,
+		s3:             newMockS3(),
+		bucket:         "bucket",
+		root:           "fly-statics/my-app/2",
+	}
+	deployer.takeOverStatics()
+	require.NoError(t, deployer.Push(context.Background()))
+	assert.Equal(t, "/fly-statics/my-app/2/0/", deployer.appConfig.Statics[0].GuestPath)
+
+	// The deploy failed after the push.
+	deployer.CleanupAfterFailure(context.Background())
+	assert.Equal(t, original, deployer.appConfig.Statics)
+}
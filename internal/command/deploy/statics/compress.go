@@ -0,0 +1,55 @@
+package statics
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"strings"
+)
+
+// compressibleContentTypes are the non-text content types worth compressing.
+// Everything under text/ is compressible too.
+var compressibleContentTypes = map[string]bool{
+	"application/javascript":    true,
+	"application/json":          true,
+	"application/manifest+json": true,
+	"application/wasm":          true,
+	"application/xml":           true,
+	"image/svg+xml":             true,
+	"image/x-icon":              true,
+}
+
+// isCompressible reports whether a file of the given content type is likely to shrink when gzipped.
+// Images, fonts and archives are already compressed and are left alone.
+func isCompressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || compressibleContentTypes[mediaType]
+}
+
+// gzipIfSmaller reads the whole of reader and gzips it.
+// It returns nil if the compressed body isn't any smaller than the original,
+// in which case reader has been rewound and should be uploaded as is.
+//
+// Only gzip is used: the bucket holds a single object per key, and unlike brotli,
+// gzip is accepted by every client that might request it.
+func gzipIfSmaller(reader io.ReadSeeker, size int64) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.Copy(zw, reader); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	if int64(buf.Len()) >= size {
+		if _, err := reader.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,74 @@
+package statics
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestIsCompressible(t *testing.T) {
+	assert.True(t, isCompressible("text/html"))
+	assert.True(t, isCompressible("text/css; charset=utf-8"))
+	assert.True(t, isCompressible("application/json"))
+	assert.True(t, isCompressible("image/svg+xml"))
+	assert.False(t, isCompressible("image/png"))
+	assert.False(t, isCompressible("font/woff2"))
+	assert.False(t, isCompressible(""))
+}
+
+func TestUploadDirectoryCompress(t *testing.T) {
+	html := strings.Repeat("<p>hello</p>\n", 100)
+	png := []byte("\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 64))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte(html), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tiny.css"), []byte("a{}"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logo.png"), png, 0o644))
+
+	upload := func(compress bool) map[string]string {
+		cfg := appconfig.NewConfig()
+		cfg.StaticsOptions = &appconfig.StaticsOptions{Compress: compress}
+		client := newMockS3()
+		deployer := &DeployerState{s3: client, bucket: "bucket", appConfig: cfg}
+		require.NoError(t, deployer.uploadDirectory(context.Background(), "root/0/", "", dir))
+
+		encodings := map[string]string{}
+		for _, put := range client.puts {
+			encodings[*put.Key] = lo.FromPtr(put.ContentEncoding)
+		}
+
+		if compress {
+			zr, err := gzip.NewReader(bytes.NewReader(client.objects["root/0/index.html"].body))
+			require.NoError(t, err)
+			body, err := io.ReadAll(zr)
+			require.NoError(t, err)
+			assert.Equal(t, html, string(body))
+		}
+		// Binary files are passed through verbatim
+		assert.Equal(t, png, client.objects["root/0/logo.png"].body)
+		return encodings
+	}
+
+	assert.Equal(t, map[string]string{
+		"root/0/index.html": "gzip",
+		// Too small to benefit from compression
+		"root/0/tiny.css": "",
+		"root/0/logo.png": "",
+	}, upload(true))
+
+	assert.Equal(t, map[string]string{
+		"root/0/index.html": "",
+		"root/0/tiny.css":   "",
+		"root/0/logo.png":   "",
+	}, upload(false))
+}
@@ -0,0 +1,68 @@
+package statics
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/superfly/flyctl/internal/env"
+	"github.com/superfly/flyctl/internal/logger"
+)
+
+const (
+	uploadConcurrencyEnvKey    = "FLY_STATICS_UPLOAD_CONCURRENCY"
+	directoryConcurrencyEnvKey = "FLY_STATICS_DIRECTORY_CONCURRENCY"
+	deleteConcurrencyEnvKey    = "FLY_STATICS_DELETE_CONCURRENCY"
+	deleteBatchSizeEnvKey      = "FLY_STATICS_DELETE_BATCH_SIZE"
+
+	defaultUploadConcurrency = 5
+	// maxUploadConcurrency keeps a typo from opening thousands of connections through the tokenizer proxy.
+	maxUploadConcurrency = 64
+
+	// Each directory has its own pool of upload workers, so the number of connections
+	// is up to the product of both limits.
+	defaultDirectoryConcurrency = 3
+	maxDirectoryConcurrency     = 16
+
+	defaultDeleteConcurrency = 4
+	maxDeleteConcurrency     = 16
+
+	// maxDeleteBatchSize is the most keys a single DeleteObjects request may hold.
+	maxDeleteBatchSize = 1000
+)
+
+// uploadConcurrencyFromEnv returns the number of files of a directory to upload at once.
+func uploadConcurrencyFromEnv() (int, error) {
+	return concurrencyFromEnv(uploadConcurrencyEnvKey, defaultUploadConcurrency, maxUploadConcurrency)
+}
+
+// directoryConcurrencyFromEnv returns the number of static directories to upload at once.
+func directoryConcurrencyFromEnv() (int, error) {
+	return concurrencyFromEnv(directoryConcurrencyEnvKey, defaultDirectoryConcurrency, maxDirectoryConcurrency)
+}
+
+// deleteConcurrencyFromEnv returns the number of DeleteObjects batches to send at once.
+func deleteConcurrencyFromEnv() (int, error) {
+	return concurrencyFromEnv(deleteConcurrencyEnvKey, defaultDeleteConcurrency, maxDeleteConcurrency)
+}
+
+// deleteBatchSizeFromEnv returns the number of keys deleted by each DeleteObjects request.
+func deleteBatchSizeFromEnv() (int, error) {
+	return concurrencyFromEnv(deleteBatchSizeEnvKey, maxDeleteBatchSize, maxDeleteBatchSize)
+}
+
+func concurrencyFromEnv(key string, defaultValue, maxValue int) (int, error) {
+	value := env.First(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid %s value '%s', expected a positive number", key, value)
+	}
+	if n > maxValue {
+		logEvent(logger.Debug, "setting_clamped", "env", key, "value", n, "max", maxValue)
+		n = maxValue
+	}
+	return n, nil
+}
@@ -0,0 +1,160 @@
+package statics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func TestUploadConcurrencyFromEnv(t *testing.T) {
+	cases := map[string]int{
+		"":     defaultUploadConcurrency,
+		"1":    1,
+		"12":   12,
+		"5000": maxUploadConcurrency,
+	}
+	for value, want := range cases {
+		t.Setenv(uploadConcurrencyEnvKey, value)
+		got, err := uploadConcurrencyFromEnv()
+		require.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+
+	for _, value := range []string{"0", "-3", "lots"} {
+		t.Setenv(uploadConcurrencyEnvKey, value)
+		_, err := uploadConcurrencyFromEnv()
+		assert.Error(t, err, value)
+	}
+
+	t.Setenv(directoryConcurrencyEnvKey, "")
+	got, err := directoryConcurrencyFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, defaultDirectoryConcurrency, got)
+
+	t.Setenv(directoryConcurrencyEnvKey, "100")
+	got, err = directoryConcurrencyFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, maxDirectoryConcurrency, got)
+
+	t.Setenv(deleteConcurrencyEnvKey, "")
+	got, err = deleteConcurrencyFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, defaultDeleteConcurrency, got)
+
+	// S3 doesn't take more than 1000 keys per DeleteObjects request.
+	t.Setenv(deleteBatchSizeEnvKey, "")
+	got, err = deleteBatchSizeFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, maxDeleteBatchSize, got)
+
+	t.Setenv(deleteBatchSizeEnvKey, "250")
+	got, err = deleteBatchSizeFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, 250, got)
+
+	t.Setenv(deleteBatchSizeEnvKey, "5000")
+	got, err = deleteBatchSizeFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, maxDeleteBatchSize, got)
+}
+
+func TestUploadDirectoryConcurrency(t *testing.T) {
+	t.Setenv(uploadConcurrencyEnvKey, "3")
+
+	dir := t.TempDir()
+	for i := 0; i < 30; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("file-%d.txt", i)), []byte("x"), 0o644))
+	}
+
+	var inFlight, maxInFlight atomic.Int32
+	client := newMockS3()
+	client.PutObjectFunc = func(ctx context.Context, params *s3.PutObjectInput) error {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	}
+	deployer := &DeployerState{s3: client, bucket: "bucket"}
+
+	require.NoError(t, deployer.uploadDirectory(context.Background(), "root/0/", "", dir))
+	assert.Len(t, client.keys(), 30)
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(3))
+}
+
+func TestPushUploadsDirectoriesConcurrently(t *testing.T) {
+	t.Setenv(directoryConcurrencyEnvKey, "3")
+	t.Setenv(uploadConcurrencyEnvKey, "1")
+
+	dir := t.TempDir()
+	names := []string{"public", "images", "docs"}
+	for _, name := range names {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, name), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name, "index.html"), []byte(name), 0o644))
+	}
+	chdir(t, dir)
+
+	// Each upload waits for the other directories to start theirs, so they only finish if they all run at once.
+	var started atomic.Int32
+	allStarted := make(chan struct{})
+	client := newMockS3()
+	client.PutObjectFunc = func(ctx context.Context, params *s3.PutObjectInput) error {
+		// The push is recorded once every directory is uploaded.
+		if path.Base(*params.Key) == pushRecordKey {
+			return nil
+		}
+		if started.Add(1) == int32(len(names)) {
+			close(allStarted)
+		}
+		select {
+		case <-allStarted:
+			return nil
+		case <-time.After(5 * time.Second):
+			t.Errorf("uploading %s didn't overlap with the other directories", *params.Key)
+			return statusError(http.StatusBadRequest)
+		}
+	}
+
+	deployer := &DeployerState{
+		appConfig:      &appconfig.Config{AppName: "my-app"},
+		releaseVersion: 1,
+		s3:             client,
+		bucket:         "bucket",
+	}
+	for _, name := range names {
+		deployer.originalStatics = append(deployer.originalStatics, appconfig.Static{GuestPath: name, UrlPrefix: "/" + name + "/"})
+	}
+
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+	require.NoError(t, deployer.UseNextVersion(ctx))
+	require.NoError(t, deployer.Push(ctx))
+
+	// Each static is still served from its own directory, in the order they were configured.
+	require.Len(t, deployer.appConfig.Statics, 3)
+	for i, name := range names {
+		static := deployer.appConfig.Statics[i]
+		assert.Equal(t, fmt.Sprintf("/fly-statics/my-app/%d/%d/", deployer.Version(), i), static.GuestPath)
+		assert.Equal(t, "/"+name+"/", static.UrlPrefix)
+		key := fmt.Sprintf("fly-statics/my-app/%d/%d/index.html", deployer.Version(), i)
+		require.Contains(t, client.objects, key)
+		assert.Equal(t, name, string(client.objects[key].body))
+	}
+}
@@ -2,11 +2,17 @@ package statics
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"path"
+	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/s3"
@@ -14,6 +20,8 @@ import (
 	"github.com/samber/lo"
 	"github.com/superfly/fly-go"
 	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/logger"
 	"github.com/superfly/flyctl/iostreams"
 	"github.com/superfly/flyctl/terminal"
 )
@@ -26,17 +34,37 @@ const (
 	// and tokenizer will forward requests upstream with HTTPS.
 	tigrisUrl = "http://" + tigrisHostname
 
-	tokenizerUrl     = "https://tokenizer.fly.io"
-	tokenizerSealKey = "3afdb665d93f741adc98a6cfecb36f1e02403a095e8efa921fd2321857011f42"
-
 	staticsMetaKeyAppId      = "fly-statics-app-id"
 	staticsMetaTokenizedAuth = "fly-statics-tokenized-auth"
 	staticsMetaBucketName    = "fly-statics-bucket-name"
+	// staticsMetaOrgId is the internal ID of the org that the tokenized auth was sealed for.
+	staticsMetaOrgId = "fly-statics-org-id"
 )
 
 // TODO(allison): Make sure that UI delete/move app operations take this into account.
 
-const staticsKeepVersions = 3
+// Options are the user-controlled settings for a statics push.
+type Options struct {
+	// NotifyURL, if set, receives a JSON POST describing the push once it has been finalized.
+	NotifyURL string
+	// KeyCase normalizes object keys for hosts that match paths case-insensitively.
+	KeyCase KeyCase
+	// AssetIndexKey, if set, is the key relative to the version root where an index
+	// of every uploaded path is written after a successful push.
+	AssetIndexKey string
+	// Staged uploads statics to a staging prefix, and only copies them to the release's
+	// prefix once every file has been uploaded.
+	Staged bool
+	// ManifestPath, if set, is the local path where a JSON manifest of every pushed file
+	// is written once the push has been finalized.
+	ManifestPath string
+	// Image, if set, is the image being deployed. Statics with an absolute guest_path are
+	// copied out of it and pushed, instead of being served from the image.
+	Image string
+	// Resume keeps the files of a push that fails, and resumes an interrupted push to an earlier
+	// version instead of starting over, skipping the files it already uploaded.
+	Resume bool
+}
 
 type DeployerState struct {
 	// State that's pulled from the larger machines deployment
@@ -44,47 +72,118 @@ type DeployerState struct {
 	org            *fly.Organization
 	appConfig      *appconfig.Config
 	releaseVersion int
+	opts           Options
 
 	// State specific to the statics deployment
-	s3              *s3.Client
+	s3              s3API
 	bucket          string
 	root            string
 	originalStatics []appconfig.Static
+	// tookOverStatics is set once the app config's statics were replaced, see takeOverStatics.
+	tookOverStatics bool
+
+	// Totals for the files uploaded by Push
+	uploadedFiles  atomic.Int64
+	uploadedBytes  atomic.Int64
+	uploadRetries  atomic.Int64
+	concurrency    uploadConcurrency
+	deletedObjects atomic.Int64
+	// pushDuration is how long the last successful Push took.
+	pushDuration time.Duration
+
+	uploadsMu sync.Mutex
+	// uploads are the files uploaded by uploadDirectory, by the prefix they were uploaded under.
+	uploads map[string][]uploadRecord
+	// progress is shared by the directories Push uploads at once. Without it, uploadDirectory
+	// reports its own progress.
+	progress *uploadProgress
+	// manifestFiles are the files of the last push, for its manifest.
+	manifestFiles []manifestFile
+	// reused is set when the push found nothing changed, and serves the previous release's statics.
+	reused bool
+	// resumable are the keys an interrupted push already uploaded, with their ETags, when resuming it.
+	resumable map[string]string
+
+	// io receives progress output, or is nil to stay silent.
+	io *iostreams.IOStreams
 }
 
-func Deployer(appConfig *appconfig.Config, app *fly.App, org *fly.Organization, releaseVersion int) *DeployerState {
+func Deployer(appConfig *appconfig.Config, app *fly.App, org *fly.Organization, releaseVersion int, opts Options) *DeployerState {
 	return &DeployerState{
 		app:            app,
 		appConfig:      appConfig,
 		org:            org,
 		releaseVersion: releaseVersion,
+		opts:           opts,
+	}
+}
+
+// StaticIsCandidateForTigrisPush reports whether static is pushed to tigris. Statics with an absolute
+// guest_path are only pushed when fromImage is set, as they're copied out of the image being deployed.
+func StaticIsCandidateForTigrisPush(static appconfig.Static, fromImage bool) bool {
+	return staticSkipReason(static, fromImage) == ""
+}
+
+// CheckCandidates returns a *NoCandidatesError when statics are configured but none of them
+// would be pushed to tigris. It returns nil when there are no statics, or some are pushed.
+func CheckCandidates(statics []appconfig.Static, fromImage bool) error {
+	var skipped []SkippedStatic
+	for _, static := range statics {
+		reason := staticSkipReason(static, fromImage)
+		if reason == "" {
+			return nil
+		}
+		skipped = append(skipped, SkippedStatic{Static: static, Reason: reason})
 	}
+	if len(skipped) == 0 {
+		return nil
+	}
+	return &NoCandidatesError{Skipped: skipped}
 }
 
-func StaticIsCandidateForTigrisPush(static appconfig.Static) bool {
+// staticSkipReason explains why a static isn't pushed to tigris, or returns "" if it is.
+func staticSkipReason(static appconfig.Static, fromImage bool) string {
 	if static.TigrisBucket != "" {
 		// If this is already mapped to a tigris bucket, that means the user is directly
 		// controlling the bucket, and therefore we should not touch it or push anything to it.
-		return false
+		return fmt.Sprintf("it's already mapped to the tigris bucket '%s'", static.TigrisBucket)
 	}
 	if len(static.GuestPath) == 0 {
-		return false
+		return "it has no guest_path"
 	}
-	// TODO(allison): Extract statics from the docker image?
-	if static.GuestPath[0] == '/' {
+	if static.GuestPath[0] == '/' && !fromImage {
 		// This is an absolute path. We should not modify this, as this path
 		// is going to be relative to the root of the docker image.
-		return false
+		return "its guest_path is absolute, so it's served from the image"
 	}
 	// Now we know that we have a relative path, and that we're not already using a tigris bucket.
 	// We can push this to the bucket.
-	return true
+	return ""
 }
 
 // Configure create the tigris bucket if not created, and sets up internal state on the deployer.
+// If statics_options.storage is set, the user's storage is used instead of a tigris bucket.
 func (deployer *DeployerState) Configure(ctx context.Context) error {
 
-	tokenizedAuth, err := deployer.ensureBucketCreated(ctx)
+	if err := deployer.opts.KeyCase.validate(); err != nil {
+		return err
+	}
+	if deployer.opts.Resume && deployer.opts.Staged {
+		// Staged files are only published once all of them are uploaded, so there's nothing to resume.
+		return errors.New("statics pushes can't be resumed when they're staged")
+	}
+	// Catch a mistyped guest_path before a bucket is provisioned for nothing.
+	if err := deployer.checkStaticDirs(); err != nil {
+		return err
+	}
+
+	var err error
+	if storage := deployer.appConfig.StaticsStorage(); storage != nil {
+		deployer.bucket = storage.Bucket
+		deployer.s3, err = customStorageClient(ctx, storage)
+	} else {
+		deployer.s3, err = deployer.tigrisClient(ctx)
+	}
 	if err != nil {
 		return err
 	}
@@ -97,29 +196,132 @@ func (deployer *DeployerState) Configure(ctx context.Context) error {
 	//
 	// TODO(allison): We can probably solve this by sending the full statics config
 	//                to each machine as metadata and resynthesizing it during config save.
+	//
+	// If the deploy fails, RestoreOriginalStatics puts the user's statics back.
+	deployer.takeOverStatics()
+
+	deployer.root = deployer.versionRoot(deployer.appConfig.AppName, deployer.releaseVersion)
+
+	if !config.FromContext(ctx).JSONOutput {
+		deployer.io = iostreams.FromContext(ctx)
+	}
+	return nil
+}
+
+// takeOverStatics stashes the app's statics, and leaves only those that aren't pushed to tigris
+// in the app config. Push adds the pushed statics back, served from the bucket.
+func (deployer *DeployerState) takeOverStatics() {
 	deployer.originalStatics = deployer.appConfig.Statics
 	deployer.appConfig.Statics = lo.Filter(deployer.appConfig.Statics, func(static appconfig.Static, _ int) bool {
-		return !StaticIsCandidateForTigrisPush(static)
+		return !StaticIsCandidateForTigrisPush(static, deployer.opts.Image != "")
 	})
+	deployer.tookOverStatics = true
+}
+
+// RestoreOriginalStatics puts the statics the app config had before Configure back, undoing
+// the filtering and the statics synthesized by Push, so a config saved or deployed again after
+// a failure is the user's.
+func (deployer *DeployerState) RestoreOriginalStatics() {
+	if deployer.tookOverStatics {
+		deployer.appConfig.Statics = deployer.originalStatics
+	}
+}
+
+// checkStaticDirs makes sure that the guest_path of every static pushed from the local filesystem is
+// a directory, reporting each one that isn't. Statics copied out of the image are checked as they're copied.
+func (deployer *DeployerState) checkStaticDirs() error {
+	var errs []error
+	for _, static := range deployer.appConfig.Statics {
+		if StaticIsCandidateForTigrisPush(static, false) {
+			errs = append(errs, checkStaticDir(static))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkStaticDir returns an error if the static's guest_path isn't a local directory.
+func checkStaticDir(static appconfig.Static) error {
+	info, err := os.Stat(filepath.FromSlash(path.Clean(static.GuestPath)))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("static guest_path '%s' (url_prefix '%s') doesn't exist", static.GuestPath, static.UrlPrefix)
+	case err != nil:
+		return fmt.Errorf("static guest_path '%s' (url_prefix '%s') can't be read: %w", static.GuestPath, static.UrlPrefix, err)
+	case !info.IsDir():
+		return fmt.Errorf("static guest_path '%s' (url_prefix '%s') is a file, it must be a directory", static.GuestPath, static.UrlPrefix)
+	}
+	return nil
+}
+
+// tigrisClient creates the app's tigris bucket if it doesn't exist yet, and returns a client for it.
+func (deployer *DeployerState) tigrisClient(ctx context.Context) (s3API, error) {
+	// Catch a misconfigured tokenizer before creating the bucket and sealing its credentials.
+	if _, err := tokenizerConfigFromEnv(); err != nil {
+		return nil, err
+	}
 
-	deployer.s3, err = s3ClientWithAuth(ctx, tokenizedAuth, deployer.org)
+	tokenizedAuth, err := deployer.ensureBucketCreated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s3ClientWithAuth(ctx, tokenizedAuth, deployer.org)
+}
+
+// UseNextVersion moves the push to a version after the release's and every version already in the bucket,
+// so that statics can be pushed outside of a deploy. It must be called after Configure.
+func (deployer *DeployerState) UseNextVersion(ctx context.Context) error {
+	versions, err := deployer.listVersions(ctx, deployer.appConfig.AppName)
 	if err != nil {
 		return err
 	}
+	deployer.useVersion(max(deployer.releaseVersion, lo.Max(versions)) + 1)
+	return nil
+}
 
-	deployer.root = fmt.Sprintf("fly-statics/%s/%d", deployer.appConfig.AppName, deployer.releaseVersion)
+// SkipPushedVersions moves a deploy past the versions `fly statics push` published ahead of its release,
+// so that it doesn't overwrite, or delete after a failure, statics the app's machines may be serving.
+// It must be called after Configure.
+func (deployer *DeployerState) SkipPushedVersions(ctx context.Context) error {
+	versions, err := deployer.listVersions(ctx, deployer.appConfig.AppName)
+	if err != nil {
+		return err
+	}
+	if latest := lo.Max(versions); latest >= deployer.releaseVersion {
+		deployer.useVersion(latest + 1)
+	}
 	return nil
 }
 
-func (deployer *DeployerState) deleteOldStatics(ctx context.Context, appName string, currentVer int) error {
+// useVersion pushes the statics under the given version instead of the release's.
+func (deployer *DeployerState) useVersion(version int) {
+	deployer.releaseVersion = version
+	deployer.root = deployer.versionRoot(deployer.appConfig.AppName, version)
+}
 
-	// List directories in the app's directory.
-	// Delete all versions except for the three latest versions.
+// Version returns the version the statics are pushed under.
+func (deployer *DeployerState) Version() int {
+	return deployer.releaseVersion
+}
+
+// appRoot returns the prefix every statics version of the app is under, e.g. fly-statics/my-app/,
+// or <prefix>/my-app/ when statics_options.prefix is set.
+func (deployer *DeployerState) appRoot(appName string) string {
+	return fmt.Sprintf("%s/%s/", deployer.appConfig.StaticsPrefix(), appName)
+}
+
+// versionRoot returns the prefix of a single statics version of the app, e.g. fly-statics/my-app/12.
+func (deployer *DeployerState) versionRoot(appName string, version int) string {
+	return fmt.Sprintf("%s%d", deployer.appRoot(appName), version)
+}
 
-	// List `fly-statics/<app_name>/` to get a list of all versions.
+// listVersions returns the release versions that have statics in the bucket, in no particular order.
+func (deployer *DeployerState) listVersions(ctx context.Context, appName string) ([]int, error) {
+
+	// List `<prefix>/<app_name>/` to get a list of all versions.
+	appRoot := deployer.appRoot(appName)
 	paginator := s3.NewListObjectsV2Paginator(deployer.s3, &s3.ListObjectsV2Input{
 		Bucket:    &deployer.bucket,
-		Prefix:    fly.Pointer(fmt.Sprintf("fly-statics/%s/", appName)),
+		Prefix:    fly.Pointer(appRoot),
 		Delimiter: fly.Pointer("/"),
 	})
 
@@ -128,18 +330,15 @@ func (deployer *DeployerState) deleteOldStatics(ctx context.Context, appName str
 
 		listOutput, err := paginator.NextPage(ctx)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		// Extract the version numbers from the common prefixes.
-		// These should be strings of the format `fly-statics/<app_name>/<version>/`.
+		// These should be strings of the format `<prefix>/<app_name>/<version>/`.
 		versions := lo.FilterMap(listOutput.CommonPrefixes, func(prefix types.CommonPrefix, _ int) (int, bool) {
-			// The number is the third part of the prefix.
-			parts := strings.Split(*prefix.Prefix, "/")
-			if len(parts) < 3 {
-				return 0, false
-			}
-			num, err := strconv.Atoi(parts[2])
+			// The number is what follows the app's root, which may have any number of parts.
+			versionStr, _, _ := strings.Cut(strings.TrimPrefix(*prefix.Prefix, appRoot), "/")
+			num, err := strconv.Atoi(versionStr)
 			if err != nil {
 				return 0, false
 			}
@@ -150,14 +349,41 @@ func (deployer *DeployerState) deleteOldStatics(ctx context.Context, appName str
 		}
 	}
 
-	versions := lo.Keys(versionSet)
+	return lo.Keys(versionSet), nil
+}
+
+// previousRoot returns the prefix holding the statics of the newest release before this one,
+// or an empty string if there isn't one.
+func (deployer *DeployerState) previousRoot(ctx context.Context) (string, error) {
+	versions, err := deployer.listVersions(ctx, deployer.appConfig.AppName)
+	if err != nil {
+		return "", err
+	}
+	versions = lo.Filter(versions, func(version int, _ int) bool {
+		return version < deployer.releaseVersion
+	})
+	if len(versions) == 0 {
+		return "", nil
+	}
+	return deployer.versionRoot(deployer.appConfig.AppName, lo.Max(versions)), nil
+}
+
+func (deployer *DeployerState) deleteOldStatics(ctx context.Context, appName string, currentVer int) error {
+
+	// List directories in the app's directory.
+	// Delete all versions except for the latest `keep_versions` versions.
+	versions, err := deployer.listVersions(ctx, appName)
+	if err != nil {
+		return err
+	}
 
 	var ignore []int
 	for _, version := range versions {
 		if version > currentVer {
 			ignore = append(ignore, version)
-			terminal.Debugf("Deleting too-new static dir (likely for reused app name): %s\n", fmt.Sprintf("fly-statics/%s/%d/", appName, version))
-			err := deployer.deleteDirectory(ctx, fmt.Sprintf("fly-statics/%s/%d/", appName, version))
+			dir := deployer.versionRoot(appName, version) + "/"
+			deployer.logEvent(logger.Debug, "version_pruned", "root", dir, "reason", "newer than the release, likely a reused app name")
+			err := deployer.deleteDirectory(ctx, dir)
 			if err != nil {
 				return err
 			}
@@ -174,11 +400,17 @@ func (deployer *DeployerState) deleteOldStatics(ctx context.Context, appName str
 	versions = lo.Uniq(versions)
 
 	// Delete versions that are older than we wish to keep.
-	if len(versions) > staticsKeepVersions {
-		versions = versions[:len(versions)-staticsKeepVersions]
+	keepVersions := deployer.appConfig.StaticsKeepVersions()
+	if keepVersions < 1 {
+		// Keeping no versions would delete the statics of the release being deployed.
+		return fmt.Errorf("refusing to prune statics, keep_versions must be at least 1, got %d", keepVersions)
+	}
+	if len(versions) > keepVersions {
+		versions = versions[:len(versions)-keepVersions]
 		for _, version := range versions {
-			terminal.Debugf("Deleting old static dir: %s\n", fmt.Sprintf("fly-statics/%s/%d/", appName, version))
-			err := deployer.deleteDirectory(ctx, fmt.Sprintf("fly-statics/%s/%d/", appName, version))
+			dir := deployer.versionRoot(appName, version) + "/"
+			deployer.logEvent(logger.Debug, "version_pruned", "root", dir, "reason", "older than keep_versions")
+			err := deployer.deleteDirectory(ctx, dir)
 			if err != nil {
 				return err
 			}
@@ -191,6 +423,7 @@ func (deployer *DeployerState) deleteOldStatics(ctx context.Context, appName str
 // Push statics to the tigris bucket.
 func (deployer *DeployerState) Push(ctx context.Context) (err error) {
 
+	started := time.Now()
 	defer func() {
 		panicErr := recover()
 		if err != nil || panicErr != nil {
@@ -201,33 +434,162 @@ func (deployer *DeployerState) Push(ctx context.Context) (err error) {
 		}
 	}()
 
-	staticNum := 0
-	for _, static := range deployer.originalStatics {
-		if !StaticIsCandidateForTigrisPush(static) {
-			continue
+	if deployer.opts.Resume {
+		if _, err := deployer.resumeInterrupted(ctx); err != nil {
+			deployer.logEvent(logger.Debug, "resume_failed", "error", err)
 		}
+	}
+	uploadRoot := deployer.uploadRoot()
+
+	// Files that haven't changed since the previous release are copied from it instead of uploaded again.
+	previousRoot, err := deployer.previousRoot(ctx)
+	if err != nil {
+		deployer.logEvent(logger.Debug, "previous_version_unknown", "error", err)
+		previousRoot, err = "", nil
+	}
+
+	var (
+		assets  []assetEntry
+		indexes []directoryIndex
+	)
+	// Statics sharing a directory are uploaded once, and each is served from its part of the upload.
+	pushed := lo.Filter(deployer.originalStatics, func(static appconfig.Static, _ int) bool {
+		return StaticIsCandidateForTigrisPush(static, deployer.opts.Image != "")
+	})
+	candidates, cleanup, err := deployer.extractImageStatics(ctx, pushed)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	sources, err := groupStaticSources(deployer.appConfig, candidates)
+	if err != nil {
+		return err
+	}
+
+	// If nothing changed since the previous release, its statics are served instead of a copy of them.
+	hash, err := deployer.pushHash(pushed, sources)
+	if err != nil {
+		return err
+	}
+	if previousRoot != "" && deployer.reusePrevious(ctx, previousRoot, hash) {
+		for staticNum, source := range sources {
+			dest := fmt.Sprintf("%s/%d/", deployer.root, staticNum)
+			for _, static := range source.statics {
+				staticDest, _ := deployer.staticDest(dest, static, nil)
+				deployer.serveStatic(staticDest, static.Static)
+			}
+		}
+		deployer.pushDuration = time.Since(started)
+		return nil
+	}
+
+	if err = deployer.uploadSources(ctx, sources, uploadRoot, previousRoot); err != nil {
+		return err
+	}
+	for staticNum, source := range sources {
 		dest := fmt.Sprintf("%s/%d/", deployer.root, staticNum)
-		staticNum += 1
+		sourceUploads := deployer.takeUploads(fmt.Sprintf("%s/%d/", uploadRoot, staticNum))
+		deployer.manifestFiles = append(deployer.manifestFiles, newManifestFiles(dest, sourceUploads)...)
+
+		for _, static := range source.statics {
+			staticDest, uploads := deployer.staticDest(dest, static, sourceUploads)
+			assets = append(assets, newAssetEntries(static.UrlPrefix, uploads)...)
+			if static.IndexDocument != "" {
+				indexes = append(indexes, directoryIndexes(staticDest, deployer.opts.KeyCase.key(static.IndexDocument), uploads)...)
+			}
+			deployer.serveStatic(staticDest, static.Static)
+		}
+	}
 
-		err = deployer.uploadDirectory(ctx, dest, path.Clean(static.GuestPath))
-		if err != nil {
-			return err
+	if deployer.opts.AssetIndexKey != "" {
+		if err = deployer.uploadAssetIndex(ctx, assets); err != nil {
+			return fmt.Errorf("failed to upload statics asset index: %w", err)
 		}
+	}
 
-		// TODO(allison): This is a temporary workaround.
-		//                When they're available, we want to swap over to virtual services.
-		deployer.appConfig.Statics = append(deployer.appConfig.Statics, appconfig.Static{
-			GuestPath:     "/" + dest,
-			UrlPrefix:     static.UrlPrefix,
-			TigrisBucket:  deployer.bucket,
-			IndexDocument: static.IndexDocument,
-		})
+	if deployer.opts.Staged {
+		if err = deployer.publishStaged(ctx); err != nil {
+			return fmt.Errorf("failed to publish staged statics: %w", err)
+		}
 	}
 
+	// Statics sharing an upload can ask for the same directory index.
+	indexes = lo.UniqBy(indexes, func(index directoryIndex) string { return index.Key })
+	if err = deployer.putDirectoryIndexes(ctx, indexes); err != nil {
+		return fmt.Errorf("failed to set up statics index documents: %w", err)
+	}
+
+	if err := deployer.putPushRecord(ctx, hash); err != nil {
+		deployer.logEvent(logger.Debug, "push_record_failed", "error", err)
+	}
+
+	deployer.pushDuration = time.Since(started)
+	metrics := deployer.Metrics()
+	deployer.logEvent(logger.Debug, "push_finished", "files", metrics.Files, "bytes", metrics.Bytes, "duration", metrics.Duration,
+		"retries", metrics.Retries, "peak_concurrency", metrics.PeakConcurrency)
 	return nil
 }
 
-// Finalize deletes old statics from the tigris bucket.
+// staticDest returns the prefix static is served from, given the prefix its source was uploaded to,
+// along with the part of the source's uploads it serves.
+func (deployer *DeployerState) staticDest(sourceDest string, static servedStatic, sourceUploads []uploadRecord) (string, []uploadRecord) {
+	if static.dir == "" {
+		return sourceDest, sourceUploads
+	}
+	dir := deployer.opts.KeyCase.key(static.dir)
+	return sourceDest + dir + "/", uploadsUnder(sourceUploads, dir)
+}
+
+// serveStatic points the app config at the statics pushed to dest.
+func (deployer *DeployerState) serveStatic(dest string, static appconfig.Static) {
+	// TODO(allison): This is a temporary workaround.
+	//                When they're available, we want to swap over to virtual services.
+	deployer.appConfig.Statics = append(deployer.appConfig.Statics, appconfig.Static{
+		GuestPath:     "/" + dest,
+		UrlPrefix:     static.UrlPrefix,
+		TigrisBucket:  deployer.bucket,
+		IndexDocument: static.IndexDocument,
+	})
+}
+
+// uploadSources uploads the directory of each source under uploadRoot/<staticNum>/, a few
+// directories at a time. What each one uploaded is left for takeUploads, so the statics keep
+// the same prefixes whatever order the uploads finish in.
+func (deployer *DeployerState) uploadSources(ctx context.Context, sources []*staticSource, uploadRoot, previousRoot string) error {
+	concurrency, err := directoryConcurrencyFromEnv()
+	if err != nil {
+		return err
+	}
+
+	deployer.progress = newUploadProgress(deployer.io, 0, 0)
+	defer func() {
+		deployer.progress.stop()
+		deployer.progress = nil
+	}()
+
+	workQueue := make(chan int, len(sources))
+	for staticNum := range sources {
+		workQueue <- staticNum
+	}
+	close(workQueue)
+
+	waitForWorkers := spawnWorkers(ctx, min(concurrency, len(sources)), func(ctx context.Context) error {
+		for staticNum := range workQueue {
+			previousDest := ""
+			if previousRoot != "" {
+				previousDest = fmt.Sprintf("%s/%d/", previousRoot, staticNum)
+			}
+			uploadDest := fmt.Sprintf("%s/%d/", uploadRoot, staticNum)
+			if err := deployer.uploadDirectory(ctx, uploadDest, previousDest, sources[staticNum].localPath); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return waitForWorkers()
+}
+
+// Finalize deletes old statics from the tigris bucket, and reports what the push uploaded.
 func (deployer *DeployerState) Finalize(ctx context.Context) error {
 
 	io := iostreams.FromContext(ctx)
@@ -238,6 +600,22 @@ func (deployer *DeployerState) Finalize(ctx context.Context) error {
 		fmt.Fprintf(io.ErrOut, "Failed to delete old statics: %v\n", err)
 	}
 
+	if deployer.opts.NotifyURL != "" {
+		if err := deployer.notify(ctx); err != nil {
+			fmt.Fprintf(io.ErrOut, "Failed to send statics push notification: %v\n", err)
+		}
+	}
+
+	if deployer.opts.ManifestPath != "" {
+		if err := deployer.writeManifest(); err != nil {
+			fmt.Fprintf(io.ErrOut, "Failed to write statics manifest: %v\n", err)
+		}
+	}
+
+	if deployer.io != nil {
+		fmt.Fprintln(deployer.io.Out, deployer.pushSummary())
+	}
+
 	// TODO(allison): do we need to do anything else here? i.e. push new service config?
 	//                this is dependent on the proxy work to support statics, which I don't
 	//                *believe* is done yet.
@@ -248,15 +626,58 @@ func (deployer *DeployerState) Finalize(ctx context.Context) error {
 }
 
 // CleanupAfterFailure removes the incomplete push and restores the app to its original state.
-func (deployer *DeployerState) CleanupAfterFailure(_ context.Context) {
+// It gets more time the more files were uploaded, or FLY_STATICS_CLEANUP_TIMEOUT, and isn't
+// stopped by ctx being canceled, since that's often why the push failed.
+func (deployer *DeployerState) CleanupAfterFailure(ctx context.Context) {
 
-	terminal.Debugf("Cleaning up failed statics push\n")
+	deployer.RestoreOriginalStatics()
 
-	deleteCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	if deployer.reused {
+		// The statics are the previous release's, which is still being served.
+		return
+	}
 
-	err := deployer.deleteDirectory(deleteCtx, deployer.root)
+	if deployer.opts.Resume {
+		err := deployer.keepInterrupted(context.WithoutCancel(ctx))
+		if err == nil {
+			terminal.Infof("Kept the %d statics files uploaded so far under %s, the next deploy with --statics-resume picks up from there\n", deployer.uploadedFiles.Load(), deployer.root)
+			return
+		}
+		// Without the record the files can't be resumed, so they're cleaned up as usual.
+		deployer.logEvent(logger.Warn, "keep_interrupted_failed", "error", err)
+	}
+
+	deployer.logEvent(logger.Info, "cleanup_started", "files", deployer.uploadedFiles.Load())
+
+	uploaded := deployer.uploadedFiles.Load()
+	timeout, err := cleanupTimeoutFromEnv(uploaded)
 	if err != nil {
-		terminal.Debugf("Failed to delete statics: %v\n", err)
+		terminal.Warnf("%v, using the default\n", err)
+		timeout = defaultCleanupTimeout(uploaded)
+	}
+	deleteCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	prefixes := []string{deployer.root}
+	if deployer.opts.Staged {
+		prefixes = append(prefixes, deployer.stagingRoot())
+	}
+
+	deletedBefore := deployer.deletedObjects.Load()
+	for _, prefix := range prefixes {
+		if err := deployer.deleteDirectory(deleteCtx, prefix); err != nil {
+			deployer.logEvent(logger.Warn, "cleanup_failed", "root", prefix, "error", err)
+		}
+	}
+	deleted := deployer.deletedObjects.Load() - deletedBefore
+
+	remaining, err := deployer.countObjects(deleteCtx, prefixes)
+	switch {
+	case err != nil:
+		terminal.Warnf("Removed %d objects of the failed statics push, but couldn't check whether any are left: %v\n", deleted, err)
+	case remaining > 0:
+		terminal.Warnf("Removed %d objects of the failed statics push, %d are left under %s\n", deleted, remaining, strings.Join(prefixes, ", "))
+	default:
+		deployer.logEvent(logger.Info, "cleanup_finished", "deleted", deleted)
 	}
 }
@@ -0,0 +1,282 @@
+package statics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func TestDeleteOldStaticsPaginates(t *testing.T) {
+	client := newMockS3()
+	// Force every listing to span several pages.
+	client.pageSize = 2
+	for _, version := range []int{1, 2, 3, 4, 5, 6, 7, 9, 10} {
+		for _, file := range []string{"0/index.html", "0/css/app.css", "1/logo.svg"} {
+			client.addObject(fmt.Sprintf("fly-statics/my-app/%d/%s", version, file), "x")
+		}
+	}
+	client.addObject("fly-statics/other-app/1/0/index.html", "x")
+
+	deployer := &DeployerState{s3: client, bucket: "bucket"}
+	require.NoError(t, deployer.deleteOldStatics(context.Background(), "my-app", 7))
+
+	var want []string
+	for _, version := range []int{5, 6, 7} {
+		for _, file := range []string{"0/css/app.css", "0/index.html", "1/logo.svg"} {
+			want = append(want, fmt.Sprintf("fly-statics/my-app/%d/%s", version, file))
+		}
+	}
+	want = append([]string{"fly-statics/other-app/1/0/index.html"}, want...)
+	assert.ElementsMatch(t, want, client.keys())
+
+	// The version listing alone needs several pages.
+	assert.Greater(t, len(client.lists), 5)
+}
+
+func TestDeleteOldStaticsKeepVersions(t *testing.T) {
+	for _, keep := range []int{1, 2, 5} {
+		client := newMockS3()
+		for version := 1; version <= 6; version++ {
+			client.addObject(fmt.Sprintf("fly-statics/my-app/%d/0/index.html", version), "x")
+		}
+
+		deployer := &DeployerState{
+			appConfig: &appconfig.Config{
+				AppName:        "my-app",
+				StaticsOptions: &appconfig.StaticsOptions{KeepVersions: fly.Pointer(keep)},
+			},
+			s3:     client,
+			bucket: "bucket",
+		}
+		require.NoError(t, deployer.deleteOldStatics(context.Background(), "my-app", 6))
+
+		var want []string
+		for version := 6 - keep + 1; version <= 6; version++ {
+			want = append(want, fmt.Sprintf("fly-statics/my-app/%d/0/index.html", version))
+		}
+		assert.Equal(t, want, client.keys(), "keep_versions = %d", keep)
+	}
+}
+
+func TestDeleteOldStaticsRefusesToKeepNoVersions(t *testing.T) {
+	for _, keep := range []int{0, -1} {
+		client := newMockS3()
+		client.addObject("fly-statics/my-app/1/0/index.html", "x")
+
+		deployer := &DeployerState{
+			appConfig: &appconfig.Config{
+				AppName:        "my-app",
+				StaticsOptions: &appconfig.StaticsOptions{KeepVersions: fly.Pointer(keep)},
+			},
+			s3:     client,
+			bucket: "bucket",
+		}
+		assert.Error(t, deployer.deleteOldStatics(context.Background(), "my-app", 1), "keep_versions = %d", keep)
+		assert.Equal(t, []string{"fly-statics/my-app/1/0/index.html"}, client.keys())
+	}
+}
+
+func TestUseNextVersion(t *testing.T) {
+	client := newMockS3()
+	deployer := &DeployerState{s3: client, bucket: "bucket", appConfig: &appconfig.Config{AppName: "my-app"}, releaseVersion: 4}
+
+	// Without any statics, the version after the current release is used
+	require.NoError(t, deployer.UseNextVersion(context.Background()))
+	assert.Equal(t, 5, deployer.Version())
+	assert.Equal(t, "fly-statics/my-app/5", deployer.root)
+
+	// Versions pushed outside of a deploy are skipped over
+	client.addObject("fly-statics/my-app/5/0/index.html", "x")
+	client.addObject("fly-statics/my-app/7/0/index.html", "x")
+	deployer.releaseVersion = 4
+	require.NoError(t, deployer.UseNextVersion(context.Background()))
+	assert.Equal(t, 8, deployer.Version())
+	assert.Equal(t, "fly-statics/my-app/8", deployer.root)
+}
+
+func TestDeployAfterPush(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("pushed"), 0o644))
+	chdir(t, dir)
+
+	client := newMockS3()
+	newDeployer := func(releaseVersion int) *DeployerState {
+		return &DeployerState{
+			appConfig:       &appconfig.Config{AppName: "my-app"},
+			releaseVersion:  releaseVersion,
+			s3:              client,
+			bucket:          "bucket",
+			root:            fmt.Sprintf("fly-statics/my-app/%d", releaseVersion),
+			originalStatics: []appconfig.Static{{GuestPath: "public", UrlPrefix: "/"}},
+		}
+	}
+
+	// `fly statics push` at release 3 publishes version 4
+	push := newDeployer(3)
+	require.NoError(t, push.UseNextVersion(context.Background()))
+	require.NoError(t, push.Push(context.Background()))
+	assert.Equal(t, 4, push.Version())
+
+	// The next deploy creates release 4, and mustn't reuse the pushed version
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("deployed"), 0o644))
+	deploy := newDeployer(4)
+	require.NoError(t, deploy.SkipPushedVersions(context.Background()))
+	assert.Equal(t, 5, deploy.Version())
+	assert.Equal(t, "fly-statics/my-app/5", deploy.root)
+
+	// A failed deploy only cleans up its own version
+	deploy.CleanupAfterFailure(context.Background())
+	assert.Equal(t, []string{"fly-statics/my-app/4/.fly-statics-push.json", "fly-statics/my-app/4/0/index.html"}, client.keys())
+	assert.Equal(t, "pushed", string(client.objects["fly-statics/my-app/4/0/index.html"].body))
+
+	require.NoError(t, deploy.Push(context.Background()))
+	assert.Equal(t, []string{
+		"fly-statics/my-app/4/.fly-statics-push.json",
+		"fly-statics/my-app/4/0/index.html",
+		"fly-statics/my-app/5/.fly-statics-push.json",
+		"fly-statics/my-app/5/0/index.html",
+	}, client.keys())
+	assert.Equal(t, "pushed", string(client.objects["fly-statics/my-app/4/0/index.html"].body))
+	assert.Equal(t, "deployed", string(client.objects["fly-statics/my-app/5/0/index.html"].body))
+
+	// A deploy without pushed versions keeps its release's version
+	next := newDeployer(6)
+	require.NoError(t, next.SkipPushedVersions(context.Background()))
+	assert.Equal(t, 6, next.Version())
+}
+
+func TestCustomStaticsPrefix(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("<html></html>"), 0o644))
+	chdir(t, dir)
+
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+	client := newMockS3()
+	// Another app shares the bucket, and something else lives under the default prefix.
+	client.addObject("cdn/statics/other-app/9/0/index.html", "x")
+	client.addObject("fly-statics/my-app/9/0/index.html", "x")
+	for _, version := range []int{2, 3, 4} {
+		client.addObject(fmt.Sprintf("cdn/statics/my-app/%d/0/index.html", version), "x")
+	}
+
+	deployer := &DeployerState{
+		appConfig: &appconfig.Config{
+			AppName:        "my-app",
+			StaticsOptions: &appconfig.StaticsOptions{Prefix: "cdn/statics", KeepVersions: fly.Pointer(2)},
+		},
+		releaseVersion:  1,
+		s3:              client,
+		bucket:          "bucket",
+		originalStatics: []appconfig.Static{{GuestPath: "public", UrlPrefix: "/"}},
+	}
+
+	// Versions are found under the prefix, ignoring the default one
+	require.NoError(t, deployer.UseNextVersion(ctx))
+	assert.Equal(t, 5, deployer.Version())
+	assert.Equal(t, "cdn/statics/my-app/5", deployer.root)
+
+	require.NoError(t, deployer.Push(ctx))
+	assert.Contains(t, client.keys(), "cdn/statics/my-app/5/0/index.html")
+	require.Len(t, deployer.appConfig.Statics, 1)
+	assert.Equal(t, "/cdn/statics/my-app/5/0/", deployer.appConfig.Statics[0].GuestPath)
+	version, ok := VersionFromGuestPath(deployer.appRoot("my-app"), deployer.appConfig.Statics[0].GuestPath)
+	assert.True(t, ok)
+	assert.Equal(t, 5, version)
+
+	// Cleanup keeps the newest versions under the prefix, and leaves everything else alone
+	require.NoError(t, deployer.deleteOldStatics(ctx, "my-app", 5))
+	assert.ElementsMatch(t, []string{
+		"cdn/statics/other-app/9/0/index.html",
+		"fly-statics/my-app/9/0/index.html",
+		"cdn/statics/my-app/4/0/index.html",
+		"cdn/statics/my-app/5/.fly-statics-push.json",
+		"cdn/statics/my-app/5/0/index.html",
+	}, client.keys())
+}
+
+func TestCheckCandidates(t *testing.T) {
+	assert.NoError(t, CheckCandidates(nil, false))
+	assert.NoError(t, CheckCandidates([]appconfig.Static{
+		{GuestPath: "/app/public", UrlPrefix: "/"},
+		{GuestPath: "public", UrlPrefix: "/assets"},
+	}, false))
+	// Absolute paths are pushed when they're copied out of the image.
+	assert.NoError(t, CheckCandidates([]appconfig.Static{{GuestPath: "/app/public", UrlPrefix: "/"}}, true))
+}
+
+func TestCheckCandidatesSkipReasons(t *testing.T) {
+	cases := []struct {
+		name   string
+		static appconfig.Static
+		want   string
+	}{
+		{
+			name:   "absolute path",
+			static: appconfig.Static{GuestPath: "/app/public", UrlPrefix: "/"},
+			want:   "'/app/public' (url_prefix '/') is skipped because its guest_path is absolute, so it's served from the image",
+		},
+		{
+			name:   "bucket mapped",
+			static: appconfig.Static{GuestPath: "public", UrlPrefix: "/", TigrisBucket: "my-bucket"},
+			want:   "'public' (url_prefix '/') is skipped because it's already mapped to the tigris bucket 'my-bucket'",
+		},
+		{
+			name:   "empty guest path",
+			static: appconfig.Static{UrlPrefix: "/"},
+			want:   "a static (url_prefix '/') is skipped because it has no guest_path",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckCandidates([]appconfig.Static{tc.static}, false)
+			var noCandidates *NoCandidatesError
+			require.ErrorAs(t, err, &noCandidates)
+			require.Len(t, noCandidates.Skipped, 1)
+			assert.Equal(t, tc.static, noCandidates.Skipped[0].Static)
+			assert.Equal(t, "none of the app's statics are pushed to tigris:\n  "+tc.want, err.Error())
+		})
+	}
+}
+
+func TestConfigureChecksStaticDirs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte("<html></html>"), 0o644))
+	chdir(t, dir)
+
+	// There's no app or org, so provisioning a bucket would fail differently.
+	deployer := &DeployerState{appConfig: &appconfig.Config{AppName: "my-app", Statics: []appconfig.Static{
+		{GuestPath: "public", UrlPrefix: "/"},
+		{GuestPath: "pubilc/assets", UrlPrefix: "/assets"},
+		{GuestPath: "index.html", UrlPrefix: "/home"},
+		{GuestPath: "/app/docs", UrlPrefix: "/docs"},
+	}}}
+	err := deployer.Configure(context.Background())
+	assert.EqualError(t, err, "static guest_path 'pubilc/assets' (url_prefix '/assets') doesn't exist\n"+
+		"static guest_path 'index.html' (url_prefix '/home') is a file, it must be a directory")
+	assert.Nil(t, deployer.s3)
+}
+
+func TestCheckStaticDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("<html></html>"), 0o644))
+	chdir(t, dir)
+
+	assert.NoError(t, checkStaticDir(appconfig.Static{GuestPath: "public/", UrlPrefix: "/"}))
+	assert.EqualError(t, checkStaticDir(appconfig.Static{GuestPath: "dist", UrlPrefix: "/"}),
+		"static guest_path 'dist' (url_prefix '/') doesn't exist")
+	assert.EqualError(t, checkStaticDir(appconfig.Static{GuestPath: "public/index.html", UrlPrefix: "/"}),
+		"static guest_path 'public/index.html' (url_prefix '/') is a file, it must be a directory")
+}
@@ -0,0 +1,98 @@
+package statics
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/samber/lo"
+	"github.com/superfly/fly-go"
+)
+
+// DownloadVersion downloads the statics of a version from the app's bucket into dest, keeping
+// their paths relative to the version, e.g. dest/0/index.html for the first static. It returns
+// the number of files and bytes written.
+func DownloadVersion(ctx context.Context, appName string, version int, dest string) (int, int64, error) {
+	deployer, err := appDeployer(ctx, appName)
+	if err != nil {
+		return 0, 0, err
+	}
+	if deployer == nil {
+		return 0, 0, fmt.Errorf("%s has no statics bucket", appName)
+	}
+	return deployer.downloadDirectory(ctx, deployer.versionRoot(appName, version)+"/", dest)
+}
+
+// downloadDirectory downloads every object under prefix into dest, at its key relative to prefix.
+func (deployer *DeployerState) downloadDirectory(ctx context.Context, prefix, dest string) (files int, bytes int64, err error) {
+	paginator := s3.NewListObjectsV2Paginator(deployer.s3, &s3.ListObjectsV2Input{
+		Bucket: &deployer.bucket,
+		Prefix: fly.Pointer(prefix),
+	})
+	for paginator.HasMorePages() {
+		listOutput, err := paginator.NextPage(ctx)
+		if err != nil {
+			return files, bytes, err
+		}
+		for _, obj := range listOutput.Contents {
+			rel := strings.TrimPrefix(*obj.Key, prefix)
+			// Keys are untrusted, don't let one write outside of dest.
+			if rel == "" || strings.HasSuffix(rel, "/") || !filepath.IsLocal(filepath.FromSlash(rel)) {
+				continue
+			}
+			// The push record is bookkeeping, not one of the statics.
+			if rel == pushRecordKey {
+				continue
+			}
+			n, err := deployer.downloadObject(ctx, *obj.Key, filepath.Join(dest, filepath.FromSlash(rel)))
+			if err != nil {
+				return files, bytes, fmt.Errorf("failed to download %s: %w", rel, err)
+			}
+			files++
+			bytes += n
+		}
+	}
+	return files, bytes, nil
+}
+
+// downloadObject writes the object at key to the local file at target, creating its directory.
+// Objects that were compressed for upload are decompressed, except for files that were already
+// compressed by the build.
+func (deployer *DeployerState) downloadObject(ctx context.Context, key, target string) (int64, error) {
+	output, err := deployer.s3.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &deployer.bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer output.Body.Close()
+
+	var body io.Reader = output.Body
+	if encoding, _ := precompressedEncoding(key); encoding == "" && lo.FromPtr(output.ContentEncoding) == "gzip" {
+		gz, err := gzip.NewReader(output.Body)
+		if err != nil {
+			return 0, err
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return 0, err
+	}
+	file, err := os.Create(target)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
@@ -0,0 +1,49 @@
+package statics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadDirectory(t *testing.T) {
+	client := newMockS3()
+	client.addObject("fly-statics/my-app/3/0/index.html", "<html></html>")
+	client.addObject("fly-statics/my-app/3/0/css/app.css", "body{}")
+	client.addObject("fly-statics/my-app/3/1/logo.svg", "<svg></svg>")
+	// Other versions and apps are left alone
+	client.addObject("fly-statics/my-app/4/0/index.html", "<html>new</html>")
+	client.addObject("fly-statics/my-app-2/3/0/index.html", "<html>other</html>")
+	client.addObject("fly-statics/my-app/3/../../escape.txt", "nope")
+
+	deployer := &DeployerState{s3: client, bucket: "bucket"}
+	dest := filepath.Join(t.TempDir(), "download")
+	files, bytes, err := deployer.downloadDirectory(context.Background(), "fly-statics/my-app/3/", dest)
+	require.NoError(t, err)
+	assert.Equal(t, 3, files)
+	assert.Equal(t, int64(30), bytes)
+
+	for rel, content := range map[string]string{
+		"0/index.html":  "<html></html>",
+		"0/css/app.css": "body{}",
+		"1/logo.svg":    "<svg></svg>",
+	} {
+		got, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(rel)))
+		require.NoError(t, err, rel)
+		assert.Equal(t, content, string(got), rel)
+	}
+
+	var downloaded []string
+	require.NoError(t, filepath.WalkDir(filepath.Dir(dest), func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, _ := filepath.Rel(dest, path)
+			downloaded = append(downloaded, filepath.ToSlash(rel))
+		}
+		return err
+	}))
+	assert.ElementsMatch(t, []string{"0/index.html", "0/css/app.css", "1/logo.svg"}, downloaded)
+}
@@ -0,0 +1,82 @@
+package statics
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+// failedUploadsError reports every file that couldn't be uploaded after exhausting its attempts.
+type failedUploadsError struct {
+	mu    sync.Mutex
+	files map[string]error
+}
+
+func (e *failedUploadsError) add(file string, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.files == nil {
+		e.files = map[string]error{}
+	}
+	e.files[file] = err
+}
+
+func (e *failedUploadsError) Error() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	files := make([]string, 0, len(e.files))
+	for file := range e.files {
+		files = append(files, file)
+	}
+	slices.Sort(files)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "failed to upload %d statics file(s):", len(files))
+	for _, file := range files {
+		fmt.Fprintf(&b, "\n  %s: %v", file, e.files[file])
+	}
+	return b.String()
+}
+
+func (e *failedUploadsError) Unwrap() []error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	errs := make([]error, 0, len(e.files))
+	for _, err := range e.files {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// SkippedStatic is a static that isn't pushed to tigris, and why.
+type SkippedStatic struct {
+	appconfig.Static
+	Reason string
+}
+
+// NoCandidatesError is returned by CheckCandidates when the app has statics, but none of them
+// can be pushed to tigris, so none of them are served from it.
+type NoCandidatesError struct {
+	Skipped []SkippedStatic
+}
+
+func (e *NoCandidatesError) Error() string {
+	var b strings.Builder
+	b.WriteString("none of the app's statics are pushed to tigris:")
+	for _, skipped := range e.Skipped {
+		fmt.Fprintf(&b, "\n  %s (url_prefix '%s') is skipped because %s", skippedName(skipped.Static), skipped.UrlPrefix, skipped.Reason)
+	}
+	return b.String()
+}
+
+func skippedName(static appconfig.Static) string {
+	if static.GuestPath == "" {
+		return "a static"
+	}
+	return fmt.Sprintf("'%s'", static.GuestPath)
+}
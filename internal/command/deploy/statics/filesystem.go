@@ -1,51 +1,65 @@
 package statics
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
-	"mime"
-	"net/http"
 	"os"
 	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/samber/lo"
 	"github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/logger"
 	"github.com/superfly/flyctl/terminal"
 )
 
 // Upload a directory to the tigris bucket with the given prefix `dest`.
-func (deployer *DeployerState) uploadDirectory(ctx context.Context, dest, localPath string) error {
+// Files that are unchanged from the ones under the prefix `previousDest` are copied from there instead.
+// An empty `previousDest` uploads every file.
+func (deployer *DeployerState) uploadDirectory(ctx context.Context, dest, previousDest, localPath string) error {
+
+	concurrency, err := uploadConcurrencyFromEnv()
+	if err != nil {
+		return err
+	}
+	attempts, err := uploadAttemptsFromEnv()
+	if err != nil {
+		return err
+	}
 
 	// Clean the destination path.
 	// This is for the case where someone launches an app, it fails, then they
 	// just delete the app and re-launch it.
-	if err := deployer.deleteDirectory(ctx, dest); err != nil {
-		return err
+	// A resumed push keeps the files the interrupted one uploaded, as uploadFile skips them.
+	if deployer.resumable == nil {
+		if err := deployer.deleteDirectory(ctx, dest); err != nil {
+			return err
+		}
 	}
 
 	// Recursively upload the directory to the bucket.
-	var files []string
-	localDir := os.DirFS(localPath)
-	err := fs.WalkDir(localDir, ".", func(name string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if d.IsDir() {
-			return nil
-		}
-		files = append(files, name)
-		return nil
-	})
+	files, totalBytes, err := listStaticFiles(deployer.appConfig, localPath)
 	if err != nil {
 		return err
 	}
 
+	if deployer.opts.KeyCase != KeyCasePreserve {
+		if err := checkCaseCollisions(files); err != nil {
+			return err
+		}
+	}
+
 	// Create a work queue, then start a number of workers to upload the files.
 	workQueue := make(chan string, len(files))
 	for _, file := range files {
@@ -53,57 +67,171 @@ func (deployer *DeployerState) uploadDirectory(ctx context.Context, dest, localP
 	}
 	close(workQueue)
 
-	waitForWorkers := spawnWorkers(ctx, 5, func(ctx context.Context) error {
-		for file := range workQueue {
+	// Files that fail all of their attempts are recorded instead of stopping the push,
+	// so that one bad file doesn't keep the rest from being uploaded.
+	failed := &failedUploadsError{}
 
-			reader, err := os.Open(filepath.Join(localPath, file))
-			if err != nil {
-				return err
-			}
+	progress := deployer.progress
+	if progress == nil {
+		progress = newUploadProgress(deployer.io, len(files), totalBytes)
+		defer progress.stop()
+	} else {
+		progress.addTotals(len(files), totalBytes)
+	}
 
-			mimeType := "application/octet-stream"
-			if detectedMime := mime.TypeByExtension(filepath.Ext(file)); detectedMime != "" {
-				mimeType = detectedMime
-			} else {
-				first512 := make([]byte, 512)
-				_, err = reader.Read(first512)
-				if err != nil {
-					return fmt.Errorf("failed to read static file %s: %w", file, err)
-				} else {
-					_, err = reader.Seek(0, 0)
-					if err != nil {
-						return fmt.Errorf("failed to seek static file %s: %w", file, err)
-					}
-					mimeType = http.DetectContentType(first512)
+	waitForWorkers := spawnWorkers(ctx, concurrency, func(ctx context.Context) error {
+		for file := range workQueue {
+			if err := deployer.uploadFileWithRetries(ctx, attempts, dest, previousDest, localPath, file, progress); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
 				}
+				failed.add(file, err)
 			}
+		}
+		return nil
+	})
 
-			if runtime.GOOS == "windows" {
-				file = strings.ReplaceAll(file, "\\", "/")
-			}
+	if err := waitForWorkers(); err != nil {
+		return err
+	}
+	if len(failed.files) > 0 {
+		return failed
+	}
+	return nil
+}
 
-			terminal.Debugf("Uploading to %s\n", path.Join(dest, file))
+// uploadFileWithRetries uploads a file, giving it its own budget of attempts.
+// Attempts are spaced out with exponential backoff, and errors that can't be fixed by retrying end them early.
+func (deployer *DeployerState) uploadFileWithRetries(ctx context.Context, attempts int, dest, previousDest, localPath, file string, progress *uploadProgress) (err error) {
+	defer deployer.concurrency.start()()
 
-			// Upload the file to the bucket.
-			_, err = deployer.s3.PutObject(ctx, &s3.PutObjectInput{
-				Bucket:      &deployer.bucket,
-				Key:         fly.Pointer(path.Join(dest, file)),
-				Body:        reader,
-				ContentType: &mimeType,
-			})
-			if err != nil {
-				return err
-			}
+	b := newUploadBackoff()
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var size int64
+		if size, err = deployer.uploadFile(ctx, dest, previousDest, localPath, file); err == nil {
+			progress.fileDone(size)
+			return nil
+		}
+		if attempt == attempts || !isRetryableUploadError(err) {
+			break
+		}
+		deployer.logEvent(logger.Debug, "upload_retry", "file", file, "attempt", attempt, "attempts", attempts, "error", err)
+		deployer.uploadRetries.Add(1)
 
-			err = reader.Close()
-			if err != nil {
-				terminal.Debugf("failed to close file %s: %v", file, err)
-			}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(b.Duration()):
 		}
-		return nil
-	})
+	}
+	return err
+}
+
+// Upload a single file, relative to `localPath`, to the bucket under the prefix `dest`,
+// copying it from under `previousDest` if it's unchanged there.
+// It returns the size of the uploaded file.
+func (deployer *DeployerState) uploadFile(ctx context.Context, dest, previousDest, localPath, file string) (int64, error) {
+
+	reader, err := os.Open(filepath.Join(localPath, file))
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := reader.Close(); err != nil {
+			deployer.logEvent(logger.Debug, "close_failed", "file", file, "error", err)
+		}
+	}()
+
+	info, err := reader.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	encoding, typeFile := precompressedEncoding(file)
+	mimeType, err := detectContentType(typeFile, reader, deployer.appConfig.StaticsContentTypes())
+	if err != nil {
+		return 0, err
+	}
+
+	var sha string
+	if deployer.opts.ManifestPath != "" {
+		if sha, err = contentSHA256(reader); err != nil {
+			return 0, err
+		}
+	}
+
+	var (
+		body            io.ReadSeeker = reader
+		bodySize                      = info.Size()
+		contentEncoding *string
+	)
+	switch {
+	case encoding != "":
+		// Already compressed by the build, so it's uploaded as is.
+		contentEncoding = &encoding
+	case deployer.appConfig.StaticsCompress() && isCompressible(mimeType):
+		compressed, err := gzipIfSmaller(reader, info.Size())
+		if err != nil {
+			return 0, fmt.Errorf("failed to compress static file %s: %w", file, err)
+		}
+		if compressed != nil {
+			body = bytes.NewReader(compressed)
+			bodySize = int64(len(compressed))
+			contentEncoding = fly.Pointer("gzip")
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		file = strings.ReplaceAll(file, "\\", "/")
+	}
+	cacheHeader := cacheControl(deployer.appConfig, file)
+	file = deployer.opts.KeyCase.key(file)
+	key := path.Join(dest, file)
+
+	input := &s3.PutObjectInput{
+		Bucket:          &deployer.bucket,
+		Key:             &key,
+		Body:            body,
+		ContentType:     &mimeType,
+		ContentEncoding: contentEncoding,
+		CacheControl:    cacheHeader,
+	}
 
-	return waitForWorkers()
+	// A push being resumed skips the files that are already uploaded.
+	copied, err := deployer.alreadyUploaded(key, body)
+	if err != nil {
+		return 0, err
+	}
+	if copied {
+		deployer.logEvent(logger.Debug, "upload_resumed", "file", key)
+	}
+
+	if !copied && previousDest != "" {
+		copied, err = deployer.copyIfUnchanged(ctx, path.Join(previousDest, file), input, body)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if !copied {
+		deployer.logEvent(logger.Debug, "upload_started", "file", key, "bytes", bodySize)
+
+		// Upload the file to the bucket.
+		if err = deployer.putObject(ctx, input, body, bodySize); err != nil {
+			return 0, err
+		}
+		deployer.logEvent(logger.Debug, "upload_finished", "file", key, "bytes", bodySize)
+	}
+
+	deployer.uploadedFiles.Add(1)
+	deployer.uploadedBytes.Add(info.Size())
+	deployer.recordUpload(dest, uploadRecord{
+		File:        file,
+		Size:        info.Size(),
+		ContentType: mimeType,
+		SHA256:      sha,
+	})
+	return info.Size(), nil
 }
 
 // Delete all files with the given prefix `dir` from the bucket.
@@ -117,12 +245,15 @@ func (deployer *DeployerState) deleteDirectory(ctx context.Context, dir string)
 		dir += "/"
 	}
 
+	// No delimiter, so that files nested under the prefix are listed too.
 	paginator := s3.NewListObjectsV2Paginator(deployer.s3, &s3.ListObjectsV2Input{
-		Bucket:    &deployer.bucket,
-		Prefix:    fly.Pointer(dir),
-		Delimiter: fly.Pointer("/"),
+		Bucket: &deployer.bucket,
+		Prefix: fly.Pointer(dir),
 	})
 
+	// Every page is listed before anything is deleted, so that deleting
+	// doesn't shift the objects on the pages that are still to come.
+	var objectIdentifiers []types.ObjectIdentifier
 	for paginator.HasMorePages() {
 
 		listOutput, err := paginator.NextPage(ctx)
@@ -130,27 +261,148 @@ func (deployer *DeployerState) deleteDirectory(ctx context.Context, dir string)
 			return err
 		}
 
-		objectIdentifiers := lo.Map(listOutput.Contents, func(obj types.Object, _ int) types.ObjectIdentifier {
+		objectIdentifiers = append(objectIdentifiers, lo.Map(listOutput.Contents, func(obj types.Object, _ int) types.ObjectIdentifier {
 			return types.ObjectIdentifier{
 				Key: obj.Key,
 			}
-		})
+		})...)
+	}
 
-		// Delete files in batches of 1000
-		split := lo.Chunk(objectIdentifiers, 1000)
-		for _, batch := range split {
+	batchSize, err := deleteBatchSizeFromEnv()
+	if err != nil {
+		return err
+	}
+	concurrency, err := deleteConcurrencyFromEnv()
+	if err != nil {
+		return err
+	}
 
-			_, err = deployer.s3.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+	// Every batch is attempted even if another fails, so as much as possible is deleted,
+	// and all of the failures are reported together.
+	batches := make(chan []types.ObjectIdentifier)
+	var (
+		errsMu sync.Mutex
+		errs   []error
+	)
+	waitForWorkers := spawnWorkers(ctx, concurrency, func(ctx context.Context) error {
+		for batch := range batches {
+			output, err := deployer.s3.DeleteObjects(ctx, &s3.DeleteObjectsInput{
 				Bucket: &deployer.bucket,
 				Delete: &types.Delete{
 					Objects: batch,
 				},
 			})
 			if err != nil {
-				return err
+				errsMu.Lock()
+				errs = append(errs, fmt.Errorf("failed to delete %d objects under %s: %w", len(batch), dir, err))
+				errsMu.Unlock()
+				continue
 			}
+			deployer.deletedObjects.Add(int64(len(batch) - len(output.Errors)))
 		}
+		return nil
+	})
+	for _, batch := range lo.Chunk(objectIdentifiers, batchSize) {
+		batches <- batch
 	}
+	close(batches)
 
-	return nil
+	if err := waitForWorkers(); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// listStaticFiles walks the static directory at localPath, returning the slash-separated paths
+// of the files to upload, relative to localPath, and their total size. Ignored files are left out.
+//
+// Symlinks are followed as long as they resolve to somewhere inside localPath. Symlinks that
+// point outside of it, are broken, or would loop back on themselves are skipped with a warning.
+func listStaticFiles(cfg *appconfig.Config, localPath string) (files []string, totalBytes int64, err error) {
+	ignore, err := loadStaticsIgnore(cfg, localPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	root, err := filepath.EvalSymlinks(localPath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	walker := &staticsWalker{root: root, ignore: ignore}
+	if err := walker.walk(root, ""); err != nil {
+		return nil, 0, err
+	}
+	return walker.files, walker.totalBytes, nil
+}
+
+// staticsWalker collects the files of a static directory, following symlinks within it.
+type staticsWalker struct {
+	root   string
+	ignore *staticsIgnore
+
+	files      []string
+	totalBytes int64
+}
+
+// walk lists the files under dir, a resolved path within the static root,
+// naming them relative to the static root under prefix.
+func (w *staticsWalker) walk(dir, prefix string) error {
+	return fs.WalkDir(os.DirFS(dir), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == "." {
+			return nil
+		}
+		file := path.Join(prefix, name)
+		if skip, err := w.ignore.skip(file, d); skip || err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return w.followSymlink(filepath.Join(dir, filepath.FromSlash(name)), file)
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		w.files = append(w.files, file)
+		w.totalBytes += info.Size()
+		return nil
+	})
+}
+
+func (w *staticsWalker) followSymlink(link, file string) error {
+	target, err := filepath.EvalSymlinks(link)
+	if err != nil {
+		terminal.Warnf("Skipping static %s, it's a broken symlink\n", file)
+		return nil
+	}
+	if !isWithinDir(w.root, target) {
+		terminal.Warnf("Skipping static %s, it's a symlink to %s which is outside of the static directory\n", file, target)
+		return nil
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		w.files = append(w.files, file)
+		w.totalBytes += info.Size()
+		return nil
+	}
+	if isWithinDir(target, filepath.Dir(link)) {
+		terminal.Warnf("Skipping static %s, it's a symlink to one of its own parent directories\n", file)
+		return nil
+	}
+	return w.walk(target, file)
+}
+
+// isWithinDir reports whether path is dir or somewhere beneath it.
+func isWithinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
 }
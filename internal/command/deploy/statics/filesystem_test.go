@@ -0,0 +1,149 @@
+package statics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/jpillora/backoff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withoutRetryDelay(t *testing.T) {
+	newBackoff := newUploadBackoff
+	newUploadBackoff = func() *backoff.Backoff {
+		return &backoff.Backoff{Min: time.Nanosecond, Max: time.Nanosecond}
+	}
+	t.Cleanup(func() { newUploadBackoff = newBackoff })
+}
+
+func TestUploadDirectoryPerFileRetries(t *testing.T) {
+	withoutRetryDelay(t)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad.txt"), []byte("bad"), 0o644))
+	for i := 0; i < 20; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("good-%d.txt", i)), []byte("good"), 0o644))
+	}
+
+	var badAttempts, flakyAttempts atomic.Int32
+	client := newMockS3()
+	client.PutObjectFunc = func(ctx context.Context, params *s3.PutObjectInput) error {
+		switch {
+		case strings.HasSuffix(*params.Key, "bad.txt"):
+			badAttempts.Add(1)
+			return errors.New("boom")
+		case strings.HasSuffix(*params.Key, "good-0.txt") && flakyAttempts.Add(1) == 1:
+			// Fails once, then succeeds on its own retry
+			return errors.New("flaky")
+		}
+		return nil
+	}
+	deployer := &DeployerState{s3: client, bucket: "bucket"}
+
+	err := deployer.uploadDirectory(context.Background(), "root/0/", "", dir)
+	require.Error(t, err)
+
+	var failed *failedUploadsError
+	require.ErrorAs(t, err, &failed)
+	assert.Len(t, failed.files, 1)
+	assert.Contains(t, err.Error(), "bad.txt: boom")
+
+	assert.Equal(t, int32(defaultUploadAttempts), badAttempts.Load())
+	assert.Len(t, client.keys(), 20)
+	assert.Equal(t, int64(20), deployer.uploadedFiles.Load())
+}
+
+func TestDeleteDirectoryPaginates(t *testing.T) {
+	client := newMockS3()
+	client.pageSize = 2
+	var want []string
+	for _, file := range []string{"index.html", "css/app.css", "css/vendor/reset.css", "img/logo.svg", "img/icons/a.svg"} {
+		key := "fly-statics/my-app/1/0/" + file
+		client.addObject(key, "x")
+		want = append(want, key)
+	}
+	client.addObject("fly-statics/my-app/1/1/index.html", "x")
+
+	deployer := &DeployerState{s3: client, bucket: "bucket"}
+	require.NoError(t, deployer.deleteDirectory(context.Background(), "fly-statics/my-app/1/0"))
+
+	var deleted []string
+	for _, input := range client.deletes {
+		for _, obj := range input.Delete.Objects {
+			deleted = append(deleted, *obj.Key)
+		}
+	}
+	assert.ElementsMatch(t, want, deleted)
+	assert.Equal(t, int64(len(want)), deployer.deletedObjects.Load())
+	assert.Len(t, client.lists, 3)
+	assert.Equal(t, []string{"fly-statics/my-app/1/1/index.html"}, client.keys())
+}
+
+func TestDeleteDirectoryBatches(t *testing.T) {
+	t.Setenv(deleteBatchSizeEnvKey, "3")
+	t.Setenv(deleteConcurrencyEnvKey, "4")
+	client := newMockS3()
+	client.pageSize = 4
+	var want []string
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("fly-statics/my-app/1/0/file-%02d.txt", i)
+		client.addObject(key, "x")
+		want = append(want, key)
+	}
+
+	deployer := &DeployerState{s3: client, bucket: "bucket"}
+	require.NoError(t, deployer.deleteDirectory(context.Background(), "fly-statics/my-app/1"))
+
+	var deleted []string
+	for _, input := range client.deletes {
+		assert.LessOrEqual(t, len(input.Delete.Objects), 3)
+		for _, obj := range input.Delete.Objects {
+			deleted = append(deleted, *obj.Key)
+		}
+	}
+	assert.ElementsMatch(t, want, deleted)
+	assert.Len(t, client.deletes, 7)
+	assert.Len(t, client.lists, 5)
+	assert.Empty(t, client.keys())
+	assert.Equal(t, int64(20), deployer.deletedObjects.Load())
+}
+
+func TestDeleteDirectoryReportsEveryFailedBatch(t *testing.T) {
+	t.Setenv(deleteBatchSizeEnvKey, "2")
+	client := newMockS3()
+	for i := 0; i < 6; i++ {
+		client.addObject(fmt.Sprintf("fly-statics/my-app/1/0/file-%d.txt", i), "x")
+	}
+	client.DeleteObjectsFunc = func(ctx context.Context, params *s3.DeleteObjectsInput) error {
+		for _, obj := range params.Delete.Objects {
+			if strings.HasSuffix(*obj.Key, "file-0.txt") || strings.HasSuffix(*obj.Key, "file-5.txt") {
+				return errors.New("access denied")
+			}
+		}
+		return nil
+	}
+
+	deployer := &DeployerState{s3: client, bucket: "bucket"}
+	err := deployer.deleteDirectory(context.Background(), "fly-statics/my-app/1")
+	require.Error(t, err)
+
+	// The batch in between is still deleted.
+	assert.Len(t, client.deletes, 3)
+	assert.Equal(t, 2, strings.Count(err.Error(), "failed to delete 2 objects under fly-statics/my-app/1/: access denied"))
+	assert.ElementsMatch(t, []string{
+		"fly-statics/my-app/1/0/file-0.txt",
+		"fly-statics/my-app/1/0/file-1.txt",
+		"fly-statics/my-app/1/0/file-4.txt",
+		"fly-statics/my-app/1/0/file-5.txt",
+	}, client.keys())
+	assert.Equal(t, int64(2), deployer.deletedObjects.Load())
+}
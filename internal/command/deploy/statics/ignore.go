@@ -0,0 +1,109 @@
+package statics
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/dockerignore"
+	"github.com/moby/patternmatcher"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/logger"
+)
+
+// ignoreFileName is the file at the root of a static's directory listing the files not to upload.
+const ignoreFileName = ".flyignore"
+
+// staticsIgnore decides which files under a static's directory are uploaded.
+// Patterns use the same gitignore-style syntax as .dockerignore.
+type staticsIgnore struct {
+	matcher *patternmatcher.PatternMatcher
+	// skipHidden skips dotfiles and dot-directories, other than .well-known.
+	skipHidden bool
+}
+
+// loadStaticsIgnore reads the ignore patterns for the static at localPath: those from
+// statics_options.ignore in fly.toml, followed by those from its .flyignore file, if any.
+func loadStaticsIgnore(cfg *appconfig.Config, localPath string) (*staticsIgnore, error) {
+	var patterns []string
+	var skipHidden bool
+	if cfg != nil && cfg.StaticsOptions != nil {
+		patterns = append(patterns, cfg.StaticsOptions.Ignore...)
+		skipHidden = cfg.StaticsOptions.SkipHidden
+	}
+
+	ignoreFile := filepath.Join(localPath, ignoreFileName)
+	file, err := os.Open(ignoreFile)
+	switch {
+	case err == nil:
+		defer func() {
+			if err := file.Close(); err != nil {
+				logEvent(logger.Debug, "close_failed", "file", ignoreFile, "error", err)
+			}
+		}()
+		filePatterns, err := dockerignore.ReadAll(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", ignoreFile, err)
+		}
+		patterns = append(patterns, filePatterns...)
+		// The ignore file itself is never uploaded.
+		patterns = append(patterns, ignoreFileName)
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+
+	if len(patterns) == 0 {
+		return &staticsIgnore{skipHidden: skipHidden}, nil
+	}
+	matcher, err := patternmatcher.New(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid statics ignore pattern: %w", err)
+	}
+	return &staticsIgnore{matcher: matcher, skipHidden: skipHidden}, nil
+}
+
+// skip reports whether the walk should skip a file or directory, given its slash-separated
+// path relative to the static's directory. For ignored directories it returns fs.SkipDir,
+// unless a negated pattern could re-include something inside them.
+func (i *staticsIgnore) skip(name string, d fs.DirEntry) (bool, error) {
+	if name == "." {
+		return false, nil
+	}
+	if i.skipHidden && isHidden(name) {
+		if d.IsDir() {
+			return true, fs.SkipDir
+		}
+		return true, nil
+	}
+	if i.matcher == nil {
+		return false, nil
+	}
+	ignored, err := i.matcher.MatchesOrParentMatches(filepath.FromSlash(name))
+	if err != nil || !ignored {
+		return false, err
+	}
+	if !d.IsDir() {
+		return true, nil
+	}
+
+	if i.matcher.Exclusions() {
+		dirPrefix := filepath.FromSlash(name) + string(filepath.Separator)
+		for _, pattern := range i.matcher.Patterns() {
+			if pattern.Exclusion() && strings.HasPrefix(pattern.String()+string(filepath.Separator), dirPrefix) {
+				// Keep walking, the files in it are matched one by one.
+				return true, nil
+			}
+		}
+	}
+	return true, fs.SkipDir
+}
+
+// isHidden reports whether the file or directory at the slash-separated path name is a dotfile.
+// .well-known is never hidden, since it's meant to be served.
+func isHidden(name string) bool {
+	base := path.Base(name)
+	return strings.HasPrefix(base, ".") && base != ".well-known"
+}
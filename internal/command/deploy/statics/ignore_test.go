@@ -0,0 +1,118 @@
+package statics
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestUploadDirectoryIgnore(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{
+		"index.html",
+		".DS_Store",
+		"js/app.js",
+		"js/app.js.map",
+		"js/vendor/lib.js.map",
+		".git/HEAD",
+		"node_modules/left-pad/index.js",
+		"drafts/post.html",
+		"drafts/keep.html",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte("x"), 0o644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ignoreFileName), []byte("# not public\n.git\nnode_modules\ndrafts\n!drafts/keep.html\n"), 0o644))
+
+	cfg := appconfig.NewConfig()
+	cfg.StaticsOptions = &appconfig.StaticsOptions{Ignore: []string{"**/*.map", ".DS_Store"}}
+	client := newMockS3()
+	deployer := &DeployerState{s3: client, bucket: "bucket", appConfig: cfg}
+	require.NoError(t, deployer.uploadDirectory(context.Background(), "root/0/", "", dir))
+
+	assert.Equal(t, []string{
+		"root/0/drafts/keep.html",
+		"root/0/index.html",
+		"root/0/js/app.js",
+	}, client.keys())
+}
+
+func TestStaticsIgnoreSkipsDirectories(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ignoreFileName), []byte("node_modules\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "node_modules"), 0o755))
+
+	ignore, err := loadStaticsIgnore(nil, dir)
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		skip, err := ignore.skip(entry.Name(), entry)
+		assert.True(t, skip, entry.Name())
+		if entry.IsDir() {
+			assert.Equal(t, filepath.SkipDir, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestStaticsIgnoreWithoutPatterns(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte("x"), 0o644))
+
+	client := newMockS3()
+	deployer := &DeployerState{s3: client, bucket: "bucket"}
+	require.NoError(t, deployer.uploadDirectory(context.Background(), "root/0/", "", dir))
+	assert.Equal(t, []string{"root/0/index.html"}, client.keys())
+}
+
+func TestUploadDirectorySkipHidden(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{
+		"index.html",
+		".env",
+		"css/.site.css.swp",
+		"css/site.css",
+		".cache/build.json",
+		".well-known/security.txt",
+		".well-known/acme-challenge/token",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte("x"), 0o644))
+	}
+
+	cfg := appconfig.NewConfig()
+	cfg.StaticsOptions = &appconfig.StaticsOptions{SkipHidden: true}
+	client := newMockS3()
+	deployer := &DeployerState{s3: client, bucket: "bucket", appConfig: cfg}
+	require.NoError(t, deployer.uploadDirectory(context.Background(), "root/0/", "", dir))
+
+	assert.Equal(t, []string{
+		"root/0/.well-known/acme-challenge/token",
+		"root/0/.well-known/security.txt",
+		"root/0/css/site.css",
+		"root/0/index.html",
+	}, client.keys())
+
+	// Hidden directories aren't walked at all
+	ignore, err := loadStaticsIgnore(cfg, dir)
+	require.NoError(t, err)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		if entry.Name() != ".cache" {
+			continue
+		}
+		skip, err := ignore.skip(entry.Name(), entry)
+		assert.True(t, skip)
+		assert.Equal(t, fs.SkipDir, err)
+	}
+}
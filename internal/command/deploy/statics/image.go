@@ -0,0 +1,69 @@
+package statics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+)
+
+// copyDirFromImage is replaced in tests to avoid needing a Docker daemon.
+var copyDirFromImage = imgsrc.CopyDirFromImage
+
+// extractImageStatics copies the statics with an absolute guest_path out of the image being deployed,
+// into a temporary directory that mirrors the image's layout, and returns the statics with their guest_path
+// pointing at the copy. Other statics are returned as they are. The returned function removes the copies.
+func (deployer *DeployerState) extractImageStatics(ctx context.Context, statics []appconfig.Static) ([]appconfig.Static, func(), error) {
+	var guestPaths []string
+	for _, static := range statics {
+		if guestPath := path.Clean(static.GuestPath); path.IsAbs(guestPath) && !slices.Contains(guestPaths, guestPath) {
+			guestPaths = append(guestPaths, guestPath)
+		}
+	}
+	if len(guestPaths) == 0 {
+		return statics, func() {}, nil
+	}
+
+	dir, err := os.MkdirTemp("", "fly-statics-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	// Directories nested in one that's already copied come along with it.
+	slices.SortStableFunc(guestPaths, func(a, b string) int {
+		return strings.Count(a, "/") - strings.Count(b, "/")
+	})
+	var copied []string
+	for _, guestPath := range guestPaths {
+		if slices.ContainsFunc(copied, func(parent string) bool {
+			_, nested := nestedStaticDir(parent, guestPath)
+			return nested
+		}) {
+			continue
+		}
+		if err := copyDirFromImage(ctx, deployer.opts.Image, guestPath, filepath.Join(dir, filepath.FromSlash(guestPath))); err != nil {
+			cleanup()
+			if errors.Is(err, imgsrc.ErrImagePathNotFound) {
+				return nil, nil, fmt.Errorf("static guest_path '%s' doesn't exist in the image %s", guestPath, deployer.opts.Image)
+			}
+			return nil, nil, fmt.Errorf("failed to copy statics at '%s' out of the image: %w", guestPath, err)
+		}
+		copied = append(copied, guestPath)
+	}
+
+	extracted := slices.Clone(statics)
+	for i, static := range extracted {
+		if guestPath := path.Clean(static.GuestPath); path.IsAbs(guestPath) {
+			extracted[i].GuestPath = filepath.ToSlash(filepath.Join(dir, filepath.FromSlash(guestPath)))
+		}
+	}
+	return extracted, cleanup, nil
+}
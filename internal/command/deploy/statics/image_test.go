@@ -0,0 +1,84 @@
+package statics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+)
+
+func TestStaticIsCandidateForTigrisPushFromImage(t *testing.T) {
+	local := appconfig.Static{GuestPath: "public", UrlPrefix: "/"}
+	inImage := appconfig.Static{GuestPath: "/app/public", UrlPrefix: "/"}
+	inBucket := appconfig.Static{GuestPath: "/app/public", UrlPrefix: "/", TigrisBucket: "my-bucket"}
+
+	assert.True(t, StaticIsCandidateForTigrisPush(local, false))
+	assert.False(t, StaticIsCandidateForTigrisPush(inImage, false))
+	assert.False(t, StaticIsCandidateForTigrisPush(inBucket, false))
+
+	assert.True(t, StaticIsCandidateForTigrisPush(local, true))
+	assert.True(t, StaticIsCandidateForTigrisPush(inImage, true))
+	assert.False(t, StaticIsCandidateForTigrisPush(inBucket, true))
+}
+
+// fakeImage stands in for copyDirFromImage, copying from the directories of an image's filesystem.
+func fakeImage(t *testing.T, dirs ...string) (copied *[]string) {
+	copied = &[]string{}
+	original := copyDirFromImage
+	t.Cleanup(func() { copyDirFromImage = original })
+	copyDirFromImage = func(ctx context.Context, imageRef, srcPath, dest string) error {
+		*copied = append(*copied, srcPath)
+		for _, dir := range dirs {
+			if dir == srcPath {
+				if err := os.MkdirAll(dest, 0o755); err != nil {
+					return err
+				}
+				return os.WriteFile(filepath.Join(dest, "index.html"), []byte(imageRef+":"+srcPath), 0o644)
+			}
+		}
+		return fmt.Errorf("%s: %w", srcPath, imgsrc.ErrImagePathNotFound)
+	}
+	return copied
+}
+
+func TestExtractImageStatics(t *testing.T) {
+	copied := fakeImage(t, "/app/public", "/app/docs")
+	deployer := &DeployerState{opts: Options{Image: "registry.fly.io/my-app:deployment-1"}}
+
+	extracted, cleanup, err := deployer.extractImageStatics(context.Background(), []appconfig.Static{
+		{GuestPath: "public", UrlPrefix: "/"},
+		{GuestPath: "/app/public/img", UrlPrefix: "/img"},
+		{GuestPath: "/app/public/", UrlPrefix: "/assets"},
+		{GuestPath: "/app/docs", UrlPrefix: "/docs"},
+	})
+	require.NoError(t, err)
+
+	// Directories nested in another one are copied along with it.
+	assert.Equal(t, []string{"/app/public", "/app/docs"}, *copied)
+	assert.Equal(t, "public", extracted[0].GuestPath)
+	assert.Equal(t, extracted[2].GuestPath+"/img", extracted[1].GuestPath)
+	assert.Equal(t, "/assets", extracted[2].UrlPrefix)
+	content, err := os.ReadFile(filepath.Join(extracted[3].GuestPath, "index.html"))
+	require.NoError(t, err)
+	assert.Equal(t, "registry.fly.io/my-app:deployment-1:/app/docs", string(content))
+
+	cleanup()
+	assert.NoDirExists(t, extracted[3].GuestPath)
+}
+
+func TestExtractImageStaticsMissingPath(t *testing.T) {
+	fakeImage(t, "/app/public")
+	deployer := &DeployerState{opts: Options{Image: "registry.fly.io/my-app:deployment-1"}}
+
+	_, _, err := deployer.extractImageStatics(context.Background(), []appconfig.Static{
+		{GuestPath: "/app/public", UrlPrefix: "/"},
+		{GuestPath: "/app/dist", UrlPrefix: "/dist"},
+	})
+	assert.EqualError(t, err, "static guest_path '/app/dist' doesn't exist in the image registry.fly.io/my-app:deployment-1")
+}
@@ -0,0 +1,92 @@
+package statics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/samber/lo"
+	"github.com/superfly/fly-go"
+)
+
+// uploadRecord describes a file that was uploaded to the bucket.
+type uploadRecord struct {
+	// File is the path of the file, relative to the static's guest path.
+	File        string
+	Size        int64
+	ContentType string
+	// SHA256 is the hash of the local file, only computed when a manifest is written.
+	SHA256 string
+}
+
+// recordUpload records a file uploaded under the prefix dest.
+func (deployer *DeployerState) recordUpload(dest string, record uploadRecord) {
+	deployer.uploadsMu.Lock()
+	defer deployer.uploadsMu.Unlock()
+	if deployer.uploads == nil {
+		deployer.uploads = map[string][]uploadRecord{}
+	}
+	deployer.uploads[dest] = append(deployer.uploads[dest], record)
+}
+
+// takeUploads returns the files uploaded under the prefix dest since it was last called for it.
+// Directories uploaded at once keep their own records.
+func (deployer *DeployerState) takeUploads(dest string) []uploadRecord {
+	deployer.uploadsMu.Lock()
+	defer deployer.uploadsMu.Unlock()
+	uploads := deployer.uploads[dest]
+	delete(deployer.uploads, dest)
+	return uploads
+}
+
+// assetIndex is the machine-readable list of every path served from the statics of a release.
+type assetIndex struct {
+	Version int          `json:"version"`
+	Assets  []assetEntry `json:"assets"`
+}
+
+type assetEntry struct {
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+}
+
+func newAssetEntries(urlPrefix string, uploads []uploadRecord) []assetEntry {
+	return lo.Map(uploads, func(upload uploadRecord, _ int) assetEntry {
+		return assetEntry{
+			Path:        path.Join("/", urlPrefix, upload.File),
+			Size:        upload.Size,
+			ContentType: upload.ContentType,
+		}
+	})
+}
+
+// uploadAssetIndex writes the asset index to the configured key under the version root.
+func (deployer *DeployerState) uploadAssetIndex(ctx context.Context, assets []assetEntry) error {
+	slices.SortFunc(assets, func(a, b assetEntry) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	body, err := json.Marshal(assetIndex{
+		Version: deployer.releaseVersion,
+		Assets:  assets,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Rooting the key before joining keeps it from escaping the version root.
+	key := path.Join(deployer.uploadRoot(), path.Clean("/"+deployer.opts.AssetIndexKey))
+
+	_, err = deployer.s3.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      &deployer.bucket,
+		Key:         &key,
+		Body:        bytes.NewReader(body),
+		ContentType: fly.Pointer("application/json"),
+	})
+	return err
+}
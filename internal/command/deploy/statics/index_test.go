@@ -0,0 +1,80 @@
+package statics
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+// chdir changes the working directory for the duration of the test,
+// since candidate statics are resolved relative to it.
+func chdir(t *testing.T, dir string) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { require.NoError(t, os.Chdir(wd)) })
+}
+
+func TestPushAssetIndex(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public", "css"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("<html></html>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "css", "app.css"), []byte("body{}"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "images"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "images", "logo.svg"), []byte("<svg></svg>"), 0o644))
+	chdir(t, dir)
+
+	client := newMockS3()
+	deployer := &DeployerState{
+		appConfig:      &appconfig.Config{AppName: "my-app"},
+		releaseVersion: 4,
+		opts:           Options{AssetIndexKey: "_assets.json"},
+		s3:             client,
+		bucket:         "bucket",
+		root:           "fly-statics/my-app/4",
+		originalStatics: []appconfig.Static{
+			{GuestPath: "public", UrlPrefix: "/"},
+			{GuestPath: "images", UrlPrefix: "/img"},
+		},
+	}
+	require.NoError(t, deployer.Push(context.Background()))
+
+	obj, ok := client.objects["fly-statics/my-app/4/_assets.json"]
+	require.True(t, ok, "asset index wasn't uploaded")
+	assert.Equal(t, "application/json", obj.contentType)
+
+	var index assetIndex
+	require.NoError(t, json.Unmarshal(obj.body, &index))
+	assert.Equal(t, assetIndex{
+		Version: 4,
+		Assets: []assetEntry{
+			{Path: "/css/app.css", Size: 6, ContentType: "text/css"},
+			{Path: "/img/logo.svg", Size: 11, ContentType: "image/svg+xml"},
+			{Path: "/index.html", Size: 13, ContentType: "text/html"},
+		},
+	}, index)
+}
+
+func TestPushWithoutAssetIndex(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("<html></html>"), 0o644))
+	chdir(t, dir)
+
+	client := newMockS3()
+	deployer := &DeployerState{
+		appConfig:       &appconfig.Config{AppName: "my-app"},
+		s3:              client,
+		bucket:          "bucket",
+		root:            "fly-statics/my-app/1",
+		originalStatics: []appconfig.Static{{GuestPath: "public", UrlPrefix: "/"}},
+	}
+	require.NoError(t, deployer.Push(context.Background()))
+	assert.Equal(t, []string{"fly-statics/my-app/1/.fly-statics-push.json", "fly-statics/my-app/1/0/index.html"}, client.keys())
+}
@@ -0,0 +1,64 @@
+package statics
+
+import (
+	"context"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/logger"
+)
+
+// directoryIndex is an object that serves a directory's index document for requests to the directory.
+type directoryIndex struct {
+	// Key is the directory's key, ending in a slash, like docs/ for a /docs/ request.
+	Key string
+	// Source is the key of the index document in that directory, like docs/index.html.
+	Source string
+}
+
+// directoryIndexes returns the directory objects that make requests like /docs/ resolve to
+// docs/index.html, for every uploaded file named indexDocument. Keys are under dest, which
+// ends in a slash, and the static's own root maps to dest itself.
+func directoryIndexes(dest, indexDocument string, uploads []uploadRecord) []directoryIndex {
+	indexDocument = strings.TrimPrefix(path.Clean("/"+indexDocument), "/")
+	if indexDocument == "" {
+		return nil
+	}
+
+	var indexes []directoryIndex
+	for _, upload := range uploads {
+		if path.Base(upload.File) != indexDocument {
+			continue
+		}
+		key := dest
+		if dir := path.Dir(upload.File); dir != "." {
+			key += dir + "/"
+		}
+		indexes = append(indexes, directoryIndex{Key: key, Source: dest + upload.File})
+	}
+	slices.SortFunc(indexes, func(a, b directoryIndex) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return indexes
+}
+
+// putDirectoryIndexes copies each index document to its directory's key. It runs once the statics
+// are in their final place, since publishing staged statics doesn't keep keys ending in a slash.
+func (deployer *DeployerState) putDirectoryIndexes(ctx context.Context, indexes []directoryIndex) error {
+	for _, index := range indexes {
+		deployer.logEvent(logger.Debug, "index_document", "file", index.Key, "source", index.Source)
+
+		_, err := deployer.s3.CopyObject(ctx, &s3.CopyObjectInput{
+			Bucket:     &deployer.bucket,
+			Key:        fly.Pointer(index.Key),
+			CopySource: fly.Pointer(copySource(deployer.bucket, index.Source)),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package statics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestDirectoryIndexes(t *testing.T) {
+	uploads := []uploadRecord{
+		{File: "index.html"},
+		{File: "docs/index.html"},
+		{File: "docs/guide/index.html"},
+		{File: "docs/guide/intro.html"},
+		{File: "css/app.css"},
+	}
+	assert.Equal(t, []directoryIndex{
+		{Key: "root/0/", Source: "root/0/index.html"},
+		{Key: "root/0/docs/", Source: "root/0/docs/index.html"},
+		{Key: "root/0/docs/guide/", Source: "root/0/docs/guide/index.html"},
+	}, directoryIndexes("root/0/", "index.html", uploads))
+	assert.Equal(t, directoryIndexes("root/0/", "index.html", uploads), directoryIndexes("root/0/", "/index.html", uploads))
+	assert.Empty(t, directoryIndexes("root/0/", "home.html", uploads))
+	assert.Empty(t, directoryIndexes("root/0/", "", uploads))
+}
+
+func TestPushDirectoryIndexes(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "site", "docs"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "site", "css"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "site", "index.html"), []byte("<html>home</html>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "site", "docs", "index.html"), []byte("<html>docs</html>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "site", "css", "app.css"), []byte("body{}"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "raw"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "raw", "index.html"), []byte("<html>raw</html>"), 0o644))
+	chdir(t, dir)
+
+	client := newMockS3()
+	deployer := &DeployerState{
+		appConfig:      &appconfig.Config{AppName: "my-app"},
+		releaseVersion: 2,
+		opts:           Options{Staged: true},
+		s3:             client,
+		bucket:         "bucket",
+		root:           "fly-statics/my-app/2",
+		originalStatics: []appconfig.Static{
+			{GuestPath: "site", UrlPrefix: "/", IndexDocument: "index.html"},
+			{GuestPath: "raw", UrlPrefix: "/raw"},
+		},
+	}
+	require.NoError(t, deployer.Push(context.Background()))
+
+	// Only the static with an index document serves it for directory requests.
+	assert.Equal(t, []string{
+		"fly-statics/my-app/2/.fly-statics-push.json",
+		"fly-statics/my-app/2/0/",
+		"fly-statics/my-app/2/0/css/app.css",
+		"fly-statics/my-app/2/0/docs/",
+		"fly-statics/my-app/2/0/docs/index.html",
+		"fly-statics/my-app/2/0/index.html",
+		"fly-statics/my-app/2/1/index.html",
+	}, client.keys())
+	assert.Equal(t, "<html>docs</html>", string(client.objects["fly-statics/my-app/2/0/docs/"].body))
+	assert.Equal(t, "text/html", client.objects["fly-statics/my-app/2/0/docs/"].contentType)
+
+	require.Len(t, deployer.appConfig.Statics, 2)
+	assert.Equal(t, "index.html", deployer.appConfig.Statics[0].IndexDocument)
+	assert.Empty(t, deployer.appConfig.Statics[1].IndexDocument)
+}
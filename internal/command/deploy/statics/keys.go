@@ -0,0 +1,58 @@
+package statics
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// KeyCase controls how object keys are normalized for hosts that treat paths case-insensitively.
+type KeyCase string
+
+const (
+	// KeyCasePreserve uploads keys exactly as they're named on disk.
+	KeyCasePreserve KeyCase = ""
+	// KeyCaseCheck fails the push if two files differ only by case.
+	KeyCaseCheck KeyCase = "check"
+	// KeyCaseLower lowercases every key, failing the push if that would make two files collide.
+	KeyCaseLower KeyCase = "lower"
+)
+
+func (k KeyCase) validate() error {
+	switch k {
+	case KeyCasePreserve, KeyCaseCheck, KeyCaseLower:
+		return nil
+	default:
+		return fmt.Errorf("invalid statics key case '%s', expected '%s' or '%s'", k, KeyCaseCheck, KeyCaseLower)
+	}
+}
+
+// key returns the object key for a file, relative to the upload destination.
+func (k KeyCase) key(file string) string {
+	if k == KeyCaseLower {
+		return strings.ToLower(file)
+	}
+	return file
+}
+
+// checkCaseCollisions returns an error naming every group of files whose paths differ only by case.
+func checkCaseCollisions(files []string) error {
+	groups := map[string][]string{}
+	for _, file := range files {
+		folded := strings.ToLower(file)
+		groups[folded] = append(groups[folded], file)
+	}
+
+	var collisions []string
+	for _, group := range groups {
+		if len(group) > 1 {
+			slices.Sort(group)
+			collisions = append(collisions, strings.Join(group, ", "))
+		}
+	}
+	if len(collisions) == 0 {
+		return nil
+	}
+	slices.Sort(collisions)
+	return fmt.Errorf("statics contain files whose names differ only by case, which collide on case-insensitive hosts:\n  %s", strings.Join(collisions, "\n  "))
+}
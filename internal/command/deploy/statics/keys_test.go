@@ -0,0 +1,55 @@
+package statics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCaseCollisions(t *testing.T) {
+	require.NoError(t, checkCaseCollisions([]string{"logo.png", "img/logo.png", "index.html"}))
+
+	err := checkCaseCollisions([]string{"Logo.png", "index.html", "logo.png"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Logo.png, logo.png")
+}
+
+func TestUploadDirectoryKeyCase(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Logo.png"), []byte("a"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "logo.png"), []byte("b"), 0o644))
+
+	newDeployer := func(keyCase KeyCase) (*DeployerState, *mockS3) {
+		client := newMockS3()
+		deployer := &DeployerState{s3: client, bucket: "bucket", opts: Options{KeyCase: keyCase}}
+		return deployer, client
+	}
+
+	// Collisions are uploaded untouched unless asked for.
+	deployer, client := newDeployer(KeyCasePreserve)
+	require.NoError(t, deployer.uploadDirectory(context.Background(), "root/0/", "", dir))
+	assert.Equal(t, []string{"root/0/Logo.png", "root/0/logo.png"}, client.keys())
+
+	for _, keyCase := range []KeyCase{KeyCaseCheck, KeyCaseLower} {
+		deployer, client = newDeployer(keyCase)
+		err := deployer.uploadDirectory(context.Background(), "root/0/", "", dir)
+		require.Error(t, err, keyCase)
+		assert.Contains(t, err.Error(), "Logo.png, logo.png")
+		assert.Empty(t, client.puts, keyCase)
+	}
+}
+
+func TestUploadDirectoryLowercaseKeys(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "Images"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Images", "Logo.PNG"), []byte("a"), 0o644))
+
+	client := newMockS3()
+	deployer := &DeployerState{s3: client, bucket: "bucket", opts: Options{KeyCase: KeyCaseLower}}
+	require.NoError(t, deployer.uploadDirectory(context.Background(), "root/0/", "", dir))
+	assert.Equal(t, []string{"root/0/images/logo.png"}, client.keys())
+}
@@ -0,0 +1,68 @@
+package statics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/superfly/flyctl/internal/logger"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// eventLogger returns the logger statics events are written to. It's replaced in tests.
+var eventLogger = func() *logger.Logger { return terminal.DefaultLogger }
+
+// redactedFields are never logged with their value, whatever logs them.
+var redactedFields = map[string]bool{
+	"auth":              true,
+	"tokenized_auth":    true,
+	"token":             true,
+	"access_key_id":     true,
+	"secret_access_key": true,
+}
+
+// logEvent writes a statics event as a single line with its fields, e.g.
+// "statics upload_finished file=0/index.html bytes=512", so that it can be grepped and parsed
+// from CI output. It's only written when flyctl's log level lets level through.
+// Fields are pairs of a key and a value.
+func logEvent(level logger.Level, event string, fields ...any) {
+	log := eventLogger()
+	if log == nil {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("statics ")
+	b.WriteString(event)
+	for i := 0; i+1 < len(fields); i += 2 {
+		key := fmt.Sprint(fields[i])
+		value := fmt.Sprint(fields[i+1])
+		if redactedFields[key] {
+			value = "[redacted]"
+		}
+		if value == "" || strings.ContainsAny(value, " =\"\n") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&b, " %s=%s", key, value)
+	}
+
+	switch level {
+	case logger.Error:
+		log.Error(b.String())
+	case logger.Warn:
+		log.Warn(b.String())
+	case logger.Info:
+		log.Info(b.String())
+	default:
+		log.Debug(b.String())
+	}
+}
+
+// logEvent writes a statics event, with the app, bucket and version of the push as its first fields.
+func (deployer *DeployerState) logEvent(level logger.Level, event string, fields ...any) {
+	var app string
+	if deployer.appConfig != nil {
+		app = deployer.appConfig.AppName
+	}
+	logEvent(level, event, append([]any{"app", app, "bucket", deployer.bucket, "version", deployer.releaseVersion}, fields...)...)
+}
@@ -0,0 +1,86 @@
+package statics
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/logger"
+)
+
+// captureEvents sends statics events at level and above to the returned buffer.
+func captureEvents(t *testing.T, level logger.Level) *bytes.Buffer {
+	var buf bytes.Buffer
+	original := eventLogger
+	t.Cleanup(func() { eventLogger = original })
+	log := logger.New(&buf, level, false)
+	eventLogger = func() *logger.Logger { return log }
+	return &buf
+}
+
+func TestLogEventFields(t *testing.T) {
+	buf := captureEvents(t, logger.Debug)
+	deployer := &DeployerState{appConfig: &appconfig.Config{AppName: "my-app"}, bucket: "my-bucket", releaseVersion: 3}
+
+	deployer.logEvent(logger.Debug, "upload_finished", "file", "fly-statics/my-app/3/0/index.html", "bytes", 512)
+	deployer.logEvent(logger.Warn, "cleanup_failed", "root", "fly-statics/my-app/3", "error", "access denied")
+
+	assert.Equal(t, "DEBUG statics upload_finished app=my-app bucket=my-bucket version=3 file=fly-statics/my-app/3/0/index.html bytes=512\n"+
+		"WARN statics cleanup_failed app=my-app bucket=my-bucket version=3 root=fly-statics/my-app/3 error=\"access denied\"\n", buf.String())
+}
+
+func TestLogEventLevel(t *testing.T) {
+	buf := captureEvents(t, logger.Info)
+
+	logEvent(logger.Debug, "upload_started", "file", "index.html")
+	assert.Empty(t, buf.String())
+
+	logEvent(logger.Info, "cleanup_finished", "deleted", 2)
+	assert.Equal(t, "INFO statics cleanup_finished deleted=2\n", buf.String())
+}
+
+func TestLogEventsNeverIncludeSealedToken(t *testing.T) {
+	buf := captureEvents(t, logger.Debug)
+	deployer := &DeployerState{
+		appConfig: &appconfig.Config{AppName: "my-app"},
+		app:       &fly.App{InternalNumericID: 1234},
+		org:       &fly.Organization{Slug: "my-org", InternalNumericID: "200"},
+		bucket:    "my-app-statics",
+	}
+
+	meta, err := deployer.resealedMetadata(nil, map[string]interface{}{
+		"AWS_ACCESS_KEY_ID":     "tid_access",
+		"AWS_SECRET_ACCESS_KEY": "tsec_secret",
+	})
+	require.NoError(t, err)
+	sealed := meta[staticsMetaTokenizedAuth].(string)
+	require.NotEmpty(t, sealed)
+
+	// Even an event that's handed the token by mistake doesn't write it.
+	deployer.logEvent(logger.Debug, "bucket_found", "tokenized_auth", sealed, "secret_access_key", "tsec_secret")
+
+	// Nor does a push.
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("<html></html>"), 0o644))
+	chdir(t, dir)
+	deployer.s3 = newMockS3()
+	deployer.root = "fly-statics/my-app/1"
+	deployer.releaseVersion = 1
+	deployer.originalStatics = []appconfig.Static{{GuestPath: "public", UrlPrefix: "/"}}
+	require.NoError(t, deployer.Push(context.Background()))
+
+	logs := buf.String()
+	assert.Contains(t, logs, "statics auth_sealed app=my-app bucket=my-app-statics version=0 org=my-org")
+	assert.Contains(t, logs, "statics upload_finished app=my-app bucket=my-app-statics version=1 file=fly-statics/my-app/1/0/index.html")
+	assert.Contains(t, logs, "tokenized_auth=[redacted] secret_access_key=[redacted]")
+	assert.NotContains(t, logs, sealed)
+	assert.NotContains(t, logs, "tsec_secret")
+	assert.NotContains(t, logs, "tid_access")
+}
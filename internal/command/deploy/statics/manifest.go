@@ -0,0 +1,76 @@
+package statics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// pushManifest describes every file a statics push put in the bucket, for tools that purge
+// CDN caches or keep track of what was deployed.
+type pushManifest struct {
+	App     string `json:"app"`
+	Bucket  string `json:"bucket"`
+	Version int    `json:"version"`
+	// Prefix is the key every file of the release is under.
+	Prefix string         `json:"prefix"`
+	Files  []manifestFile `json:"files"`
+}
+
+type manifestFile struct {
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+	SHA256      string `json:"sha256"`
+}
+
+// newManifestFiles returns the manifest entries for the files uploaded to dest.
+func newManifestFiles(dest string, uploads []uploadRecord) []manifestFile {
+	return lo.Map(uploads, func(upload uploadRecord, _ int) manifestFile {
+		return manifestFile{
+			Key:         path.Join(dest, upload.File),
+			Size:        upload.Size,
+			ContentType: upload.ContentType,
+			SHA256:      upload.SHA256,
+		}
+	})
+}
+
+// writeManifest writes the manifest of the last push to the configured path.
+func (deployer *DeployerState) writeManifest() error {
+	files := slices.Clone(deployer.manifestFiles)
+	slices.SortFunc(files, func(a, b manifestFile) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	body, err := json.MarshalIndent(pushManifest{
+		App:     deployer.appConfig.AppName,
+		Bucket:  deployer.bucket,
+		Version: deployer.releaseVersion,
+		Prefix:  deployer.root + "/",
+		Files:   files,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(deployer.opts.ManifestPath, append(body, '\n'), 0o644)
+}
+
+// contentSHA256 returns the hex encoded SHA-256 of body, and rewinds it.
+func contentSHA256(body io.ReadSeeker) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, body); err != nil {
+		return "", err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
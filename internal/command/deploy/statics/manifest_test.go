@@ -0,0 +1,70 @@
+package statics
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func TestFinalizeWritesManifest(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public", "css"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("<html></html>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "css", "app.css"), []byte("body{}"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "images"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "images", "logo.svg"), []byte("<svg></svg>"), 0o644))
+	chdir(t, dir)
+
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+	client := newMockS3()
+	manifestPath := filepath.Join(t.TempDir(), "statics-manifest.json")
+	deployer := &DeployerState{
+		appConfig:      &appconfig.Config{AppName: "my-app"},
+		releaseVersion: 3,
+		s3:             client,
+		bucket:         "bucket",
+		root:           "fly-statics/my-app/3",
+		originalStatics: []appconfig.Static{
+			{GuestPath: "public", UrlPrefix: "/"},
+			{GuestPath: "images", UrlPrefix: "/img"},
+		},
+		opts: Options{ManifestPath: manifestPath},
+	}
+	require.NoError(t, deployer.Push(ctx))
+	require.NoError(t, deployer.Finalize(ctx))
+
+	raw, err := os.ReadFile(manifestPath)
+	require.NoError(t, err)
+	var manifest pushManifest
+	require.NoError(t, json.Unmarshal(raw, &manifest))
+
+	assert.Equal(t, "my-app", manifest.App)
+	assert.Equal(t, "bucket", manifest.Bucket)
+	assert.Equal(t, 3, manifest.Version)
+	assert.Equal(t, "fly-statics/my-app/3/", manifest.Prefix)
+
+	sum := func(content string) string {
+		hash := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(hash[:])
+	}
+	assert.Equal(t, []manifestFile{
+		{Key: "fly-statics/my-app/3/0/css/app.css", Size: 6, ContentType: "text/css", SHA256: sum("body{}")},
+		{Key: "fly-statics/my-app/3/0/index.html", Size: 13, ContentType: "text/html", SHA256: sum("<html></html>")},
+		{Key: "fly-statics/my-app/3/1/logo.svg", Size: 11, ContentType: "image/svg+xml", SHA256: sum("<svg></svg>")},
+	}, manifest.Files)
+
+	// Every file in the manifest is in the bucket
+	for _, file := range manifest.Files {
+		assert.Contains(t, client.keys(), file.Key)
+	}
+}
@@ -0,0 +1,49 @@
+package statics
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// PushMetrics describes how the upload of a push went, to help tune the upload concurrency
+// of large pushes or spot throttling by the tokenizer proxy.
+type PushMetrics struct {
+	// Files and Bytes count the files uploaded or copied from the previous release.
+	Files int64
+	Bytes int64
+	// Duration is how long the push took.
+	Duration time.Duration
+	// Retries counts the upload attempts that failed and were tried again.
+	Retries int64
+	// PeakConcurrency is the most files that were being uploaded at once.
+	PeakConcurrency int64
+}
+
+// uploadConcurrency tracks how many files are being uploaded at once, and the most there were.
+type uploadConcurrency struct {
+	current atomic.Int64
+	peak    atomic.Int64
+}
+
+// start records that a file started uploading. The returned function records that it finished.
+func (c *uploadConcurrency) start() func() {
+	current := c.current.Add(1)
+	for {
+		peak := c.peak.Load()
+		if current <= peak || c.peak.CompareAndSwap(peak, current) {
+			break
+		}
+	}
+	return func() { c.current.Add(-1) }
+}
+
+// Metrics returns what the last Push uploaded, and how.
+func (deployer *DeployerState) Metrics() PushMetrics {
+	return PushMetrics{
+		Files:           deployer.uploadedFiles.Load(),
+		Bytes:           deployer.uploadedBytes.Load(),
+		Duration:        deployer.pushDuration,
+		Retries:         deployer.uploadRetries.Load(),
+		PeakConcurrency: deployer.concurrency.peak.Load(),
+	}
+}
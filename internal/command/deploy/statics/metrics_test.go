@@ -0,0 +1,70 @@
+package statics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestPushMetrics(t *testing.T) {
+	withoutRetryDelay(t)
+	t.Setenv(uploadConcurrencyEnvKey, "3")
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public"), 0o755))
+	for i := 0; i < 6; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "public", fmt.Sprintf("file-%d.txt", i)), []byte(strings.Repeat("x", 10*(i+1))), 0o644))
+	}
+	chdir(t, dir)
+
+	// The first three uploads wait for each other, so three are in flight at once.
+	var barrier sync.WaitGroup
+	barrier.Add(3)
+	var started, flaky0, flaky1 atomic.Int32
+	client := newMockS3()
+	client.PutObjectFunc = func(ctx context.Context, params *s3.PutObjectInput) error {
+		if !strings.HasSuffix(*params.Key, ".txt") {
+			return nil
+		}
+		if started.Add(1) <= 3 {
+			barrier.Done()
+			barrier.Wait()
+		}
+		// Two files fail once each, and succeed when retried.
+		switch {
+		case strings.HasSuffix(*params.Key, "file-0.txt") && flaky0.Add(1) == 1,
+			strings.HasSuffix(*params.Key, "file-4.txt") && flaky1.Add(1) == 1:
+			return errors.New("connection reset by peer")
+		}
+		return nil
+	}
+
+	deployer := &DeployerState{
+		appConfig:       &appconfig.Config{AppName: "my-app"},
+		releaseVersion:  1,
+		s3:              client,
+		bucket:          "bucket",
+		root:            "fly-statics/my-app/1",
+		originalStatics: []appconfig.Static{{GuestPath: "public", UrlPrefix: "/"}},
+	}
+	require.NoError(t, deployer.Push(context.Background()))
+
+	metrics := deployer.Metrics()
+	assert.Equal(t, int64(6), metrics.Files)
+	assert.Equal(t, int64(10+20+30+40+50+60), metrics.Bytes)
+	assert.Equal(t, int64(2), metrics.Retries)
+	assert.Equal(t, int64(3), metrics.PeakConcurrency)
+	assert.Positive(t, metrics.Duration)
+	assert.Zero(t, deployer.concurrency.current.Load())
+}
@@ -0,0 +1,85 @@
+package statics
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// webContentTypes pins the content type of common web assets.
+// mime.TypeByExtension depends on the host's mime database and http.DetectContentType
+// reports most text formats as text/plain, both of which can break browsers
+// (e.g. ES modules served as text/plain refuse to load).
+var webContentTypes = map[string]string{
+	".css":         "text/css",
+	".html":        "text/html",
+	".ico":         "image/x-icon",
+	".js":          "text/javascript",
+	".json":        "application/json",
+	".map":         "application/json",
+	".mjs":         "text/javascript",
+	".svg":         "image/svg+xml",
+	".wasm":        "application/wasm",
+	".webmanifest": "application/manifest+json",
+	".webp":        "image/webp",
+	".woff":        "font/woff",
+	".woff2":       "font/woff2",
+}
+
+// detectContentType picks the content type for a static file.
+// The overrides from statics_options.content_types win, then known web extensions,
+// then the system mime database, and finally content sniffing.
+// The reader is rewound to the start of the file if it had to be sniffed.
+func detectContentType(file string, reader io.ReadSeeker, overrides map[string]string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(file))
+	if mimeType, ok := overrides[ext]; ok {
+		return mimeType, nil
+	}
+	if mimeType, ok := webContentTypes[ext]; ok {
+		return mimeType, nil
+	}
+	if mimeType := mime.TypeByExtension(ext); mimeType != "" {
+		return mimeType, nil
+	}
+
+	first512 := make([]byte, 512)
+	n, err := reader.Read(first512)
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read static file %s: %w", file, err)
+	}
+	if _, err := reader.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to seek static file %s: %w", file, err)
+	}
+	return http.DetectContentType(first512[:n]), nil
+}
+
+// precompressedEncodings maps the suffixes of pre-compressed files to their Content-Encoding.
+var precompressedEncodings = map[string]string{
+	".gz": "gzip",
+	".br": "br",
+}
+
+// precompressedEncoding returns the Content-Encoding of a file that a build emitted pre-compressed,
+// e.g. main.js.gz, along with the name its content type should be detected from (main.js).
+// Files that aren't pre-compressed web assets, like archive.tar.gz, return an empty encoding
+// and are served as is.
+func precompressedEncoding(file string) (encoding, typeFile string) {
+	ext := strings.ToLower(filepath.Ext(file))
+	encoding, ok := precompressedEncodings[ext]
+	if !ok {
+		return "", file
+	}
+	typeFile = strings.TrimSuffix(file, filepath.Ext(file))
+	innerExt := strings.ToLower(filepath.Ext(typeFile))
+	mimeType, ok := webContentTypes[innerExt]
+	if !ok {
+		mimeType = mime.TypeByExtension(innerExt)
+	}
+	if mimeType == "" || !isCompressible(mimeType) {
+		return "", file
+	}
+	return encoding, typeFile
+}
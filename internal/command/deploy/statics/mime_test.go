@@ -0,0 +1,104 @@
+package statics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestDetectContentType(t *testing.T) {
+	cases := map[string]string{
+		"app.mjs":             "text/javascript",
+		"assets/app.js.map":   "application/json",
+		"favicon.ico":         "image/x-icon",
+		"site.webmanifest":    "application/manifest+json",
+		"module.wasm":         "application/wasm",
+		"UPPERCASE.MJS":       "text/javascript",
+		"no-extension-at-all": "text/html; charset=utf-8",
+	}
+
+	for file, want := range cases {
+		reader := strings.NewReader("<!DOCTYPE html><html></html>")
+		got, err := detectContentType(file, reader, nil)
+		require.NoError(t, err, file)
+		assert.Equal(t, want, got, file)
+	}
+}
+
+func TestDetectContentTypeOverrides(t *testing.T) {
+	overrides := map[string]string{
+		".wasm": "application/x-custom-wasm",
+		".txt":  "text/markdown",
+		".blob": "application/x-blob",
+	}
+	cases := map[string]string{
+		"module.wasm":  "application/x-custom-wasm",
+		"README.TXT":   "text/markdown",
+		"data.blob":    "application/x-blob",
+		"app.mjs":      "text/javascript",
+		"no-extension": "text/html; charset=utf-8",
+	}
+
+	for file, want := range cases {
+		reader := strings.NewReader("<!DOCTYPE html><html></html>")
+		got, err := detectContentType(file, reader, overrides)
+		require.NoError(t, err, file)
+		assert.Equal(t, want, got, file)
+	}
+}
+
+func TestDetectContentTypeEmptyFile(t *testing.T) {
+	got, err := detectContentType("empty", strings.NewReader(""), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", got)
+}
+
+func TestPrecompressedEncoding(t *testing.T) {
+	cases := map[string][2]string{
+		"app.css.gz":        {"gzip", "app.css"},
+		"assets/main.js.br": {"br", "assets/main.js"},
+		"INDEX.HTML.GZ":     {"gzip", "INDEX.HTML"},
+		"archive.tar.gz":    {"", "archive.tar.gz"},
+		"logo.png.gz":       {"", "logo.png.gz"},
+		"data.gz":           {"", "data.gz"},
+		"app.css":           {"", "app.css"},
+	}
+
+	for file, want := range cases {
+		encoding, typeFile := precompressedEncoding(file)
+		assert.Equal(t, want[0], encoding, file)
+		assert.Equal(t, want[1], typeFile, file)
+	}
+}
+
+func TestUploadDirectoryPrecompressed(t *testing.T) {
+	gzipped := []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\x03")
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.css.gz"), gzipped, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.js.br"), []byte("brotli"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "archive.tar.gz"), gzipped, 0o644))
+
+	// Pre-compressed files aren't compressed a second time
+	cfg := appconfig.NewConfig()
+	cfg.StaticsOptions = &appconfig.StaticsOptions{Compress: true}
+	client := newMockS3()
+	deployer := &DeployerState{s3: client, bucket: "bucket", appConfig: cfg}
+	require.NoError(t, deployer.uploadDirectory(context.Background(), "root/0/", "", dir))
+
+	headers := map[string][2]string{}
+	for _, put := range client.puts {
+		headers[*put.Key] = [2]string{lo.FromPtr(put.ContentType), lo.FromPtr(put.ContentEncoding)}
+	}
+	assert.Equal(t, [2]string{"text/css", "gzip"}, headers["root/0/app.css.gz"])
+	assert.Equal(t, [2]string{"text/javascript", "br"}, headers["root/0/app.js.br"])
+	assert.Equal(t, "", headers["root/0/archive.tar.gz"][1])
+	assert.Equal(t, gzipped, client.objects["root/0/app.css.gz"].body)
+}
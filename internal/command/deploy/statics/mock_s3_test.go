@@ -0,0 +1,305 @@
+package statics
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/samber/lo"
+	fly "github.com/superfly/fly-go"
+)
+
+var _ s3API = (*mockS3)(nil)
+
+type mockObject struct {
+	body        []byte
+	contentType string
+}
+
+// mockS3 is an in-memory bucket that records the calls made against it.
+type mockS3 struct {
+	mu sync.Mutex
+
+	objects map[string]mockObject
+	// pageSize is the maximum number of keys returned per ListObjectsV2 page.
+	pageSize int
+
+	PutObjectFunc  func(ctx context.Context, params *s3.PutObjectInput) error
+	UploadPartFunc func(ctx context.Context, params *s3.UploadPartInput) error
+	// DeleteObjectsFunc fails a whole DeleteObjects request when it returns an error.
+	DeleteObjectsFunc func(ctx context.Context, params *s3.DeleteObjectsInput) error
+
+	// multipart holds the parts of in-progress multipart uploads, by upload ID and part number.
+	multipart map[string]map[int32][]byte
+	uploadIDs int
+
+	puts    []*s3.PutObjectInput
+	copies  []*s3.CopyObjectInput
+	gets    []*s3.GetObjectInput
+	heads   []*s3.HeadObjectInput
+	lists   []*s3.ListObjectsV2Input
+	deletes []*s3.DeleteObjectsInput
+
+	createMultiparts   []*s3.CreateMultipartUploadInput
+	uploadParts        []*s3.UploadPartInput
+	completeMultiparts []*s3.CompleteMultipartUploadInput
+	abortMultiparts    []*s3.AbortMultipartUploadInput
+}
+
+func newMockS3() *mockS3 {
+	return &mockS3{
+		objects:   map[string]mockObject{},
+		pageSize:  1000,
+		multipart: map[string]map[int32][]byte{},
+	}
+}
+
+func (m *mockS3) addObject(key, body string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = mockObject{body: []byte(body)}
+}
+
+func (m *mockS3) keys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.objects))
+	for k := range m.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (m *mockS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	if m.PutObjectFunc != nil {
+		if err := m.PutObjectFunc(ctx, params); err != nil {
+			return nil, err
+		}
+	}
+	body, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.puts = append(m.puts, params)
+	m.objects[*params.Key] = mockObject{body: body, contentType: lo.FromPtr(params.ContentType)}
+	return &s3.PutObjectOutput{ChecksumSHA256: mockChecksum(body)}, nil
+}
+
+func (m *mockS3) CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.copies = append(m.copies, params)
+
+	source, err := url.PathUnescape(*params.CopySource)
+	if err != nil {
+		return nil, err
+	}
+	_, key, _ := strings.Cut(source, "/")
+	obj, ok := m.objects[key]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	if params.MetadataDirective == types.MetadataDirectiveReplace {
+		obj.contentType = lo.FromPtr(params.ContentType)
+	}
+	m.objects[*params.Key] = obj
+	return &s3.CopyObjectOutput{}, nil
+}
+
+func (m *mockS3) CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.createMultiparts = append(m.createMultiparts, params)
+	m.uploadIDs++
+	uploadID := strconv.Itoa(m.uploadIDs)
+	m.multipart[uploadID] = map[int32][]byte{}
+	return &s3.CreateMultipartUploadOutput{UploadId: &uploadID}, nil
+}
+
+func (m *mockS3) UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	if m.UploadPartFunc != nil {
+		if err := m.UploadPartFunc(ctx, params); err != nil {
+			return nil, err
+		}
+	}
+	body, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.uploadParts = append(m.uploadParts, params)
+	parts, ok := m.multipart[*params.UploadId]
+	if !ok {
+		return nil, &types.NoSuchUpload{}
+	}
+	parts[*params.PartNumber] = body
+	return &s3.UploadPartOutput{
+		ETag:           fly.Pointer(fmt.Sprintf(`"%x"`, md5.Sum(body))),
+		ChecksumSHA256: mockChecksum(body),
+	}, nil
+}
+
+func (m *mockS3) CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.completeMultiparts = append(m.completeMultiparts, params)
+	parts, ok := m.multipart[*params.UploadId]
+	if !ok {
+		return nil, &types.NoSuchUpload{}
+	}
+	var body []byte
+	for _, part := range params.MultipartUpload.Parts {
+		body = append(body, parts[*part.PartNumber]...)
+	}
+	delete(m.multipart, *params.UploadId)
+
+	var contentType string
+	for _, create := range m.createMultiparts {
+		if *create.Key == *params.Key {
+			contentType = lo.FromPtr(create.ContentType)
+		}
+	}
+	m.objects[*params.Key] = mockObject{body: body, contentType: contentType}
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (m *mockS3) AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.abortMultiparts = append(m.abortMultiparts, params)
+	delete(m.multipart, *params.UploadId)
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func (m *mockS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gets = append(m.gets, params)
+	obj, ok := m.objects[*params.Key]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{
+		Body:          io.NopCloser(bytes.NewReader(obj.body)),
+		ContentType:   fly.Pointer(obj.contentType),
+		ContentLength: fly.Pointer(int64(len(obj.body))),
+	}, nil
+}
+
+func (m *mockS3) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.heads = append(m.heads, params)
+	obj, ok := m.objects[*params.Key]
+	if !ok {
+		return nil, &types.NotFound{}
+	}
+	return &s3.HeadObjectOutput{
+		ContentType:   fly.Pointer(obj.contentType),
+		ContentLength: fly.Pointer(int64(len(obj.body))),
+		ETag:          fly.Pointer(fmt.Sprintf(`"%x"`, md5.Sum(obj.body))),
+	}, nil
+}
+
+func (m *mockS3) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lists = append(m.lists, params)
+
+	prefix := lo.FromPtr(params.Prefix)
+	delimiter := lo.FromPtr(params.Delimiter)
+
+	// Collect every matching entry (objects and common prefixes) in key order.
+	type entry struct {
+		key      string
+		isPrefix bool
+	}
+	var entries []entry
+	seenPrefixes := map[string]bool{}
+	keys := make([]string, 0, len(m.objects))
+	for k := range m.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			if idx := strings.Index(k[len(prefix):], delimiter); idx >= 0 {
+				common := k[:len(prefix)+idx+len(delimiter)]
+				if !seenPrefixes[common] {
+					seenPrefixes[common] = true
+					entries = append(entries, entry{key: common, isPrefix: true})
+				}
+				continue
+			}
+		}
+		entries = append(entries, entry{key: k})
+	}
+
+	start := 0
+	if params.ContinuationToken != nil {
+		var err error
+		start, err = strconv.Atoi(*params.ContinuationToken)
+		if err != nil {
+			return nil, fmt.Errorf("bad continuation token: %w", err)
+		}
+	}
+	end := min(start+m.pageSize, len(entries))
+
+	out := &s3.ListObjectsV2Output{IsTruncated: fly.Pointer(end < len(entries))}
+	for _, e := range entries[start:end] {
+		if e.isPrefix {
+			out.CommonPrefixes = append(out.CommonPrefixes, types.CommonPrefix{Prefix: fly.Pointer(e.key)})
+		} else {
+			out.Contents = append(out.Contents, types.Object{
+				Key:  fly.Pointer(e.key),
+				Size: fly.Pointer(int64(len(m.objects[e.key].body))),
+				ETag: fly.Pointer(fmt.Sprintf(`"%x"`, md5.Sum(m.objects[e.key].body))),
+			})
+		}
+	}
+	if end < len(entries) {
+		out.NextContinuationToken = fly.Pointer(strconv.Itoa(end))
+	}
+	return out, nil
+}
+
+func (m *mockS3) DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deletes = append(m.deletes, params)
+	if m.DeleteObjectsFunc != nil {
+		if err := m.DeleteObjectsFunc(ctx, params); err != nil {
+			return nil, err
+		}
+	}
+	for _, obj := range params.Delete.Objects {
+		delete(m.objects, *obj.Key)
+	}
+	return &s3.DeleteObjectsOutput{}, nil
+}
+
+// mockChecksum is the checksum Tigris reports for what it received.
+func mockChecksum(body []byte) *string {
+	sum := sha256.Sum256(body)
+	return fly.Pointer(base64.StdEncoding.EncodeToString(sum[:]))
+}
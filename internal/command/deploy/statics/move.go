@@ -45,7 +45,7 @@ func MoveBucket(
 
 	prevBucketName := prevBucketMeta[staticsMetaBucketName].(string)
 
-	deployer := Deployer(appConfig, app, targetOrg, app.CurrentRelease.Version)
+	deployer := Deployer(appConfig, app, targetOrg, app.CurrentRelease.Version, Options{})
 	err = deployer.Configure(ctx)
 	if err != nil {
 		return err
@@ -79,7 +79,7 @@ func MoveBucket(
 	return nil
 }
 
-func transferFiles(ctx context.Context, oldS3Client *s3.Client, oldBucket string, newS3Client *s3.Client, newBucket string) error {
+func transferFiles(ctx context.Context, oldS3Client s3API, oldBucket string, newS3Client s3API, newBucket string) error {
 
 	const workerCount = 5
 
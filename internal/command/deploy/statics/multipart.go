@@ -0,0 +1,148 @@
+package statics
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/logger"
+)
+
+var (
+	// multipartThreshold is the size above which files are uploaded in parts.
+	multipartThreshold int64 = 16 << 20
+	// multipartPartSize is the size of each part of a multipart upload. S3 requires at least 5MiB.
+	multipartPartSize int64 = 8 << 20
+)
+
+// putObject uploads input, splitting its body into parts if it's larger than multipartThreshold.
+// Large files then don't have to be sent as a single long-running request.
+//
+// Every request carries the SHA256 of its body, so that Tigris rejects anything corrupted on the way
+// through the tokenizer proxy, and the checksum Tigris reports back is checked against it.
+func (deployer *DeployerState) putObject(ctx context.Context, input *s3.PutObjectInput, body io.ReadSeeker, size int64) error {
+	if size > multipartThreshold {
+		return deployer.putMultipart(ctx, input)
+	}
+
+	checksum, err := checksumSHA256(body)
+	if err != nil {
+		return err
+	}
+	input.ChecksumAlgorithm = types.ChecksumAlgorithmSha256
+	input.ChecksumSHA256 = &checksum
+
+	output, err := deployer.s3.PutObject(ctx, input)
+	if err != nil {
+		return err
+	}
+	return verifyChecksum(*input.Key, checksum, output.ChecksumSHA256)
+}
+
+// putMultipart uploads input with the multipart upload flow, one part at a time.
+// The upload is aborted if any part fails, so that Tigris doesn't keep the parts around.
+func (deployer *DeployerState) putMultipart(ctx context.Context, input *s3.PutObjectInput) (err error) {
+	created, err := deployer.s3.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:            input.Bucket,
+		Key:               input.Key,
+		ContentType:       input.ContentType,
+		ContentEncoding:   input.ContentEncoding,
+		CacheControl:      input.CacheControl,
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+		_, abortErr := deployer.s3.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   input.Bucket,
+			Key:      input.Key,
+			UploadId: created.UploadId,
+		})
+		if abortErr != nil {
+			deployer.logEvent(logger.Warn, "multipart_abort_failed", "file", *input.Key, "error", abortErr)
+		}
+	}()
+
+	var parts []types.CompletedPart
+	buf := make([]byte, multipartPartSize)
+	for partNumber := int32(1); ; partNumber++ {
+		n, readErr := io.ReadFull(input.Body, buf)
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil && !errors.Is(readErr, io.ErrUnexpectedEOF) {
+			return readErr
+		}
+
+		part := bytes.NewReader(buf[:n])
+		checksum, err := checksumSHA256(part)
+		if err != nil {
+			return err
+		}
+		uploaded, err := deployer.s3.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:            input.Bucket,
+			Key:               input.Key,
+			UploadId:          created.UploadId,
+			PartNumber:        fly.Pointer(partNumber),
+			Body:              part,
+			ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+			ChecksumSHA256:    &checksum,
+		})
+		if err != nil {
+			return err
+		}
+		if err := verifyChecksum(fmt.Sprintf("%s (part %d)", *input.Key, partNumber), checksum, uploaded.ChecksumSHA256); err != nil {
+			return err
+		}
+		parts = append(parts, types.CompletedPart{
+			ETag:           uploaded.ETag,
+			PartNumber:     fly.Pointer(partNumber),
+			ChecksumSHA256: &checksum,
+		})
+
+		if errors.Is(readErr, io.ErrUnexpectedEOF) {
+			break
+		}
+	}
+
+	_, err = deployer.s3.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          input.Bucket,
+		Key:             input.Key,
+		UploadId:        created.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	return err
+}
+
+// checksumSHA256 returns the base64-encoded SHA256 of body, as S3 expects it, and rewinds body.
+func checksumSHA256(body io.ReadSeeker) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, body); err != nil {
+		return "", err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil)), nil
+}
+
+// verifyChecksum checks the checksum Tigris computed for an upload against the one that was sent.
+// A missing checksum isn't an error, not every server reports one.
+func verifyChecksum(key, sent string, received *string) error {
+	if received == nil || *received == sent {
+		return nil
+	}
+	return fmt.Errorf("checksum mismatch uploading %s: sent %s, but the bucket stored %s", key, sent, *received)
+}
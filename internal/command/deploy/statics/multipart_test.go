@@ -0,0 +1,116 @@
+package statics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withSmallMultipartParts(t *testing.T) {
+	threshold, partSize := multipartThreshold, multipartPartSize
+	multipartThreshold, multipartPartSize = 64, 32
+	t.Cleanup(func() { multipartThreshold, multipartPartSize = threshold, partSize })
+}
+
+func TestUploadDirectoryMultipart(t *testing.T) {
+	withSmallMultipartParts(t)
+
+	large := bytes.Repeat([]byte("0123456789"), 10)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "video.bin"), large, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "small.txt"), []byte("small"), 0o644))
+
+	client := newMockS3()
+	deployer := &DeployerState{s3: client, bucket: "bucket"}
+	require.NoError(t, deployer.uploadDirectory(context.Background(), "root/0/", "", dir))
+
+	require.Len(t, client.createMultiparts, 1)
+	assert.Equal(t, "root/0/video.bin", *client.createMultiparts[0].Key)
+	// 100 bytes in 32 byte parts
+	assert.Len(t, client.uploadParts, 4)
+	require.Len(t, client.completeMultiparts, 1)
+	assert.Len(t, client.completeMultiparts[0].MultipartUpload.Parts, 4)
+	assert.Equal(t, large, client.objects["root/0/video.bin"].body)
+
+	// Small files still use a single PutObject
+	require.Len(t, client.puts, 1)
+	assert.Equal(t, "root/0/small.txt", *client.puts[0].Key)
+}
+
+func TestUploadDirectoryMultipartAbortsOnFailure(t *testing.T) {
+	withSmallMultipartParts(t)
+	withoutRetryDelay(t)
+	t.Setenv(uploadAttemptsEnvKey, "1")
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "video.bin"), bytes.Repeat([]byte("x"), 100), 0o644))
+
+	client := newMockS3()
+	client.UploadPartFunc = func(ctx context.Context, params *s3.UploadPartInput) error {
+		if *params.PartNumber == 2 {
+			return errors.New("boom")
+		}
+		return nil
+	}
+	deployer := &DeployerState{s3: client, bucket: "bucket"}
+	require.Error(t, deployer.uploadDirectory(context.Background(), "root/0/", "", dir))
+
+	assert.Len(t, client.abortMultiparts, 1)
+	assert.Empty(t, client.completeMultiparts)
+	assert.Empty(t, client.multipart)
+	assert.Empty(t, client.keys())
+}
+
+func TestUploadDirectoryChecksums(t *testing.T) {
+	withSmallMultipartParts(t)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte("hello"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "video.bin"), bytes.Repeat([]byte("x"), 100), 0o644))
+
+	client := newMockS3()
+	deployer := &DeployerState{s3: client, bucket: "bucket"}
+	require.NoError(t, deployer.uploadDirectory(context.Background(), "root/0/", "", dir))
+
+	require.Len(t, client.puts, 1)
+	assert.Equal(t, types.ChecksumAlgorithmSha256, client.puts[0].ChecksumAlgorithm)
+	// echo -n hello | openssl dgst -sha256 -binary | base64
+	assert.Equal(t, "LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=", lo.FromPtr(client.puts[0].ChecksumSHA256))
+
+	require.Len(t, client.createMultiparts, 1)
+	assert.Equal(t, types.ChecksumAlgorithmSha256, client.createMultiparts[0].ChecksumAlgorithm)
+	for i, part := range client.uploadParts {
+		assert.Equal(t, types.ChecksumAlgorithmSha256, part.ChecksumAlgorithm)
+		assert.NotEmpty(t, lo.FromPtr(part.ChecksumSHA256))
+		assert.Equal(t, part.ChecksumSHA256, client.completeMultiparts[0].MultipartUpload.Parts[i].ChecksumSHA256)
+	}
+}
+
+func TestUploadDirectoryChecksumMismatch(t *testing.T) {
+	withoutRetryDelay(t)
+	t.Setenv(uploadAttemptsEnvKey, "1")
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte("hello"), 0o644))
+
+	client := newMockS3()
+	client.PutObjectFunc = func(ctx context.Context, params *s3.PutObjectInput) error {
+		// Corrupted in transit
+		params.Body = strings.NewReader("jello")
+		return nil
+	}
+	deployer := &DeployerState{s3: client, bucket: "bucket"}
+	err := deployer.uploadDirectory(context.Background(), "root/0/", "", dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch uploading root/0/index.html")
+}
@@ -0,0 +1,55 @@
+package statics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const notifyTimeout = 10 * time.Second
+
+// pushNotification is the payload POSTed to the notify URL after a successful push.
+type pushNotification struct {
+	App     string `json:"app"`
+	Bucket  string `json:"bucket"`
+	Version int    `json:"version"`
+	Files   int64  `json:"files"`
+	Bytes   int64  `json:"bytes"`
+}
+
+// notify tells the configured notify URL that the statics push has completed.
+func (deployer *DeployerState) notify(ctx context.Context) error {
+	body, err := json.Marshal(pushNotification{
+		App:     deployer.appConfig.AppName,
+		Bucket:  deployer.bucket,
+		Version: deployer.releaseVersion,
+		Files:   deployer.uploadedFiles.Load(),
+		Bytes:   deployer.uploadedBytes.Load(),
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, deployer.opts.NotifyURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notify URL responded with %s", resp.Status)
+	}
+	return nil
+}
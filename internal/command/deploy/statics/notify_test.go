@@ -0,0 +1,49 @@
+package statics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestNotify(t *testing.T) {
+	var got pushNotification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer server.Close()
+
+	deployer := Deployer(&appconfig.Config{AppName: "my-app"}, nil, nil, 7, Options{NotifyURL: server.URL})
+	deployer.bucket = "my-bucket"
+	deployer.uploadedFiles.Add(3)
+	deployer.uploadedBytes.Add(1024)
+
+	require.NoError(t, deployer.notify(context.Background()))
+	assert.Equal(t, pushNotification{
+		App:     "my-app",
+		Bucket:  "my-bucket",
+		Version: 7,
+		Files:   3,
+		Bytes:   1024,
+	}, got)
+}
+
+func TestNotifyErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	deployer := Deployer(&appconfig.Config{AppName: "my-app"}, nil, nil, 1, Options{NotifyURL: server.URL})
+	err := deployer.notify(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "500")
+}
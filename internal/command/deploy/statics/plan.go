@@ -0,0 +1,39 @@
+package statics
+
+import (
+	"path"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+// PlannedStatic describes what a push would do with one of the app's statics.
+type PlannedStatic struct {
+	appconfig.Static
+	// SkipReason explains why the static isn't pushed to tigris. It's empty for statics that are.
+	SkipReason string
+	// Files and Bytes count what would be uploaded.
+	Files int
+	Bytes int64
+}
+
+// Plan works out which of the app's statics would be pushed to tigris, and how much would be uploaded,
+// without creating a bucket or uploading anything.
+func Plan(appConfig *appconfig.Config) ([]PlannedStatic, error) {
+	var plan []PlannedStatic
+	for _, static := range appConfig.Statics {
+		planned := PlannedStatic{Static: static, SkipReason: staticSkipReason(static, false)}
+		if planned.SkipReason == "" {
+			if err := checkStaticDir(static); err != nil {
+				return nil, err
+			}
+			files, bytes, err := listStaticFiles(appConfig, path.Clean(static.GuestPath))
+			if err != nil {
+				return nil, err
+			}
+			planned.Files = len(files)
+			planned.Bytes = bytes
+		}
+		plan = append(plan, planned)
+	}
+	return plan, nil
+}
@@ -0,0 +1,42 @@
+package statics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestPlan(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public", "css"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("<html></html>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "css", "app.css"), []byte("body{}"), 0o644))
+	chdir(t, dir)
+
+	cfg := appconfig.NewConfig()
+	cfg.Statics = []appconfig.Static{
+		{GuestPath: "public", UrlPrefix: "/"},
+		{GuestPath: "/app/public", UrlPrefix: "/app"},
+		{GuestPath: "assets", UrlPrefix: "/assets", TigrisBucket: "my-bucket"},
+		{GuestPath: "", UrlPrefix: "/empty"},
+	}
+
+	plan, err := Plan(cfg)
+	require.NoError(t, err)
+	require.Len(t, plan, 4)
+
+	assert.Empty(t, plan[0].SkipReason)
+	assert.Equal(t, 2, plan[0].Files)
+	assert.Equal(t, int64(19), plan[0].Bytes)
+
+	assert.Contains(t, plan[1].SkipReason, "absolute")
+	assert.Contains(t, plan[2].SkipReason, "my-bucket")
+	assert.Contains(t, plan[3].SkipReason, "no guest_path")
+	for _, planned := range plan[1:] {
+		assert.Zero(t, planned.Files)
+	}
+}
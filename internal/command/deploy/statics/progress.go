@@ -0,0 +1,101 @@
+package statics
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// uploadProgress reports how far along the upload of one or more directories is.
+// On a terminal it drives the progress indicator, otherwise it prints a line every
+// tenth of the way through. A nil *iostreams.IOStreams keeps it silent.
+type uploadProgress struct {
+	io         *iostreams.IOStreams
+	tty        bool
+	totalFiles int
+	totalBytes int64
+	// step is the number of files between lines when not on a terminal.
+	step int
+
+	mu    sync.Mutex
+	files int
+	bytes int64
+}
+
+func newUploadProgress(io *iostreams.IOStreams, totalFiles int, totalBytes int64) *uploadProgress {
+	p := &uploadProgress{io: io, step: 1}
+	p.addTotals(totalFiles, totalBytes)
+	return p
+}
+
+// addTotals adds the files of another directory to the upload. The progress indicator
+// starts with the first files.
+func (p *uploadProgress) addTotals(files int, bytes int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.totalFiles += files
+	p.totalBytes += bytes
+	p.step = max(p.totalFiles/10, 1)
+
+	switch {
+	case p.io == nil || p.totalFiles == 0:
+	case p.tty:
+		p.io.ChangeProgressIndicatorMsg(p.message())
+	default:
+		p.tty = p.io.IsStdoutTTY() && p.io.IsStderrTTY()
+		if p.tty {
+			p.io.StartProgressIndicatorMsg(p.message())
+		}
+	}
+}
+
+func (p *uploadProgress) message() string {
+	return fmt.Sprintf("Uploading statics: %d/%d files (%s of %s)",
+		p.files, p.totalFiles, humanize.Bytes(uint64(p.bytes)), humanize.Bytes(uint64(p.totalBytes)))
+}
+
+// fileDone records that a file of the given size was uploaded.
+func (p *uploadProgress) fileDone(size int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.files++
+	p.bytes += size
+
+	switch {
+	case p.io == nil:
+	case p.tty:
+		p.io.ChangeProgressIndicatorMsg(p.message())
+	case p.files%p.step == 0 || p.files == p.totalFiles:
+		fmt.Fprintln(p.io.ErrOut, p.message())
+	}
+}
+
+// pushSummary describes what the push uploaded, e.g. "Uploaded 1,243 files (58 MB) to my-bucket in 12.4s".
+func (deployer *DeployerState) pushSummary() string {
+	if deployer.reused {
+		return fmt.Sprintf("Statics are unchanged since version %d, serving them from %s", deployer.releaseVersion, deployer.bucket)
+	}
+
+	files := deployer.uploadedFiles.Load()
+	noun := "files"
+	if files == 1 {
+		noun = "file"
+	}
+	return fmt.Sprintf("Uploaded %s %s (%s) to %s in %s",
+		humanize.Comma(files), noun, humanize.Bytes(uint64(deployer.uploadedBytes.Load())),
+		deployer.bucket, deployer.pushDuration.Round(100*time.Millisecond))
+}
+
+// stop clears the progress indicator.
+func (p *uploadProgress) stop() {
+	if p.io != nil && p.tty {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.io.StopProgressIndicatorMsg(p.message())
+	}
+}
@@ -0,0 +1,85 @@
+package statics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func TestUploadDirectoryProgress(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("file-%d.txt", i)), []byte("hello"), 0o644))
+	}
+
+	ios, _, _, errOut := iostreams.Test()
+	deployer := &DeployerState{s3: newMockS3(), bucket: "bucket", io: ios}
+	require.NoError(t, deployer.uploadDirectory(context.Background(), "root/0/", "", dir))
+
+	// Not a terminal, so a line is printed every tenth of the way through.
+	lines := strings.Split(strings.TrimSpace(errOut.String()), "\n")
+	assert.Len(t, lines, 10)
+	assert.Equal(t, "Uploading statics: 2/20 files (10 B of 100 B)", lines[0])
+	assert.Equal(t, "Uploading statics: 20/20 files (100 B of 100 B)", lines[9])
+}
+
+func TestUploadDirectoryProgressSilent(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte("hello"), 0o644))
+
+	deployer := &DeployerState{s3: newMockS3(), bucket: "bucket"}
+	require.NoError(t, deployer.uploadDirectory(context.Background(), "root/0/", "", dir))
+	assert.Equal(t, int64(1), deployer.uploadedFiles.Load())
+}
+
+func TestFinalizePushSummary(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public", "css"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("<html></html>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "css", "app.css"), []byte("body{}"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "images"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "images", "logo.svg"), []byte("<svg></svg>"), 0o644))
+	chdir(t, dir)
+
+	ios, _, out, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+	client := newMockS3()
+	deployer := &DeployerState{
+		appConfig:      &appconfig.Config{AppName: "my-app"},
+		releaseVersion: 3,
+		s3:             client,
+		bucket:         "bucket",
+		root:           "fly-statics/my-app/3",
+		originalStatics: []appconfig.Static{
+			{GuestPath: "public", UrlPrefix: "/"},
+			{GuestPath: "images", UrlPrefix: "/img"},
+		},
+		io: ios,
+	}
+	require.NoError(t, deployer.Push(ctx))
+	require.NoError(t, deployer.Finalize(ctx))
+
+	// The counts cover every static, and match what's in the bucket besides the push record.
+	assert.Len(t, client.keys(), 4)
+	assert.Regexp(t, `^Uploaded 3 files \(30 B\) to bucket in \d+(\.\d)?m?s\n$`, out.String())
+}
+
+func TestPushSummary(t *testing.T) {
+	deployer := &DeployerState{bucket: "bucket", pushDuration: 12437 * time.Millisecond}
+	deployer.uploadedFiles.Store(1243)
+	deployer.uploadedBytes.Store(58_200_000)
+	assert.Equal(t, "Uploaded 1,243 files (58 MB) to bucket in 12.4s", deployer.pushSummary())
+
+	deployer.uploadedFiles.Store(1)
+	deployer.uploadedBytes.Store(5)
+	assert.Equal(t, "Uploaded 1 file (5 B) to bucket in 12.4s", deployer.pushSummary())
+}
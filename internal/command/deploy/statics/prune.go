@@ -0,0 +1,67 @@
+package statics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/sourcegraph/conc/pool"
+)
+
+// PruneOptions control a prune across many apps.
+type PruneOptions struct {
+	// Concurrency is the maximum number of apps pruned at once.
+	Concurrency int
+	// Timeout bounds how long a single app's prune may take.
+	Timeout time.Duration
+}
+
+// PruneResult is the outcome of pruning a single app.
+type PruneResult struct {
+	App string
+	Err error
+}
+
+// Prune deletes the statics of old releases of an app, keeping only the latest versions.
+// Apps without a statics bucket are left untouched.
+func Prune(ctx context.Context, appName string) error {
+	deployer, err := appDeployer(ctx, appName)
+	if err != nil || deployer == nil {
+		return err
+	}
+
+	// Versions newer than the current release aren't deleted: `fly statics push` creates them
+	// outside of a deploy, and the app's machines may be serving them.
+	return deployer.deleteOldStatics(ctx, appName, math.MaxInt)
+}
+
+// PruneApps prunes the statics of every app, continuing past apps that fail.
+func PruneApps(ctx context.Context, appNames []string, opts PruneOptions) []PruneResult {
+	return pruneApps(ctx, appNames, opts, Prune)
+}
+
+func pruneApps(ctx context.Context, appNames []string, opts PruneOptions, prune func(context.Context, string) error) []PruneResult {
+	results := make([]PruneResult, len(appNames))
+
+	p := pool.New().WithMaxGoroutines(max(opts.Concurrency, 1))
+	for i, appName := range appNames {
+		p.Go(func() {
+			pruneCtx := ctx
+			if opts.Timeout > 0 {
+				var cancel context.CancelFunc
+				pruneCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+				defer cancel()
+			}
+
+			err := prune(pruneCtx, appName)
+			if err != nil && pruneCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+				err = fmt.Errorf("timed out after %s: %w", opts.Timeout, err)
+			}
+			results[i] = PruneResult{App: appName, Err: err}
+		})
+	}
+	p.Wait()
+
+	return results
+}
@@ -0,0 +1,60 @@
+package statics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneApps(t *testing.T) {
+	var apps []string
+	for i := 0; i < 10; i++ {
+		apps = append(apps, fmt.Sprintf("app-%d", i))
+	}
+
+	var inFlight, maxInFlight atomic.Int32
+	prune := func(ctx context.Context, appName string) error {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+
+		switch appName {
+		case "app-3":
+			return errors.New("bucket unavailable")
+		case "app-7":
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}
+
+	results := pruneApps(context.Background(), apps, PruneOptions{Concurrency: 3, Timeout: 100 * time.Millisecond}, prune)
+	require.Len(t, results, len(apps))
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(3))
+
+	for i, result := range results {
+		assert.Equal(t, apps[i], result.App)
+		switch result.App {
+		case "app-3":
+			assert.EqualError(t, result.Err, "bucket unavailable")
+		case "app-7":
+			require.Error(t, result.Err)
+			assert.Contains(t, result.Err.Error(), "timed out after 100ms")
+			assert.ErrorIs(t, result.Err, context.DeadlineExceeded)
+		default:
+			assert.NoError(t, result.Err, result.App)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package statics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/samber/lo"
+	"github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/logger"
+)
+
+// resumeInterrupted switches the push over to the latest version before the release's if a push
+// to it was interrupted and its files were kept, see keepInterrupted. The files it already has
+// are listed, so that uploadFile can skip them. It reports whether it resumed.
+func (deployer *DeployerState) resumeInterrupted(ctx context.Context) (bool, error) {
+	versions, err := deployer.listVersions(ctx, deployer.appConfig.AppName)
+	if err != nil {
+		return false, err
+	}
+	versions = lo.Filter(versions, func(version int, _ int) bool {
+		return version < deployer.releaseVersion
+	})
+	if len(versions) == 0 {
+		return false, nil
+	}
+	version := lo.Max(versions)
+	root := deployer.versionRoot(deployer.appConfig.AppName, version)
+
+	record, err := deployer.previousPush(ctx, root)
+	if err != nil || record == nil || !record.Interrupted {
+		return false, err
+	}
+
+	uploaded := map[string]string{}
+	paginator := s3.NewListObjectsV2Paginator(deployer.s3, &s3.ListObjectsV2Input{
+		Bucket: &deployer.bucket,
+		Prefix: fly.Pointer(root + "/"),
+	})
+	for paginator.HasMorePages() {
+		listOutput, err := paginator.NextPage(ctx)
+		if err != nil {
+			return false, err
+		}
+		for _, obj := range listOutput.Contents {
+			// Keys ending in a slash are directory indexes, which are copied again once the files are in place.
+			if !strings.HasSuffix(*obj.Key, "/") {
+				uploaded[*obj.Key] = lo.FromPtr(obj.ETag)
+			}
+		}
+	}
+
+	deployer.root = root
+	deployer.releaseVersion = version
+	deployer.resumable = uploaded
+	deployer.logEvent(logger.Info, "push_resumed", "root", root, "files", len(uploaded))
+	return true, nil
+}
+
+// keepInterrupted records that the push to the version root was interrupted, leaving its files
+// in place for the next push to resume. The record has no hash, so no push ever reuses the version as is.
+func (deployer *DeployerState) keepInterrupted(ctx context.Context) error {
+	body, err := json.Marshal(pushRecord{Version: deployer.releaseVersion, Interrupted: true})
+	if err != nil {
+		return err
+	}
+	_, err = deployer.s3.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      &deployer.bucket,
+		Key:         fly.Pointer(path.Join(deployer.root, pushRecordKey)),
+		Body:        bytes.NewReader(body),
+		ContentType: fly.Pointer("application/json"),
+	})
+	return err
+}
+
+// alreadyUploaded reports whether an interrupted push that's being resumed uploaded body to key.
+// The body is rewound afterwards.
+func (deployer *DeployerState) alreadyUploaded(key string, body io.ReadSeeker) (bool, error) {
+	existing, ok := deployer.resumable[key]
+	if !ok || existing == "" {
+		return false, nil
+	}
+	etag, err := contentETag(body)
+	if err != nil {
+		return false, err
+	}
+	return etag == existing, nil
+}
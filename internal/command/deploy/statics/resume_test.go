@@ -0,0 +1,110 @@
+package statics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func newResumeDeployer(client *mockS3, version int) *DeployerState {
+	deployer := &DeployerState{
+		appConfig:       &appconfig.Config{AppName: "my-app"},
+		releaseVersion:  version,
+		s3:              client,
+		bucket:          "bucket",
+		opts:            Options{Resume: true},
+		originalStatics: []appconfig.Static{{GuestPath: "public", UrlPrefix: "/"}},
+	}
+	deployer.root = deployer.versionRoot("my-app", version)
+	return deployer
+}
+
+func TestPushResumesInterruptedUpload(t *testing.T) {
+	// One file at a time, so the upload order is predictable.
+	t.Setenv(uploadConcurrencyEnvKey, "1")
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public"), 0o755))
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "public", name), []byte(name), 0o644))
+	}
+	chdir(t, dir)
+
+	client := newMockS3()
+	client.PutObjectFunc = func(ctx context.Context, params *s3.PutObjectInput) error {
+		if strings.HasSuffix(*params.Key, "c.txt") {
+			return statusError(403)
+		}
+		return nil
+	}
+	// The other files are still uploaded when one of them fails.
+	require.Error(t, newResumeDeployer(client, 1).Push(context.Background()))
+	assert.Equal(t, []string{
+		"fly-statics/my-app/1/.fly-statics-push.json",
+		"fly-statics/my-app/1/0/a.txt",
+		"fly-statics/my-app/1/0/b.txt",
+		"fly-statics/my-app/1/0/d.txt",
+	}, client.keys())
+
+	client.PutObjectFunc = nil
+	puts := len(client.puts)
+	deployer := newResumeDeployer(client, 2)
+	require.NoError(t, deployer.Push(context.Background()))
+
+	var resumed []string
+	for _, put := range client.puts[puts:] {
+		resumed = append(resumed, *put.Key)
+	}
+	assert.ElementsMatch(t, []string{
+		"fly-statics/my-app/1/0/c.txt",
+		"fly-statics/my-app/1/.fly-statics-push.json",
+	}, resumed)
+	assert.Empty(t, client.copies)
+
+	// The files kept from the interrupted push are still there alongside the resumed ones.
+	assert.Equal(t, []string{
+		"fly-statics/my-app/1/.fly-statics-push.json",
+		"fly-statics/my-app/1/0/a.txt",
+		"fly-statics/my-app/1/0/b.txt",
+		"fly-statics/my-app/1/0/c.txt",
+		"fly-statics/my-app/1/0/d.txt",
+	}, client.keys())
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt"} {
+		assert.Equal(t, name, string(client.objects["fly-statics/my-app/1/0/"+name].body))
+	}
+	assert.Equal(t, 1, deployer.Version())
+	assert.Equal(t, "/fly-statics/my-app/1/0/", deployer.appConfig.Statics[0].GuestPath)
+
+	// The push is complete, so the next one doesn't resume it.
+	record, err := deployer.previousPush(context.Background(), "fly-statics/my-app/1")
+	require.NoError(t, err)
+	assert.False(t, record.Interrupted)
+	assert.Len(t, record.Files, 4)
+}
+
+func TestPushDoesNotResumeCompleteVersion(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("<html></html>"), 0o644))
+	chdir(t, dir)
+
+	client := newMockS3()
+	// A version pushed without a record, e.g. by an older flyctl, may be live.
+	client.addObject("fly-statics/my-app/1/0/index.html", "<html>old</html>")
+
+	deployer := newResumeDeployer(client, 2)
+	require.NoError(t, deployer.Push(context.Background()))
+	assert.Equal(t, 2, deployer.Version())
+	assert.Equal(t, "<html>old</html>", string(client.objects["fly-statics/my-app/1/0/index.html"].body))
+}
+
+func TestConfigureRejectsStagedResume(t *testing.T) {
+	deployer := &DeployerState{appConfig: &appconfig.Config{AppName: "my-app"}, opts: Options{Resume: true, Staged: true}}
+	assert.EqualError(t, deployer.Configure(context.Background()), "statics pushes can't be resumed when they're staged")
+}
@@ -0,0 +1,75 @@
+package statics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jpillora/backoff"
+	"github.com/superfly/flyctl/internal/env"
+	"github.com/superfly/flyctl/internal/logger"
+)
+
+const (
+	uploadAttemptsEnvKey = "FLY_STATICS_UPLOAD_ATTEMPTS"
+
+	// defaultUploadAttempts bounds how many times each file is tried before it's reported as failed.
+	defaultUploadAttempts = 3
+	maxUploadAttempts     = 10
+)
+
+// newUploadBackoff returns the delays between attempts to upload a file.
+// It's a variable so that tests don't have to wait.
+var newUploadBackoff = func() *backoff.Backoff {
+	return &backoff.Backoff{
+		Min:    500 * time.Millisecond,
+		Max:    10 * time.Second,
+		Factor: 2,
+		Jitter: true,
+	}
+}
+
+// uploadAttemptsFromEnv returns the number of times each file is tried.
+func uploadAttemptsFromEnv() (int, error) {
+	value := env.First(uploadAttemptsEnvKey)
+	if value == "" {
+		return defaultUploadAttempts, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid %s value '%s', expected a positive number", uploadAttemptsEnvKey, value)
+	}
+	if n > maxUploadAttempts {
+		logEvent(logger.Debug, "setting_clamped", "env", uploadAttemptsEnvKey, "value", n, "max", maxUploadAttempts)
+		n = maxUploadAttempts
+	}
+	return n, nil
+}
+
+// isRetryableUploadError reports whether an upload that failed with err may succeed if tried again.
+// Server errors, throttling and connection failures are retried. Other client errors,
+// such as the tokenizer rejecting our credentials, fail the same way every time.
+func isRetryableUploadError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+		return false
+	}
+
+	var statusErr interface{ HTTPStatusCode() int }
+	if !errors.As(err, &statusErr) {
+		// No response at all, e.g. the connection was reset.
+		return true
+	}
+	switch status := statusErr.HTTPStatusCode(); {
+	case status >= http.StatusInternalServerError:
+		return true
+	case status == http.StatusTooManyRequests, status == http.StatusRequestTimeout:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,87 @@
+package statics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statusError is shaped like the SDK's HTTP response errors.
+type statusError int
+
+func (e statusError) Error() string       { return fmt.Sprintf("status %d", int(e)) }
+func (e statusError) HTTPStatusCode() int { return int(e) }
+
+func TestIsRetryableUploadError(t *testing.T) {
+	assert.True(t, isRetryableUploadError(errors.New("connection reset by peer")))
+	assert.True(t, isRetryableUploadError(statusError(500)))
+	assert.True(t, isRetryableUploadError(fmt.Errorf("wrapped: %w", statusError(503))))
+	assert.True(t, isRetryableUploadError(statusError(429)))
+	assert.False(t, isRetryableUploadError(statusError(403)))
+	assert.False(t, isRetryableUploadError(statusError(404)))
+	assert.False(t, isRetryableUploadError(context.Canceled))
+	assert.False(t, isRetryableUploadError(os.ErrNotExist))
+}
+
+func TestUploadAttemptsFromEnv(t *testing.T) {
+	t.Setenv(uploadAttemptsEnvKey, "")
+	n, err := uploadAttemptsFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, defaultUploadAttempts, n)
+
+	t.Setenv(uploadAttemptsEnvKey, "100")
+	n, err = uploadAttemptsFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, maxUploadAttempts, n)
+
+	t.Setenv(uploadAttemptsEnvKey, "0")
+	_, err = uploadAttemptsFromEnv()
+	assert.Error(t, err)
+}
+
+func TestUploadFileRetriesWithBackoff(t *testing.T) {
+	withoutRetryDelay(t)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), []byte("hello"), 0o644))
+
+	var calls atomic.Int32
+	client := newMockS3()
+	client.PutObjectFunc = func(ctx context.Context, params *s3.PutObjectInput) error {
+		if calls.Add(1) <= 2 {
+			return statusError(503)
+		}
+		return nil
+	}
+	deployer := &DeployerState{s3: client, bucket: "bucket"}
+	require.NoError(t, deployer.uploadDirectory(context.Background(), "root/0/", "", dir))
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, []string{"root/0/index.html"}, client.keys())
+
+	// Auth failures aren't retried
+	calls.Store(0)
+	client.PutObjectFunc = func(ctx context.Context, params *s3.PutObjectInput) error {
+		calls.Add(1)
+		return statusError(403)
+	}
+	require.Error(t, deployer.uploadDirectory(context.Background(), "root/1/", "", dir))
+	assert.Equal(t, int32(1), calls.Load())
+
+	// The number of attempts is configurable
+	t.Setenv(uploadAttemptsEnvKey, "5")
+	calls.Store(0)
+	client.PutObjectFunc = func(ctx context.Context, params *s3.PutObjectInput) error {
+		calls.Add(1)
+		return statusError(500)
+	}
+	require.Error(t, deployer.uploadDirectory(context.Background(), "root/2/", "", dir))
+	assert.Equal(t, int32(5), calls.Load())
+}
@@ -0,0 +1,140 @@
+package statics
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/logger"
+)
+
+// pushRecordKey is the object, under a version root, that records what the push of that version
+// uploaded. It's outside of the prefixes statics are served from.
+const pushRecordKey = ".fly-statics-push.json"
+
+// pushRecord lets a later push tell whether anything changed since this one.
+type pushRecord struct {
+	Version int `json:"version"`
+	// Hash covers everything that decides what the push put in the bucket, see pushHash.
+	Hash  string         `json:"hash"`
+	Files []manifestFile `json:"files"`
+	// Interrupted is set when the push failed, and its files were kept for the next one to resume.
+	Interrupted bool `json:"interrupted,omitempty"`
+}
+
+// pushHash hashes the statics being pushed, the options that decide how they're uploaded,
+// and the name and content of every file of their sources.
+func (deployer *DeployerState) pushHash(statics []appconfig.Static, sources []*staticSource) (string, error) {
+	hash := sha256.New()
+	settings, err := json.Marshal(map[string]any{
+		"statics":         statics,
+		"statics_options": deployer.appConfig.StaticsOptions,
+		"key_case":        deployer.opts.KeyCase,
+		"asset_index":     deployer.opts.AssetIndexKey,
+	})
+	if err != nil {
+		return "", err
+	}
+	hash.Write(settings)
+
+	for staticNum, source := range sources {
+		files, _, err := listStaticFiles(deployer.appConfig, source.localPath)
+		if err != nil {
+			return "", err
+		}
+		for _, file := range files {
+			sum, err := fileSHA256(filepath.Join(source.localPath, filepath.FromSlash(file)))
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(hash, "%d/%s %s\n", staticNum, file, sum)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func fileSHA256(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() // skipcq: GO-S2307
+	return contentSHA256(f)
+}
+
+// previousPush returns the record of the push of the version at root, or nil if it doesn't have one.
+func (deployer *DeployerState) previousPush(ctx context.Context, root string) (*pushRecord, error) {
+	output, err := deployer.s3.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &deployer.bucket,
+		Key:    fly.Pointer(path.Join(root, pushRecordKey)),
+	})
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close() // skipcq: GO-S2307
+
+	body, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, err
+	}
+	var record pushRecord
+	if err := json.Unmarshal(body, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// putPushRecord records what was pushed under the version root, for the next push to compare against.
+func (deployer *DeployerState) putPushRecord(ctx context.Context, hash string) error {
+	body, err := json.Marshal(pushRecord{
+		Version: deployer.releaseVersion,
+		Hash:    hash,
+		Files:   deployer.manifestFiles,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = deployer.s3.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      &deployer.bucket,
+		Key:         fly.Pointer(path.Join(deployer.root, pushRecordKey)),
+		Body:        bytes.NewReader(body),
+		ContentType: fly.Pointer("application/json"),
+	})
+	return err
+}
+
+// reusePrevious switches the push over to the previous version if its push had the same hash,
+// so nothing is uploaded and the versions kept in the bucket aren't pushed out by a copy of it.
+// It reports whether it did.
+func (deployer *DeployerState) reusePrevious(ctx context.Context, previousRoot, hash string) bool {
+	record, err := deployer.previousPush(ctx, previousRoot)
+	if err != nil {
+		deployer.logEvent(logger.Debug, "previous_push_unreadable", "root", previousRoot, "error", err)
+		return false
+	}
+	if record == nil || record.Hash != hash {
+		return false
+	}
+
+	deployer.logEvent(logger.Debug, "push_reused", "root", previousRoot, "previous_version", record.Version)
+	deployer.root = previousRoot
+	deployer.releaseVersion = record.Version
+	deployer.manifestFiles = record.Files
+	deployer.reused = true
+	return true
+}
@@ -0,0 +1,74 @@
+package statics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func newReuseDeployer(t *testing.T, client *mockS3) *DeployerState {
+	deployer := &DeployerState{
+		appConfig:       &appconfig.Config{AppName: "my-app"},
+		s3:              client,
+		bucket:          "bucket",
+		originalStatics: []appconfig.Static{{GuestPath: "public", UrlPrefix: "/"}},
+	}
+	require.NoError(t, deployer.UseNextVersion(context.Background()))
+	return deployer
+}
+
+func TestPushReusesUnchangedStatics(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("<html></html>"), 0o644))
+	chdir(t, dir)
+
+	client := newMockS3()
+	first := newReuseDeployer(t, client)
+	require.NoError(t, first.Push(context.Background()))
+	assert.Contains(t, client.keys(), "fly-statics/my-app/1/.fly-statics-push.json")
+
+	second := newReuseDeployer(t, client)
+	assert.Equal(t, 2, second.Version())
+	puts, copies := len(client.puts), len(client.copies)
+	require.NoError(t, second.Push(context.Background()))
+
+	assert.Len(t, client.puts, puts)
+	assert.Len(t, client.copies, copies)
+	assert.True(t, second.reused)
+	assert.Equal(t, 1, second.Version())
+	require.Len(t, second.appConfig.Statics, 1)
+	assert.Equal(t, "/fly-statics/my-app/1/0/", second.appConfig.Statics[0].GuestPath)
+	assert.Equal(t, "Statics are unchanged since version 1, serving them from bucket", second.pushSummary())
+
+	// A changed file is pushed as a new version.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("<html>new</html>"), 0o644))
+	third := newReuseDeployer(t, client)
+	require.NoError(t, third.Push(context.Background()))
+
+	assert.False(t, third.reused)
+	assert.Equal(t, 2, third.Version())
+	assert.Equal(t, "<html>new</html>", string(client.objects["fly-statics/my-app/2/0/index.html"].body))
+}
+
+func TestPushDoesNotReuseWhenStaticsChange(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("<html></html>"), 0o644))
+	chdir(t, dir)
+
+	client := newMockS3()
+	require.NoError(t, newReuseDeployer(t, client).Push(context.Background()))
+
+	deployer := newReuseDeployer(t, client)
+	deployer.originalStatics[0].UrlPrefix = "/site"
+	require.NoError(t, deployer.Push(context.Background()))
+
+	assert.False(t, deployer.reused)
+	assert.Equal(t, "/fly-statics/my-app/2/0/", deployer.appConfig.Statics[0].GuestPath)
+}
@@ -0,0 +1,15 @@
+package statics
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithChecksums(t *testing.T) {
+	options := s3.NewFromConfig(aws.Config{}, withChecksums).Options()
+	assert.Equal(t, aws.ResponseChecksumValidationWhenSupported, options.ResponseChecksumValidation)
+	assert.Equal(t, aws.RequestChecksumCalculationWhenRequired, options.RequestChecksumCalculation)
+}
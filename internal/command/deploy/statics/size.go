@@ -0,0 +1,75 @@
+package statics
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+
+	"github.com/docker/go-units"
+	"github.com/dustin/go-humanize"
+	"github.com/superfly/flyctl/internal/env"
+)
+
+const (
+	warnSizeEnvKey  = "FLY_STATICS_WARN_SIZE"
+	warnFilesEnvKey = "FLY_STATICS_WARN_FILES"
+
+	// Pushes above these are more likely to include node_modules or a build cache by mistake
+	// than to be the app's assets.
+	defaultWarnSize  = 500 * units.MB
+	defaultWarnFiles = 20000
+)
+
+// LargePushWarning returns a warning when the statics that plan pushes add up to more than
+// FLY_STATICS_WARN_SIZE bytes or FLY_STATICS_WARN_FILES files, or "" when they don't.
+// Statics sharing a directory are only counted once, as they're only uploaded once.
+func LargePushWarning(plan []PlannedStatic) (string, error) {
+	maxSize, maxFiles, err := pushWarnLimitsFromEnv()
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		files   int
+		size    int64
+		counted = map[string]bool{}
+	)
+	for _, planned := range plan {
+		localPath := path.Clean(planned.GuestPath)
+		if planned.SkipReason != "" || counted[localPath] {
+			continue
+		}
+		counted[localPath] = true
+		files += planned.Files
+		size += planned.Bytes
+	}
+
+	if size <= maxSize && files <= maxFiles {
+		return "", nil
+	}
+	return fmt.Sprintf("statics add up to %s files (%s), more than the %s files or %s a push is expected to have. "+
+		"Check that they don't include directories like node_modules or build caches, which can be left out with statics_options.ignore in fly.toml or a .flyignore file",
+		humanize.Comma(int64(files)), humanize.Bytes(uint64(size)), humanize.Comma(int64(maxFiles)), humanize.Bytes(uint64(maxSize))), nil
+}
+
+// pushWarnLimitsFromEnv returns the size and number of files above which a push is warned about.
+func pushWarnLimitsFromEnv() (int64, int, error) {
+	maxSize := int64(defaultWarnSize)
+	if value := env.First(warnSizeEnvKey); value != "" {
+		size, err := units.FromHumanSize(value)
+		if err != nil || size <= 0 {
+			return 0, 0, fmt.Errorf("invalid %s value '%s', expected a size like 500mb or 2gb", warnSizeEnvKey, value)
+		}
+		maxSize = size
+	}
+
+	maxFiles := defaultWarnFiles
+	if value := env.First(warnFilesEnvKey); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return 0, 0, fmt.Errorf("invalid %s value '%s', expected a positive number", warnFilesEnvKey, value)
+		}
+		maxFiles = n
+	}
+	return maxSize, maxFiles, nil
+}
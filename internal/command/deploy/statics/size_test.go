@@ -0,0 +1,61 @@
+package statics
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestLargePushWarning(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public", "node_modules", "left-pad"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("<html></html>"), 0o644))
+	for i := range 30 {
+		name := filepath.Join(dir, "public", "node_modules", "left-pad", fmt.Sprintf("%d.js", i))
+		require.NoError(t, os.WriteFile(name, make([]byte, 100), 0o644))
+	}
+	chdir(t, dir)
+
+	cfg := appconfig.NewConfig()
+	cfg.Statics = []appconfig.Static{
+		{GuestPath: "public", UrlPrefix: "/"},
+		// Served twice, but uploaded and counted once
+		{GuestPath: "public/", UrlPrefix: "/mirror"},
+	}
+	plan, err := Plan(cfg)
+	require.NoError(t, err)
+
+	// Well under the defaults
+	warning, err := LargePushWarning(plan)
+	require.NoError(t, err)
+	assert.Empty(t, warning)
+
+	t.Setenv(warnFilesEnvKey, "20")
+	warning, err = LargePushWarning(plan)
+	require.NoError(t, err)
+	assert.Contains(t, warning, "statics add up to 31 files (3.0 kB), more than the 20 files or 500 MB")
+	assert.Contains(t, warning, "node_modules")
+
+	t.Setenv(warnFilesEnvKey, "")
+	t.Setenv(warnSizeEnvKey, "2kb")
+	warning, err = LargePushWarning(plan)
+	require.NoError(t, err)
+	assert.Contains(t, warning, "more than the 20,000 files or 2.0 kB")
+
+	// Leaving the directory out brings the push back under the limit
+	cfg.StaticsOptions = &appconfig.StaticsOptions{Ignore: []string{"node_modules"}}
+	plan, err = Plan(cfg)
+	require.NoError(t, err)
+	warning, err = LargePushWarning(plan)
+	require.NoError(t, err)
+	assert.Empty(t, warning)
+
+	t.Setenv(warnSizeEnvKey, "lots")
+	_, err = LargePushWarning(plan)
+	assert.EqualError(t, err, "invalid FLY_STATICS_WARN_SIZE value 'lots', expected a size like 500mb or 2gb")
+}
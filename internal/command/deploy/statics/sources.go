@@ -0,0 +1,140 @@
+package statics
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+// staticSource is a local directory that's uploaded once, however many statics serve from it.
+type staticSource struct {
+	localPath string
+	statics   []servedStatic
+}
+
+// servedStatic is a static served from a source, or from a directory within it.
+type servedStatic struct {
+	appconfig.Static
+	// dir is the slash-separated directory of the source that the static serves, or "" for all of it.
+	dir string
+}
+
+// groupStaticSources groups statics by the directory they're uploaded from. Statics with the same
+// guest path share an upload, and so do statics nested in another one's guest path, as long as the
+// outer upload holds exactly the files the nested static would upload by itself; its own ignore
+// file could make them differ. Sources and their statics keep the order of the config.
+func groupStaticSources(cfg *appconfig.Config, statics []appconfig.Static) ([]*staticSource, error) {
+	files := map[string][]string{}
+	listFiles := func(localPath string) ([]string, error) {
+		if listed, ok := files[localPath]; ok {
+			return listed, nil
+		}
+		listed, _, err := listStaticFiles(cfg, localPath)
+		if err != nil {
+			return nil, err
+		}
+		listed = slices.Clone(listed)
+		slices.Sort(listed)
+		files[localPath] = listed
+		return listed, nil
+	}
+
+	var localPaths []string
+	for _, static := range statics {
+		if localPath := path.Clean(static.GuestPath); !slices.Contains(localPaths, localPath) {
+			localPaths = append(localPaths, localPath)
+		}
+	}
+
+	// Outer directories are resolved first, so that the ones nested in them only need
+	// to be checked against the directories that are uploaded.
+	byDepth := slices.Clone(localPaths)
+	slices.SortStableFunc(byDepth, func(a, b string) int {
+		return strings.Count(a, "/") - strings.Count(b, "/")
+	})
+	type location struct {
+		source string
+		dir    string
+	}
+	locations := map[string]location{}
+	var uploaded []string
+	for _, localPath := range byDepth {
+		found := false
+		for _, source := range uploaded {
+			dir, ok := nestedStaticDir(source, localPath)
+			if !ok {
+				continue
+			}
+			sourceFiles, err := listFiles(source)
+			if err != nil {
+				return nil, err
+			}
+			nestedFiles, err := listFiles(localPath)
+			if err != nil {
+				return nil, err
+			}
+			if slices.Equal(filesUnder(sourceFiles, dir), nestedFiles) {
+				locations[localPath] = location{source: source, dir: dir}
+				found = true
+				break
+			}
+		}
+		if !found {
+			locations[localPath] = location{source: localPath}
+			uploaded = append(uploaded, localPath)
+		}
+	}
+
+	var sources []*staticSource
+	bySource := map[string]*staticSource{}
+	for _, localPath := range localPaths {
+		if loc := locations[localPath]; loc.dir == "" {
+			source := &staticSource{localPath: localPath}
+			sources = append(sources, source)
+			bySource[localPath] = source
+		}
+	}
+	for _, static := range statics {
+		loc := locations[path.Clean(static.GuestPath)]
+		source := bySource[loc.source]
+		source.statics = append(source.statics, servedStatic{Static: static, dir: loc.dir})
+	}
+	return sources, nil
+}
+
+// nestedStaticDir returns the slash-separated directory of parent that child is, if it's nested in it.
+func nestedStaticDir(parent, child string) (string, bool) {
+	if parent == child || strings.HasPrefix(child, "../") || child == ".." {
+		return "", false
+	}
+	if parent == "." {
+		return child, true
+	}
+	dir, ok := strings.CutPrefix(child, parent+"/")
+	return dir, ok
+}
+
+// filesUnder returns the files within dir, relative to it.
+func filesUnder(files []string, dir string) []string {
+	var under []string
+	for _, file := range files {
+		if rel, ok := strings.CutPrefix(file, dir+"/"); ok {
+			under = append(under, rel)
+		}
+	}
+	return under
+}
+
+// uploadsUnder returns the uploads within dir, relative to it.
+func uploadsUnder(uploads []uploadRecord, dir string) []uploadRecord {
+	var under []uploadRecord
+	for _, upload := range uploads {
+		if rel, ok := strings.CutPrefix(upload.File, dir+"/"); ok {
+			upload.File = rel
+			under = append(under, upload)
+		}
+	}
+	return under
+}
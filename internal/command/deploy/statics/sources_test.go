@@ -0,0 +1,81 @@
+package statics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestGroupStaticSources(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{"public/index.html", "public/img/logo.svg", "docs/index.html", "docs/api/index.html", "docs/api/.flyignore"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(file), 0o644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "api", ".flyignore"), []byte("*.md\n"), 0o644))
+	chdir(t, dir)
+
+	sources, err := groupStaticSources(&appconfig.Config{}, []appconfig.Static{
+		{GuestPath: "public/img", UrlPrefix: "/img"},
+		{GuestPath: "public", UrlPrefix: "/"},
+		{GuestPath: "./public/", UrlPrefix: "/mirror"},
+		{GuestPath: "docs", UrlPrefix: "/docs"},
+		// Its own ignore file makes it upload different files than docs does.
+		{GuestPath: "docs/api", UrlPrefix: "/api"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, sources, 3)
+	assert.Equal(t, "public", sources[0].localPath)
+	assert.Equal(t, []servedStatic{
+		{Static: appconfig.Static{GuestPath: "public/img", UrlPrefix: "/img"}, dir: "img"},
+		{Static: appconfig.Static{GuestPath: "public", UrlPrefix: "/"}},
+		{Static: appconfig.Static{GuestPath: "./public/", UrlPrefix: "/mirror"}},
+	}, sources[0].statics)
+	assert.Equal(t, "docs", sources[1].localPath)
+	assert.Equal(t, []servedStatic{{Static: appconfig.Static{GuestPath: "docs", UrlPrefix: "/docs"}}}, sources[1].statics)
+	assert.Equal(t, "docs/api", sources[2].localPath)
+	assert.Equal(t, []servedStatic{{Static: appconfig.Static{GuestPath: "docs/api", UrlPrefix: "/api"}}}, sources[2].statics)
+}
+
+func TestPushSharedStaticSource(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public", "img"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("<html></html>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "img", "logo.svg"), []byte("<svg></svg>"), 0o644))
+	chdir(t, dir)
+
+	client := newMockS3()
+	deployer := &DeployerState{
+		appConfig:      &appconfig.Config{AppName: "my-app"},
+		releaseVersion: 1,
+		s3:             client,
+		bucket:         "bucket",
+		root:           "fly-statics/my-app/1",
+		originalStatics: []appconfig.Static{
+			{GuestPath: "public", UrlPrefix: "/"},
+			{GuestPath: "public", UrlPrefix: "/v1"},
+			{GuestPath: "public/img", UrlPrefix: "/img"},
+		},
+	}
+	require.NoError(t, deployer.Push(context.Background()))
+
+	// Every file is uploaded once, under a single upload.
+	assert.ElementsMatch(t, []string{
+		"fly-statics/my-app/1/0/index.html",
+		"fly-statics/my-app/1/0/img/logo.svg",
+		"fly-statics/my-app/1/.fly-statics-push.json",
+	}, lo.Map(client.puts, func(put *s3.PutObjectInput, _ int) string { return *put.Key }))
+	assert.Equal(t, []appconfig.Static{
+		{GuestPath: "/fly-statics/my-app/1/0/", UrlPrefix: "/", TigrisBucket: "bucket"},
+		{GuestPath: "/fly-statics/my-app/1/0/", UrlPrefix: "/v1", TigrisBucket: "bucket"},
+		{GuestPath: "/fly-statics/my-app/1/0/img/", UrlPrefix: "/img", TigrisBucket: "bucket"},
+	}, deployer.appConfig.Statics)
+}
@@ -0,0 +1,88 @@
+package statics
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/logger"
+)
+
+// stagingRoot is where statics are uploaded before being published when staged uploads are enabled.
+// It's kept outside of `<prefix>/<app_name>/` so it's never mistaken for a release's statics.
+func (deployer *DeployerState) stagingRoot() string {
+	return fmt.Sprintf("%s-staging/%s/%d", deployer.appConfig.StaticsPrefix(), deployer.appConfig.AppName, deployer.releaseVersion)
+}
+
+// uploadRoot is the prefix that Push uploads to.
+func (deployer *DeployerState) uploadRoot() string {
+	if deployer.opts.Staged {
+		return deployer.stagingRoot()
+	}
+	return deployer.root
+}
+
+// publishStaged copies every staged object to the live version root, then removes the staging prefix.
+// The live root is only written once all files have been uploaded, so a failed push never leaves
+// a partial release behind.
+func (deployer *DeployerState) publishStaged(ctx context.Context) error {
+	staging := deployer.stagingRoot() + "/"
+
+	paginator := s3.NewListObjectsV2Paginator(deployer.s3, &s3.ListObjectsV2Input{
+		Bucket: &deployer.bucket,
+		Prefix: &staging,
+	})
+
+	var keys []string
+	for paginator.HasMorePages() {
+		listOutput, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, obj := range listOutput.Contents {
+			keys = append(keys, *obj.Key)
+		}
+	}
+
+	workQueue := make(chan string, len(keys))
+	for _, key := range keys {
+		workQueue <- key
+	}
+	close(workQueue)
+
+	waitForWorkers := spawnWorkers(ctx, 5, func(ctx context.Context) error {
+		for key := range workQueue {
+			liveKey := path.Join(deployer.root, strings.TrimPrefix(key, staging))
+			deployer.logEvent(logger.Debug, "publish", "file", liveKey, "source", key)
+
+			_, err := deployer.s3.CopyObject(ctx, &s3.CopyObjectInput{
+				Bucket:     &deployer.bucket,
+				Key:        &liveKey,
+				CopySource: fly.Pointer(copySource(deployer.bucket, key)),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err := waitForWorkers(); err != nil {
+		return err
+	}
+
+	return deployer.deleteDirectory(ctx, staging)
+}
+
+// copySource formats the URL-encoded source of a CopyObject request.
+func copySource(bucket, key string) string {
+	segments := strings.Split(bucket+"/"+key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,85 @@
+package statics
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func newStagedDeployer(t *testing.T, client *mockS3) *DeployerState {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public", "css"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("<html></html>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "css", "app.css"), []byte("body{}"), 0o644))
+	chdir(t, dir)
+
+	return &DeployerState{
+		appConfig:       &appconfig.Config{AppName: "my-app"},
+		releaseVersion:  2,
+		opts:            Options{Staged: true},
+		s3:              client,
+		bucket:          "bucket",
+		root:            "fly-statics/my-app/2",
+		originalStatics: []appconfig.Static{{GuestPath: "public", UrlPrefix: "/"}},
+	}
+}
+
+func liveKeys(client *mockS3) []string {
+	var keys []string
+	for _, key := range client.keys() {
+		if strings.HasPrefix(key, "fly-statics/") {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func TestPushStaged(t *testing.T) {
+	client := newMockS3()
+	client.PutObjectFunc = func(ctx context.Context, params *s3.PutObjectInput) error {
+		// The push is recorded once it's published.
+		if path.Base(*params.Key) != pushRecordKey {
+			assert.Empty(t, liveKeys(client), "live prefix was written before the upload finished")
+		}
+		return nil
+	}
+	deployer := newStagedDeployer(t, client)
+
+	require.NoError(t, deployer.Push(context.Background()))
+	assert.Equal(t, []string{
+		"fly-statics/my-app/2/.fly-statics-push.json",
+		"fly-statics/my-app/2/0/css/app.css",
+		"fly-statics/my-app/2/0/index.html",
+	}, client.keys())
+	assert.Equal(t, "/fly-statics/my-app/2/0/", deployer.appConfig.Statics[0].GuestPath)
+}
+
+func TestPushStagedFailure(t *testing.T) {
+	withoutRetryDelay(t)
+
+	client := newMockS3()
+	client.PutObjectFunc = func(ctx context.Context, params *s3.PutObjectInput) error {
+		if strings.HasSuffix(*params.Key, "app.css") {
+			return errors.New("boom")
+		}
+		return nil
+	}
+	deployer := newStagedDeployer(t, client)
+
+	require.Error(t, deployer.Push(context.Background()))
+	assert.Empty(t, client.copies)
+	assert.Empty(t, client.keys())
+}
+
+func TestCopySource(t *testing.T) {
+	assert.Equal(t, "bucket/fly-statics/app/1/0/my%20file.txt", copySource("bucket", "fly-statics/app/1/0/my file.txt"))
+}
@@ -0,0 +1,66 @@
+package statics
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+const (
+	defaultStorageRegion             = "us-east-1"
+	defaultStorageAccessKeyIDEnv     = "AWS_ACCESS_KEY_ID"
+	defaultStorageSecretAccessKeyEnv = "AWS_SECRET_ACCESS_KEY"
+)
+
+// customStorageClient returns a client for the user's own object storage. Its credentials are already
+// on this machine, so unlike with the Tigris bucket, requests go to it directly rather than through the tokenizer.
+func customStorageClient(ctx context.Context, storage *appconfig.StaticsStorage) (s3API, error) {
+
+	accessKeyID, secretAccessKey, err := storageCredentials(storage)
+	if err != nil {
+		return nil, err
+	}
+
+	region := storage.Region
+	if region == "" {
+		region = defaultStorageRegion
+	}
+
+	s3Config, err := config.LoadDefaultConfig(ctx,
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, "")),
+		config.WithRegion(region),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return s3.NewFromConfig(s3Config, withChecksums, func(o *s3.Options) {
+		o.BaseEndpoint = fly.Pointer(storage.Endpoint)
+		o.UsePathStyle = storage.PathStyle
+	}), nil
+}
+
+// storageCredentials reads the storage's credentials from the environment variables its config names.
+func storageCredentials(storage *appconfig.StaticsStorage) (accessKeyID, secretAccessKey string, err error) {
+	accessKeyIDEnv := storage.AccessKeyIDEnv
+	if accessKeyIDEnv == "" {
+		accessKeyIDEnv = defaultStorageAccessKeyIDEnv
+	}
+	secretAccessKeyEnv := storage.SecretAccessKeyEnv
+	if secretAccessKeyEnv == "" {
+		secretAccessKeyEnv = defaultStorageSecretAccessKeyEnv
+	}
+
+	accessKeyID = os.Getenv(accessKeyIDEnv)
+	secretAccessKey = os.Getenv(secretAccessKeyEnv)
+	if accessKeyID == "" || secretAccessKey == "" {
+		return "", "", fmt.Errorf("pushing statics to %s requires credentials in the %s and %s environment variables", storage.Endpoint, accessKeyIDEnv, secretAccessKeyEnv)
+	}
+	return accessKeyID, secretAccessKey, nil
+}
@@ -0,0 +1,37 @@
+package statics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestStorageCredentials(t *testing.T) {
+	t.Setenv(defaultStorageAccessKeyIDEnv, "")
+	t.Setenv(defaultStorageSecretAccessKeyEnv, "")
+
+	storage := &appconfig.StaticsStorage{Endpoint: "https://s3.example.com", Bucket: "my-statics"}
+	_, _, err := storageCredentials(storage)
+	assert.ErrorContains(t, err, "AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+
+	t.Setenv(defaultStorageAccessKeyIDEnv, "default-id")
+	t.Setenv(defaultStorageSecretAccessKeyEnv, "default-secret")
+	id, secret, err := storageCredentials(storage)
+	require.NoError(t, err)
+	assert.Equal(t, "default-id", id)
+	assert.Equal(t, "default-secret", secret)
+
+	storage.AccessKeyIDEnv = "STATICS_ACCESS_KEY_ID"
+	storage.SecretAccessKeyEnv = "STATICS_SECRET_ACCESS_KEY"
+	_, _, err = storageCredentials(storage)
+	assert.ErrorContains(t, err, "STATICS_ACCESS_KEY_ID and STATICS_SECRET_ACCESS_KEY")
+
+	t.Setenv("STATICS_ACCESS_KEY_ID", "statics-id")
+	t.Setenv("STATICS_SECRET_ACCESS_KEY", "statics-secret")
+	id, secret, err = storageCredentials(storage)
+	require.NoError(t, err)
+	assert.Equal(t, "statics-id", id)
+	assert.Equal(t, "statics-secret", secret)
+}
@@ -0,0 +1,51 @@
+package statics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadDirectorySymlinks(t *testing.T) {
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("x"), 0o644))
+
+	dir := t.TempDir()
+	for _, file := range []string{"index.html", "assets/app.js"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte("x"), 0o644))
+	}
+	require.NoError(t, os.Symlink("index.html", filepath.Join(dir, "home.html")))
+	require.NoError(t, os.Symlink("assets", filepath.Join(dir, "static")))
+	require.NoError(t, os.Symlink("missing.html", filepath.Join(dir, "broken.html")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(dir, "secret.txt")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "outside")))
+	require.NoError(t, os.Symlink("..", filepath.Join(dir, "assets", "loop")))
+
+	client := newMockS3()
+	deployer := &DeployerState{s3: client, bucket: "bucket"}
+	require.NoError(t, deployer.uploadDirectory(context.Background(), "root/0/", "", dir))
+
+	assert.Equal(t, []string{
+		"root/0/assets/app.js",
+		"root/0/home.html",
+		"root/0/index.html",
+		"root/0/static/app.js",
+	}, client.keys())
+}
+
+func TestListStaticFilesSymlinkedRoot(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("hello"), 0o644))
+	require.NoError(t, os.Symlink("public", filepath.Join(dir, "dist")))
+
+	files, totalBytes, err := listStaticFiles(nil, filepath.Join(dir, "dist"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"index.html"}, files)
+	assert.Equal(t, int64(5), totalBytes)
+}
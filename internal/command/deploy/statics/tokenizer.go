@@ -0,0 +1,41 @@
+package statics
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+
+	"github.com/superfly/flyctl/internal/env"
+)
+
+const (
+	defaultTokenizerUrl     = "https://tokenizer.fly.io"
+	defaultTokenizerSealKey = "3afdb665d93f741adc98a6cfecb36f1e02403a095e8efa921fd2321857011f42"
+
+	tokenizerUrlEnvKey     = "FLY_STATICS_TOKENIZER_URL"
+	tokenizerSealKeyEnvKey = "FLY_STATICS_TOKENIZER_SEAL_KEY"
+)
+
+// tokenizerConfig is the tokenizer that bucket credentials are sealed for, and that bucket traffic is proxied through.
+type tokenizerConfig struct {
+	url     *url.URL
+	sealKey string
+}
+
+// tokenizerConfigFromEnv returns the tokenizer to use for statics. It defaults to Fly.io's public tokenizer,
+// but can be pointed at a private one (along with its seal key) for self-hosted or staging setups.
+func tokenizerConfigFromEnv() (*tokenizerConfig, error) {
+	rawUrl := env.FirstOrDefault(defaultTokenizerUrl, tokenizerUrlEnvKey)
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil || parsedUrl.Host == "" || (parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https") {
+		return nil, fmt.Errorf("invalid %s value '%s', expected an http(s) URL", tokenizerUrlEnvKey, rawUrl)
+	}
+
+	// Secrets are sealed to the tokenizer's curve25519 public key, hex encoded.
+	sealKey := env.FirstOrDefault(defaultTokenizerSealKey, tokenizerSealKeyEnvKey)
+	if key, err := hex.DecodeString(sealKey); err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("invalid %s value, expected a 64 character hex encoded seal key", tokenizerSealKeyEnvKey)
+	}
+
+	return &tokenizerConfig{url: parsedUrl, sealKey: sealKey}, nil
+}
@@ -0,0 +1,35 @@
+package statics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenizerConfigFromEnv(t *testing.T) {
+	cfg, err := tokenizerConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, defaultTokenizerUrl, cfg.url.String())
+	assert.Equal(t, defaultTokenizerSealKey, cfg.sealKey)
+
+	t.Setenv(tokenizerUrlEnvKey, "https://tokenizer.internal.example.com")
+	t.Setenv(tokenizerSealKeyEnvKey, "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	cfg, err = tokenizerConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "tokenizer.internal.example.com", cfg.url.Host)
+	assert.Equal(t, "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", cfg.sealKey)
+
+	t.Setenv(tokenizerSealKeyEnvKey, "not-hex")
+	_, err = tokenizerConfigFromEnv()
+	assert.ErrorContains(t, err, tokenizerSealKeyEnvKey)
+
+	t.Setenv(tokenizerSealKeyEnvKey, "abcd")
+	_, err = tokenizerConfigFromEnv()
+	assert.ErrorContains(t, err, tokenizerSealKeyEnvKey)
+
+	t.Setenv(tokenizerSealKeyEnvKey, defaultTokenizerSealKey)
+	t.Setenv(tokenizerUrlEnvKey, "tokenizer.internal")
+	_, err = tokenizerConfigFromEnv()
+	assert.ErrorContains(t, err, tokenizerUrlEnvKey)
+}
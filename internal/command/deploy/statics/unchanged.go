@@ -0,0 +1,71 @@
+package statics
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/samber/lo"
+	"github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/logger"
+)
+
+// copyIfUnchanged copies the object at previousKey to the destination of input if its content
+// matches body, which is rewound afterwards. The copy takes its headers from input, so changes to
+// content types or caching rules still apply to unchanged files.
+//
+// It reports whether the object was copied. If it wasn't, the file should be uploaded.
+func (deployer *DeployerState) copyIfUnchanged(ctx context.Context, previousKey string, input *s3.PutObjectInput, body io.ReadSeeker) (bool, error) {
+	etag, err := contentETag(body)
+	if err != nil {
+		return false, err
+	}
+
+	head, err := deployer.s3.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: &deployer.bucket,
+		Key:    &previousKey,
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if !errors.As(err, &notFound) {
+			deployer.logEvent(logger.Debug, "previous_lookup_failed", "file", *input.Key, "source", previousKey, "error", err)
+		}
+		return false, nil
+	}
+	// Objects uploaded in multiple parts don't have an MD5 ETag, and never match.
+	if lo.FromPtr(head.ETag) != etag {
+		return false, nil
+	}
+
+	deployer.logEvent(logger.Debug, "upload_copied", "file", *input.Key, "source", previousKey)
+
+	_, err = deployer.s3.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:            input.Bucket,
+		Key:               input.Key,
+		CopySource:        fly.Pointer(copySource(deployer.bucket, previousKey)),
+		MetadataDirective: types.MetadataDirectiveReplace,
+		ContentType:       input.ContentType,
+		ContentEncoding:   input.ContentEncoding,
+		CacheControl:      input.CacheControl,
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// contentETag returns the ETag S3 gives an object with the given content when uploaded in a single part.
+func contentETag(body io.ReadSeeker) (string, error) {
+	hash := md5.New()
+	if _, err := io.Copy(hash, body); err != nil {
+		return "", err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)) + `"`, nil
+}
@@ -0,0 +1,65 @@
+package statics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func newUnchangedDeployer(t *testing.T, client *mockS3) *DeployerState {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "public", "css"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "index.html"), []byte("<html></html>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "css", "app.css"), []byte("body{color:red}"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public", "new.txt"), []byte("new"), 0o644))
+	chdir(t, dir)
+
+	return &DeployerState{
+		appConfig:       &appconfig.Config{AppName: "my-app"},
+		releaseVersion:  3,
+		s3:              client,
+		bucket:          "bucket",
+		root:            "fly-statics/my-app/3",
+		originalStatics: []appconfig.Static{{GuestPath: "public", UrlPrefix: "/"}},
+	}
+}
+
+func TestPushCopiesUnchangedFiles(t *testing.T) {
+	client := newMockS3()
+	client.addObject("fly-statics/my-app/1/0/index.html", "<html>old</html>")
+	client.addObject("fly-statics/my-app/2/0/index.html", "<html></html>")
+	client.addObject("fly-statics/my-app/2/0/css/app.css", "body{}")
+	deployer := newUnchangedDeployer(t, client)
+
+	require.NoError(t, deployer.Push(context.Background()))
+
+	var puts, copies []string
+	for _, put := range client.puts {
+		puts = append(puts, *put.Key)
+	}
+	for _, c := range client.copies {
+		copies = append(copies, *c.Key)
+		assert.Equal(t, "bucket/fly-statics/my-app/2/0/index.html", *c.CopySource)
+	}
+	assert.ElementsMatch(t, []string{"fly-statics/my-app/3/0/css/app.css", "fly-statics/my-app/3/0/new.txt", "fly-statics/my-app/3/.fly-statics-push.json"}, puts)
+	assert.Equal(t, []string{"fly-statics/my-app/3/0/index.html"}, copies)
+
+	assert.Equal(t, "<html></html>", string(client.objects["fly-statics/my-app/3/0/index.html"].body))
+	assert.Equal(t, "text/html", client.objects["fly-statics/my-app/3/0/index.html"].contentType)
+	assert.Equal(t, int64(3), deployer.uploadedFiles.Load())
+}
+
+func TestPushWithoutPreviousVersionUploadsEverything(t *testing.T) {
+	client := newMockS3()
+	deployer := newUnchangedDeployer(t, client)
+
+	require.NoError(t, deployer.Push(context.Background()))
+	assert.Len(t, client.puts, 4)
+	assert.Empty(t, client.copies)
+	assert.Empty(t, client.heads)
+}
@@ -4,13 +4,13 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"net/url"
 	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/superfly/fly-go"
+	"github.com/superfly/fly-go/tokens"
 	"github.com/superfly/flyctl/gql"
 	"github.com/superfly/flyctl/internal/flyutil"
 	"github.com/superfly/tokenizer"
@@ -51,6 +51,9 @@ func spawnWorkers(ctx context.Context, n int, f func(context.Context) error) fun
 	}
 }
 
+// getPushToken mints a short-lived deploy token for org, and returns the Authorization header that
+// authenticates to the tokenizer with it. The tokenizer only accepts macaroons, so this fails with
+// an error explaining how to get one rather than letting every S3 request be rejected.
 func getPushToken(ctx context.Context, org *fly.Organization) (string, error) {
 	client := flyutil.ClientFromContext(ctx)
 
@@ -64,12 +67,29 @@ func getPushToken(ctx context.Context, org *fly.Organization) (string, error) {
 		"1h",
 	)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("failed to create a token to push statics for organization %s: %w\n%s", org.Slug, err, pushTokenHint)
 	}
-	return resp.CreateLimitedAccessToken.LimitedAccessToken.TokenHeader, nil
+	return macaroonAuthHeader(resp.CreateLimitedAccessToken.LimitedAccessToken.TokenHeader, org)
 }
 
-func s3ClientWithAuth(ctx context.Context, auth string, org *fly.Organization) (*s3.Client, error) {
+const pushTokenHint = "Statics are pushed with a macaroon token. Log in again with `fly auth login`, or set FLY_API_TOKEN to a token from `fly tokens create deploy`"
+
+// macaroonAuthHeader returns the FlyV1 Authorization header for the macaroons in header,
+// leaving out any other kind of token.
+func macaroonAuthHeader(header string, org *fly.Organization) (string, error) {
+	toks := tokens.Parse(header)
+	if len(toks.GetMacaroonTokens()) == 0 {
+		return "", fmt.Errorf("the token created to push statics for organization %s isn't a macaroon, which the tokenizer requires\n%s", org.Slug, pushTokenHint)
+	}
+	return toks.MacaroonsOnly().FlapsHeader(), nil
+}
+
+func s3ClientWithAuth(ctx context.Context, auth string, org *fly.Organization) (s3API, error) {
+
+	tokenizerCfg, err := tokenizerConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
 
 	s3Config, err := config.LoadDefaultConfig(ctx,
 		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("tokenizer-access-key", "tokenizer-secret-key", "")),
@@ -80,25 +100,20 @@ func s3ClientWithAuth(ctx context.Context, auth string, org *fly.Organization) (
 	}
 	s3Config.BaseEndpoint = fly.Pointer(tigrisUrl)
 
-	parsedProxyUrl, err := url.Parse(tokenizerUrl)
-	if err != nil {
-		// Should be impossible, this is not runtime-controlled and issues would be caught before release.
-		return nil, fmt.Errorf("could not parse tokenizer URL: %w", err)
-	}
 	s3HttpTransport := http.DefaultTransport.(*http.Transport).Clone()
-	s3HttpTransport.Proxy = http.ProxyURL(parsedProxyUrl)
+	s3HttpTransport.Proxy = http.ProxyURL(tokenizerCfg.url)
 
 	userAuthHeader, err := getPushToken(ctx, org)
 	if err != nil {
 		return nil, err
 	}
 
-	s3HttpClient, err := tokenizer.Client(tokenizerUrl, tokenizer.WithAuth(userAuthHeader), tokenizer.WithSecret(auth, map[string]string{}))
+	s3HttpClient, err := tokenizer.Client(tokenizerCfg.url.String(), tokenizer.WithAuth(userAuthHeader), tokenizer.WithSecret(auth, map[string]string{}))
 	if err != nil {
 		return nil, err
 	}
 
 	s3Config.HTTPClient = s3HttpClient
 
-	return s3.NewFromConfig(s3Config), nil
+	return s3.NewFromConfig(s3Config, withChecksums), nil
 }
@@ -0,0 +1,54 @@
+package statics
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	genq "github.com/Khan/genqlient/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/flyutil"
+	"github.com/superfly/flyctl/internal/mock"
+)
+
+// tokenGenqClient answers CreateLimitedAccessToken with header.
+type tokenGenqClient struct {
+	header string
+}
+
+func (c *tokenGenqClient) MakeRequest(_ context.Context, _ *genq.Request, resp *genq.Response) error {
+	body, err := json.Marshal(map[string]any{
+		"createLimitedAccessToken": map[string]any{
+			"limitedAccessToken": map[string]any{"tokenHeader": c.header},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, resp.Data)
+}
+
+func TestGetPushToken(t *testing.T) {
+	org := &fly.Organization{ID: "org-id", Slug: "my-org"}
+	genqClient := &tokenGenqClient{header: "FlyV1 fm2_abc,fm2_def"}
+	ctx := flyutil.NewContextWithClient(context.Background(), &mock.Client{
+		GenqClientFunc: func() genq.Client { return genqClient },
+	})
+
+	header, err := getPushToken(ctx, org)
+	require.NoError(t, err)
+	assert.Equal(t, "FlyV1 fm2_abc,fm2_def", header)
+
+	// Anything that isn't a macaroon is left out
+	genqClient.header = "FlyV1 fm2_abc,oauth-token"
+	header, err = getPushToken(ctx, org)
+	require.NoError(t, err)
+	assert.Equal(t, "FlyV1 fm2_abc", header)
+
+	genqClient.header = "Bearer oauth-token"
+	_, err = getPushToken(ctx, org)
+	require.ErrorContains(t, err, "the token created to push statics for organization my-org isn't a macaroon, which the tokenizer requires")
+	assert.ErrorContains(t, err, "fly tokens create deploy")
+}
@@ -0,0 +1,149 @@
+package statics
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/flyutil"
+)
+
+// VersionSummary describes the statics stored in the bucket for one version.
+type VersionSummary struct {
+	Version int   `json:"version"`
+	Objects int   `json:"objects"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// appDeployer returns a deployer for the existing statics bucket of an app, for managing
+// what's already been pushed. Returns nil, nil if the app has no statics bucket.
+func appDeployer(ctx context.Context, appName string) (*DeployerState, error) {
+	client := flyutil.ClientFromContext(ctx)
+
+	app, err := client.GetApp(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket, err := FindBucket(ctx, app, &app.Organization)
+	if err != nil {
+		return nil, err
+	}
+	if bucket == nil {
+		return nil, nil
+	}
+	meta := bucket.Metadata.(map[string]interface{})
+
+	s3Client, err := s3ClientWithAuth(ctx, meta[staticsMetaTokenizedAuth].(string), &app.Organization)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeployerState{
+		app:       app,
+		org:       &app.Organization,
+		appConfig: bucketConfig(ctx, app.Name),
+		s3:        s3Client,
+		bucket:    meta[staticsMetaBucketName].(string),
+	}, nil
+}
+
+// bucketConfig returns the settings for finding an app's statics in its bucket. They come from the
+// app config in ctx, if it's the app's, so that statics pushed under a custom prefix are found.
+func bucketConfig(ctx context.Context, appName string) *appconfig.Config {
+	cfg := &appconfig.Config{AppName: appName}
+	if local := appconfig.ConfigFromContext(ctx); local != nil && local.AppName == appName && local.StaticsOptions != nil {
+		cfg.StaticsOptions = &appconfig.StaticsOptions{Prefix: local.StaticsOptions.Prefix}
+	}
+	return cfg
+}
+
+// BucketPrefix returns the prefix the app's statics versions are stored under, e.g. fly-statics/my-app/.
+func BucketPrefix(ctx context.Context, appName string) string {
+	return (&DeployerState{appConfig: bucketConfig(ctx, appName)}).appRoot(appName)
+}
+
+// ListVersions summarizes every statics version in the app's bucket, oldest first.
+// Returns nil if the app has no statics bucket.
+func ListVersions(ctx context.Context, appName string) ([]VersionSummary, error) {
+	deployer, err := appDeployer(ctx, appName)
+	if err != nil || deployer == nil {
+		return nil, err
+	}
+	return deployer.summarizeVersions(ctx, appName)
+}
+
+// summarizeVersions counts the objects and bytes stored under each version of the app's statics.
+func (deployer *DeployerState) summarizeVersions(ctx context.Context, appName string) ([]VersionSummary, error) {
+	prefix := deployer.appRoot(appName)
+	paginator := s3.NewListObjectsV2Paginator(deployer.s3, &s3.ListObjectsV2Input{
+		Bucket: &deployer.bucket,
+		Prefix: fly.Pointer(prefix),
+	})
+
+	summaries := map[int]*VersionSummary{}
+	for paginator.HasMorePages() {
+		listOutput, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range listOutput.Contents {
+			versionStr, rel, _ := strings.Cut(strings.TrimPrefix(*obj.Key, prefix), "/")
+			version, err := strconv.Atoi(versionStr)
+			if err != nil || rel == pushRecordKey {
+				continue
+			}
+			summary, ok := summaries[version]
+			if !ok {
+				summary = &VersionSummary{Version: version}
+				summaries[version] = summary
+			}
+			summary.Objects++
+			if obj.Size != nil {
+				summary.Bytes += *obj.Size
+			}
+		}
+	}
+
+	versions := make([]VersionSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		versions = append(versions, *summary)
+	}
+	slices.SortFunc(versions, func(a, b VersionSummary) int { return a.Version - b.Version })
+	return versions, nil
+}
+
+// VersionFromGuestPath returns the statics version a machine's static is served from,
+// given the app's BucketPrefix and its guest path, e.g. /fly-statics/my-app/12/0/. It reports
+// false for statics that weren't pushed by flyctl.
+func VersionFromGuestPath(prefix, guestPath string) (int, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimPrefix(guestPath, "/"), prefix)
+	if !ok {
+		return 0, false
+	}
+	versionStr, _, _ := strings.Cut(rest, "/")
+	version, err := strconv.Atoi(versionStr)
+	if err != nil {
+		return 0, false
+	}
+	return version, true
+}
+
+// DeleteVersions deletes the given statics versions from the app's bucket,
+// returning the number of objects deleted.
+func DeleteVersions(ctx context.Context, appName string, versions []int) (int64, error) {
+	deployer, err := appDeployer(ctx, appName)
+	if err != nil || deployer == nil {
+		return 0, err
+	}
+	for _, version := range versions {
+		if err := deployer.deleteDirectory(ctx, deployer.versionRoot(appName, version)+"/"); err != nil {
+			return deployer.deletedObjects.Load(), err
+		}
+	}
+	return deployer.deletedObjects.Load(), nil
+}
@@ -0,0 +1,38 @@
+package statics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeVersions(t *testing.T) {
+	client := newMockS3()
+	client.pageSize = 2
+	client.addObject("fly-statics/my-app/10/0/index.html", "hello")
+	client.addObject("fly-statics/my-app/10/0/css/app.css", "body{}")
+	client.addObject("fly-statics/my-app/9/0/index.html", "hi")
+	client.addObject("fly-statics/my-app/not-a-version/0/index.html", "ignored")
+	client.addObject("fly-statics/other-app/1/0/index.html", "ignored")
+
+	deployer := &DeployerState{s3: client, bucket: "bucket"}
+	versions, err := deployer.summarizeVersions(context.Background(), "my-app")
+	require.NoError(t, err)
+	assert.Equal(t, []VersionSummary{
+		{Version: 9, Objects: 1, Bytes: 2},
+		{Version: 10, Objects: 2, Bytes: 11},
+	}, versions)
+}
+
+func TestVersionFromGuestPath(t *testing.T) {
+	version, ok := VersionFromGuestPath("fly-statics/my-app/", "/fly-statics/my-app/12/0/")
+	assert.True(t, ok)
+	assert.Equal(t, 12, version)
+
+	_, ok = VersionFromGuestPath("fly-statics/my-app/", "/fly-statics/other-app/12/0/")
+	assert.False(t, ok)
+	_, ok = VersionFromGuestPath("fly-statics/my-app/", "public")
+	assert.False(t, ok)
+}
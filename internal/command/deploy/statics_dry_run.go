@@ -0,0 +1,70 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command/deploy/statics"
+	"github.com/superfly/flyctl/internal/flyutil"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// printStaticsPlan shows which statics a deploy would push to tigris, where to, and how much it
+// would upload. It only reads from the API, nothing is created or uploaded.
+func printStaticsPlan(ctx context.Context, appConfig *appconfig.Config, appCompact *fly.AppCompact) error {
+	io := iostreams.FromContext(ctx)
+	apiClient := flyutil.ClientFromContext(ctx)
+
+	plan, err := statics.Plan(appConfig)
+	if err != nil {
+		return err
+	}
+	if len(plan) == 0 {
+		fmt.Fprintln(io.Out, "No statics are configured")
+		return nil
+	}
+
+	var pushed, skipped []statics.PlannedStatic
+	for _, planned := range plan {
+		if planned.SkipReason == "" {
+			pushed = append(pushed, planned)
+		} else {
+			skipped = append(skipped, planned)
+		}
+	}
+
+	if len(pushed) > 0 {
+		app, err := apiClient.GetApp(ctx, appCompact.Name)
+		if err != nil {
+			return err
+		}
+		org, err := apiClient.GetOrganizationBySlug(ctx, appCompact.Organization.Slug)
+		if err != nil {
+			return err
+		}
+		bucket, err := statics.FindBucket(ctx, app, org)
+		if err != nil {
+			return err
+		}
+
+		destination := "a new tigris bucket"
+		if bucket != nil {
+			destination = fmt.Sprintf("the tigris bucket '%s'", bucket.Name)
+		}
+		fmt.Fprintf(io.Out, "Statics that would be pushed to %s:\n", destination)
+		for _, planned := range pushed {
+			fmt.Fprintf(io.Out, "  %s -> %s (%d files, %s)\n", planned.GuestPath, planned.UrlPrefix, planned.Files, humanize.Bytes(uint64(planned.Bytes)))
+		}
+	}
+
+	if len(skipped) > 0 {
+		fmt.Fprintln(io.Out, "Statics that would not be pushed:")
+		for _, planned := range skipped {
+			fmt.Fprintf(io.Out, "  %s -> %s: %s\n", planned.GuestPath, planned.UrlPrefix, planned.SkipReason)
+		}
+	}
+	return nil
+}
@@ -25,11 +25,27 @@ import (
 )
 
 type Extension struct {
+	// ID, PlanID and Options identify the provisioned add-on for follow-up
+	// mutations such as gql.UpdateAddOn, without having to refetch it.
+	ID          string
+	PlanID      string
+	Options     interface{}
 	Data        gql.ExtensionData
 	App         *gql.AppData
 	SetsSecrets bool
 }
 
+// extensionFromAddOn builds the Extension returned by ProvisionExtension from the created add-on.
+func extensionFromAddOn(addOn gql.CreateExtensionCreateAddOnCreateAddOnPayloadAddOn, app *gql.AppData) Extension {
+	return Extension{
+		ID:      addOn.Id,
+		PlanID:  addOn.AddOnPlan.Id,
+		Options: addOn.Options,
+		Data:    addOn.ExtensionData,
+		App:     app,
+	}
+}
+
 type ExtensionParams struct {
 	AppName              string
 	Organization         *fly.Organization
@@ -210,8 +226,7 @@ func ProvisionExtension(ctx context.Context, params ExtensionParams) (extension
 		return
 	}
 
-	extension.Data = createResp.CreateAddOn.AddOn.ExtensionData
-	extension.App = &targetApp
+	extension = extensionFromAddOn(createResp.CreateAddOn.AddOn, &targetApp)
 
 	if provider.AsyncProvisioning {
 		err = WaitForProvision(ctx, extension.Data.Name, params.Provider)
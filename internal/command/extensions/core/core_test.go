@@ -0,0 +1,31 @@
+package extensions_core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/gql"
+)
+
+func TestExtensionFromAddOn(t *testing.T) {
+	var resp gql.CreateExtensionResponse
+	require.NoError(t, json.Unmarshal([]byte(`{"createAddOn": {"addOn": {
+		"id": "ao_123",
+		"options": {"public": true},
+		"addOnPlan": {"id": "plan_456"},
+		"name": "my-bucket",
+		"environment": {"BUCKET_NAME": "my-bucket"},
+		"primaryRegion": "iad"
+	}}}`), &resp))
+
+	app := &gql.AppData{Name: "my-app"}
+	extension := extensionFromAddOn(resp.CreateAddOn.AddOn, app)
+	assert.Equal(t, "ao_123", extension.ID)
+	assert.Equal(t, "plan_456", extension.PlanID)
+	assert.Equal(t, map[string]interface{}{"public": true}, extension.Options)
+	assert.Equal(t, "my-bucket", extension.Data.Name)
+	assert.Equal(t, "iad", extension.Data.PrimaryRegion)
+	assert.Same(t, app, extension.App)
+}
@@ -58,6 +58,7 @@ import (
 	"github.com/superfly/flyctl/internal/command/services"
 	"github.com/superfly/flyctl/internal/command/settings"
 	"github.com/superfly/flyctl/internal/command/ssh"
+	"github.com/superfly/flyctl/internal/command/statics"
 	"github.com/superfly/flyctl/internal/command/status"
 	"github.com/superfly/flyctl/internal/command/storage"
 	"github.com/superfly/flyctl/internal/command/suspend"
@@ -136,6 +137,7 @@ func New() *cobra.Command {
 		group(console.New(), "upkeep"),
 		settings.New(),
 		group(storage.New(), "dbs_and_extensions"),
+		group(statics.New(), "deploy"),
 		metrics.New(),
 		synthetics.New(),
 		curl.New(),       // TODO: deprecate
@@ -0,0 +1,287 @@
+package scale
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/sourcegraph/conc/pool"
+	fly "github.com/superfly/fly-go"
+	mach "github.com/superfly/flyctl/internal/machine"
+)
+
+// GuestTarget is the guest ApplyGuestConfig resizes machines to, and how. A zero memory or
+// number of CPUs keeps what the size, or each machine when there's no size, already has.
+type GuestTarget struct {
+	SizeName string
+	MemoryMB int
+	// MemoryDeltaMB is added to the memory of each machine, once the size is applied.
+	MemoryDeltaMB int
+	CPUs          int
+	// CPUsDelta is added to the CPUs of each machine, once the size is applied.
+	CPUsDelta int
+	CPUKind   string
+	GPUs      int
+	GPUKind   string
+}
+
+// GuestUpdateOptions is how ApplyGuestConfig goes about updating the machines.
+type GuestUpdateOptions struct {
+	// MaxConcurrent bounds how many machines are updated at once.
+	MaxConcurrent int
+	// RollbackOnFailure restores the machines that were updated when another one fails.
+	RollbackOnFailure bool
+}
+
+// MachineResult is the size and memory of a machine before and after it was resized. A new
+// machine has no ID or old size, and a machine that failed to update has an Error.
+type MachineResult struct {
+	ID        string
+	Region    string
+	OldSize   string
+	OldMemory int
+	NewSize   string
+	NewMemory int
+	Error     string `json:",omitempty"`
+	// RolledBack is set on machines that were updated, then put back to their old size.
+	RolledBack bool `json:",omitempty"`
+	// Unchanged is set on machines that were already at the new size, and were left alone.
+	Unchanged bool `json:",omitempty"`
+	// Guest is the guest the machine was resized to.
+	Guest *fly.MachineGuest `json:"-"`
+}
+
+func newMachineResult(id, region string, old, guest *fly.MachineGuest) MachineResult {
+	result := MachineResult{
+		ID:        id,
+		Region:    region,
+		NewSize:   guest.ToSize(),
+		NewMemory: guest.MemoryMB,
+		Guest:     guest,
+	}
+	if old != nil {
+		result.OldSize = old.ToSize()
+		result.OldMemory = old.MemoryMB
+	}
+	return result
+}
+
+// ApplyGuestConfig resizes the guest of each of the machines, which must already be leased, to
+// target. Every guest is validated before any machine is updated. Apart from the progress of
+// each update, it doesn't print anything, so that commands other than fly scale vm can report
+// the results their own way.
+//
+// Up to opts.MaxConcurrent machines are updated at once. A machine that fails to update doesn't stop
+// the others: its result holds the error, and the returned error lists every machine that failed.
+// With opts.RollbackOnFailure, machines that haven't started updating are skipped after a failure
+// and the ones already updated are put back to their previous guest. The results are nil, and the
+// machines are left as they were, when a guest isn't valid.
+func ApplyGuestConfig(ctx context.Context, machines []*fly.Machine, target GuestTarget, opts GuestUpdateOptions) ([]MachineResult, error) {
+	oldGuests := make([]fly.MachineGuest, len(machines))
+	newGuests := make([]fly.MachineGuest, len(machines))
+	for i, machine := range machines {
+		oldGuests[i] = *machine.Config.Guest
+		newGuests[i] = oldGuests[i]
+		if err := applyGuestChanges(&newGuests[i], target); err != nil {
+			return nil, fmt.Errorf("machine %s: %w", machine.ID, err)
+		}
+		if err := validateGuest(&newGuests[i], target); err != nil {
+			return nil, err
+		}
+	}
+	for i, machine := range machines {
+		*machine.Config.Guest = newGuests[i]
+	}
+
+	var failedOnce atomic.Bool
+	errs := make([]error, len(machines))
+	skipped := make([]bool, len(machines))
+	p := pool.New().WithMaxGoroutines(max(opts.MaxConcurrent, 1))
+	for i, machine := range machines {
+		p.Go(func() {
+			if opts.RollbackOnFailure && failedOnce.Load() {
+				skipped[i] = true
+				return
+			}
+			errs[i] = updateMachineGuest(ctx, machine)
+			if errs[i] != nil {
+				failedOnce.Store(true)
+			}
+		})
+	}
+	p.Wait()
+
+	results := make([]MachineResult, len(machines))
+	var failed []string
+	for i, machine := range machines {
+		results[i] = newMachineResult(machine.ID, machine.Region, &oldGuests[i], machine.Config.Guest)
+		if errs[i] != nil {
+			results[i].Error = errs[i].Error()
+			failed = append(failed, machine.ID)
+		}
+	}
+	if len(failed) == 0 {
+		return results, nil
+	}
+	err := fmt.Errorf("failed to update %d of %d machines (%s):\n%w", len(failed), len(machines), strings.Join(failed, ", "), errors.Join(errs...))
+	if !opts.RollbackOnFailure {
+		return results, err
+	}
+
+	var rollbackErrs []error
+	for i, machine := range machines {
+		switch {
+		case skipped[i]:
+			*machine.Config.Guest = oldGuests[i]
+			results[i] = newMachineResult(machine.ID, machine.Region, &oldGuests[i], machine.Config.Guest)
+			results[i].Error = "skipped after another machine failed to update"
+		case errs[i] == nil:
+			*machine.Config.Guest = oldGuests[i]
+			if rollbackErr := updateMachineGuest(ctx, machine); rollbackErr != nil {
+				results[i].Error = fmt.Sprintf("failed to roll back: %v", rollbackErr)
+				rollbackErrs = append(rollbackErrs, fmt.Errorf("failed to roll back machine %s: %w", machine.ID, rollbackErr))
+				continue
+			}
+			results[i] = newMachineResult(machine.ID, machine.Region, &oldGuests[i], machine.Config.Guest)
+			results[i].RolledBack = true
+		}
+	}
+	return results, errors.Join(append([]error{err}, rollbackErrs...)...)
+}
+
+// updateMachine updates a machine within the lease already held on it, it's replaced in tests.
+var updateMachine = mach.Update
+
+// updateMachineGuest updates machine to its config, within the lease already held on it.
+func updateMachineGuest(ctx context.Context, machine *fly.Machine) error {
+	input := &fly.LaunchMachineInput{
+		Name:   machine.Name,
+		Region: machine.Region,
+		Config: machine.Config,
+	}
+	return updateMachine(ctx, machine, input)
+}
+
+// sameGuestSize reports whether a and b have the same CPUs, memory and GPUs, which are all
+// that applyGuestChanges changes.
+func sameGuestSize(a, b *fly.MachineGuest) bool {
+	return a.CPUKind == b.CPUKind &&
+		a.CPUs == b.CPUs &&
+		a.MemoryMB == b.MemoryMB &&
+		a.GPUKind == b.GPUKind &&
+		a.GPUs == b.GPUs
+}
+
+// applyGuestChanges sets guest to the preset for target.SizeName, if any, then overrides its CPU
+// kind, memory, number of CPUs and GPUs with the ones that were asked for. Setting a GPU kind
+// on a guest without GPUs attaches one, and the "none" kind detaches them.
+//
+// Changes to the memory and CPUs are added to what guest has. When only the CPUs change, the
+// memory is brought within what the new number of CPUs allows. It fails when the CPUs or memory
+// would drop to zero.
+func applyGuestChanges(guest *fly.MachineGuest, target GuestTarget) error {
+	if target.SizeName != "" {
+		// The size is validated by the callers
+		guest.SetSize(target.SizeName)
+	}
+	if target.CPUKind != "" {
+		guest.CPUKind = target.CPUKind
+	}
+	switch target.GPUKind {
+	case "":
+	case "none":
+		guest.GPUKind, guest.GPUs = "", 0
+	default:
+		guest.GPUKind = target.GPUKind
+		if guest.GPUs == 0 {
+			guest.GPUs = 1
+		}
+	}
+	if target.GPUs > 0 {
+		guest.GPUs = target.GPUs
+	}
+	if target.MemoryMB > 0 {
+		guest.MemoryMB = target.MemoryMB
+	}
+	if target.CPUs > 0 {
+		guest.CPUs = target.CPUs
+	}
+
+	if target.CPUsDelta != 0 {
+		if guest.CPUs+target.CPUsDelta <= 0 {
+			return fmt.Errorf("can't remove %d CPUs from a VM with %d", -target.CPUsDelta, guest.CPUs)
+		}
+		guest.CPUs += target.CPUsDelta
+		if target.MemoryMB == 0 && target.MemoryDeltaMB == 0 {
+			clampGuestMemory(guest)
+		}
+	}
+	if target.MemoryDeltaMB != 0 {
+		if guest.MemoryMB+target.MemoryDeltaMB <= 0 {
+			return fmt.Errorf("can't remove %dMB of memory from a VM with %dMB", -target.MemoryDeltaMB, guest.MemoryMB)
+		}
+		guest.MemoryMB += target.MemoryDeltaMB
+	}
+	return nil
+}
+
+// clampGuestMemory brings the memory of guest within the range its CPU kind and number of
+// CPUs allow.
+func clampGuestMemory(guest *fly.MachineGuest) {
+	switch guest.CPUKind {
+	case "shared":
+		guest.MemoryMB = min(max(guest.MemoryMB, fly.MIN_MEMORY_MB_PER_SHARED_CPU*guest.CPUs), fly.MAX_MEMORY_MB_PER_SHARED_CPU*guest.CPUs)
+	case "performance":
+		guest.MemoryMB = min(max(guest.MemoryMB, fly.MIN_MEMORY_MB_PER_CPU*guest.CPUs), fly.MAX_MEMORY_MB_PER_CPU*guest.CPUs)
+	}
+}
+
+// validateGuest checks guest once the changes in target are applied to it. Its GPUs are only
+// checked when target changes them, or the size, so machines keep working with GPU models
+// that are only known to the platform.
+func validateGuest(guest *fly.MachineGuest, target GuestTarget) error {
+	if target.SizeName != "" && (target.MemoryMB > 0 || target.MemoryDeltaMB != 0) {
+		if err := validateSizeMemory(guest, target.SizeName); err != nil {
+			return err
+		}
+	}
+	if err := mach.ValidateGuest(guest); err != nil {
+		return err
+	}
+	if target.SizeName != "" || target.GPUKind != "" || target.GPUs > 0 {
+		return mach.ValidateGPUs(guest)
+	}
+	return nil
+}
+
+// validateSizeMemory explains the range of memory sizeName allows when the memory asked for
+// along with it is out of that range. Other problems, like a number of CPUs the kind doesn't
+// have, are left to mach.ValidateGuest.
+func validateSizeMemory(guest *fly.MachineGuest, sizeName string) error {
+	var minMemory, maxMemory, step int
+	switch guest.CPUKind {
+	case "shared":
+		minMemory, maxMemory, step = fly.MIN_MEMORY_MB_PER_SHARED_CPU*guest.CPUs, fly.MAX_MEMORY_MB_PER_SHARED_CPU*guest.CPUs, 256
+	case "performance":
+		minMemory, maxMemory, step = fly.MIN_MEMORY_MB_PER_CPU*guest.CPUs, fly.MAX_MEMORY_MB_PER_CPU*guest.CPUs, 1024
+	default:
+		return nil
+	}
+	if guest.MemoryMB >= minMemory && guest.MemoryMB <= maxMemory && guest.MemoryMB%step == 0 {
+		return nil
+	}
+	probe := *guest
+	probe.MemoryMB = minMemory
+	if mach.ValidateGuest(&probe) != nil {
+		return nil
+	}
+
+	cpus := "CPUs"
+	if guest.CPUs == 1 {
+		cpus = "CPU"
+	}
+	return fmt.Errorf("%dMB of memory isn't valid for size '%s'\n * %s VMs with %d %s take between %dMB and %dMB of memory, in increments of %dMB",
+		guest.MemoryMB, sizeName, guest.CPUKind, guest.CPUs, cpus, minMemory, maxMemory, step)
+}
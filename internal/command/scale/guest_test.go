@@ -0,0 +1,85 @@
+package scale
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+)
+
+// fakeUpdateMachine replaces the machine updates with update for the duration of the test.
+func fakeUpdateMachine(t *testing.T, update func(machine *fly.Machine, input *fly.LaunchMachineInput) error) {
+	original := updateMachine
+	t.Cleanup(func() { updateMachine = original })
+	updateMachine = func(ctx context.Context, machine *fly.Machine, input *fly.LaunchMachineInput) error {
+		return update(machine, input)
+	}
+}
+
+func TestApplyGuestConfigWithFakeUpdate(t *testing.T) {
+	var (
+		lock    sync.Mutex
+		updated = map[string]fly.MachineGuest{}
+	)
+	fakeUpdateMachine(t, func(machine *fly.Machine, input *fly.LaunchMachineInput) error {
+		if machine.ID == "m2" {
+			return fmt.Errorf("machine is busy")
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		updated[machine.ID] = *input.Config.Guest
+		return nil
+	})
+
+	newMachines := func() []*fly.Machine {
+		var machines []*fly.Machine
+		for _, id := range []string{"m1", "m2", "m3"} {
+			guest := &fly.MachineGuest{}
+			require.NoError(t, guest.SetSize("shared-cpu-1x"))
+			machines = append(machines, &fly.Machine{ID: id, Region: "iad", Config: &fly.MachineConfig{Guest: guest}})
+		}
+		return machines
+	}
+
+	results, err := ApplyGuestConfig(context.Background(), newMachines(), GuestTarget{SizeName: "shared-cpu-2x", MemoryDeltaMB: 512}, GuestUpdateOptions{MaxConcurrent: 2})
+	require.ErrorContains(t, err, "failed to update 1 of 3 machines (m2)")
+	require.ErrorContains(t, err, "machine is busy")
+
+	require.Len(t, results, 3)
+	for i, result := range results {
+		assert.Equal(t, fmt.Sprintf("m%d", i+1), result.ID)
+		assert.Equal(t, "shared-cpu-1x", result.OldSize)
+		assert.Equal(t, 1024, result.NewMemory)
+		assert.Equal(t, "shared-cpu-2x", result.Guest.ToSize())
+	}
+	assert.Empty(t, results[0].Error)
+	assert.Equal(t, "machine is busy", results[1].Error)
+	assert.Empty(t, results[2].Error)
+
+	assert.Len(t, updated, 2)
+	for _, id := range []string{"m1", "m3"} {
+		assert.Equal(t, 2, updated[id].CPUs)
+		assert.Equal(t, 1024, updated[id].MemoryMB)
+	}
+
+	// An invalid guest updates nothing, and has no results
+	updated = map[string]fly.MachineGuest{}
+	results, err = ApplyGuestConfig(context.Background(), newMachines(), GuestTarget{CPUs: 16}, GuestUpdateOptions{MaxConcurrent: 2})
+	assert.Error(t, err)
+	assert.Nil(t, results)
+	assert.Empty(t, updated)
+
+	// A guest that's only invalid on a later machine leaves the earlier ones as they were
+	machines := newMachines()
+	require.NoError(t, machines[0].Config.Guest.SetSize("shared-cpu-2x"))
+	results, err = ApplyGuestConfig(context.Background(), machines, GuestTarget{CPUsDelta: -1}, GuestUpdateOptions{MaxConcurrent: 2})
+	assert.ErrorContains(t, err, "machine m2: can't remove 1 CPUs from a VM with 1")
+	assert.Nil(t, results)
+	assert.Empty(t, updated)
+	assert.Equal(t, "shared-cpu-2x", machines[0].Config.Guest.ToSize())
+	assert.Equal(t, 2, machines[0].Config.Guest.CPUs)
+}
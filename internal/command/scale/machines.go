@@ -3,16 +3,77 @@ package scale
 import (
 	"context"
 	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/samber/lo"
 	fly "github.com/superfly/fly-go"
 	"github.com/superfly/fly-go/flaps"
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/flyutil"
 	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
 )
 
-func v2ScaleVM(ctx context.Context, appName, group, sizeName string, memoryMB int) (*fly.VMSize, error) {
+// scaleVMOptions describes how fly scale vm resizes the machines of a process group.
+type scaleVMOptions struct {
+	GuestTarget
+	// Regions restricts the resize to the machines in these regions, when there are any.
+	Regions       []string
+	CreateIfEmpty bool
+	MaxConcurrent int
+	// DryRun reports what would change instead of changing it.
+	DryRun bool
+	// RollbackOnFailure restores the machines that were updated when another one fails.
+	RollbackOnFailure bool
+	// Yes skips confirming the changes.
+	Yes bool
+	// LeaseTimeout bounds how long to wait for the leases on the machines, 0 waits indefinitely.
+	LeaseTimeout time.Duration
+}
+
+// changesGuest reports whether opts asks for any change to the guests of the machines.
+func (opts *scaleVMOptions) changesGuest() bool {
+	return opts.SizeName != "" ||
+		opts.MemoryMB != 0 || opts.MemoryDeltaMB != 0 ||
+		opts.CPUs != 0 || opts.CPUsDelta != 0 || opts.CPUKind != "" ||
+		opts.GPUs != 0 || opts.GPUKind != ""
+}
+
+// scaleVMResult is how the machines of a process group were, or in a dry run would be, resized.
+type scaleVMResult struct {
+	Group  string
+	DryRun bool `json:",omitempty"`
+	// Unchanged is set when every machine was already at the new size, so none was updated.
+	Unchanged bool `json:",omitempty"`
+	Machines  []MachineResult
+}
+
+// VMSize returns the size of the first machine that was resized, in the form v1 apps reported
+// sizes in, or nil when none were.
+func (r *scaleVMResult) VMSize() *fly.VMSize {
+	for _, m := range r.Machines {
+		if m.Error == "" {
+			return &fly.VMSize{
+				Name:     m.Guest.ToSize(),
+				MemoryMB: m.Guest.MemoryMB,
+				CPUCores: float32(m.Guest.CPUs),
+				CPUClass: m.Guest.CPUKind,
+			}
+		}
+	}
+	return nil
+}
+
+// v2ScaleVM resizes the machines of group, or creates one if the group is empty and
+// opts.CreateIfEmpty is set. When some machines fail to update, the result is returned
+// along with the error. The result is nil when the user declines the changes.
+func v2ScaleVM(ctx context.Context, appName, group string, opts scaleVMOptions) (*scaleVMResult, error) {
 	flapsClient, err := flapsutil.NewClientWithOptions(ctx, flaps.NewClientOpts{
 		AppName: appName,
 	})
@@ -22,64 +83,252 @@ func v2ScaleVM(ctx context.Context, appName, group, sizeName string, memoryMB in
 	ctx = flapsutil.NewContextWithClient(ctx, flapsClient)
 
 	// Quickly validate sizeName before any network call
-	if err := (&fly.MachineGuest{}).SetSize(sizeName); err != nil && sizeName != "" {
-		return nil, err
+	if opts.SizeName != "" {
+		if err := validateSizeName(opts.SizeName); err != nil {
+			return nil, err
+		}
+	}
+	if opts.CPUs < 0 {
+		return nil, fmt.Errorf("the number of CPUs must be positive, got %d", opts.CPUs)
+	}
+	if k := opts.CPUKind; k != "" && k != "shared" && k != "performance" {
+		return nil, fmt.Errorf("the CPU kind must be 'shared' or 'performance', got '%s'", k)
+	}
+	if opts.GPUs < 0 {
+		return nil, fmt.Errorf("the number of GPUs must be positive, got %d", opts.GPUs)
+	}
+	if k := opts.GPUKind; k != "" && k != "none" && !slices.Contains(mach.GPUKinds, k) {
+		return nil, fmt.Errorf("the GPU kind must be one of %s or 'none', got '%s'", strings.Join(mach.GPUKinds, ", "), k)
 	}
 
+	appConfig, err := appconfig.FromRemoteApp(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
 	if group == "" {
-		appConfig, err := appconfig.FromRemoteApp(ctx, appName)
-		if err != nil {
-			return nil, err
-		}
 		if len(appConfig.Processes) > 1 {
 			return nil, fmt.Errorf("scaling an app with multiple process groups requires specifying a group with '--process-group <name>'\n * this app has the following process groups: %v", appConfig.FormatProcessNames())
 		}
 		group = appConfig.DefaultProcessName()
 	}
 
-	machines, err := listMachinesWithGroup(ctx, group)
+	machines, err := listMachinesWithGroup(ctx, appConfig, group)
 	if err != nil {
 		return nil, err
 	}
 	if len(machines) == 0 {
-		return nil, fmt.Errorf("No active machines in process group '%s', check `fly status` output", group)
+		if !opts.CreateIfEmpty {
+			return nil, fmt.Errorf("No active machines in process group '%s', check `fly status` output or pass --create-if-empty to create one", group)
+		}
+		return v2ScaleVMEmptyGroup(ctx, appConfig, group, opts)
+	}
+	machines, err = filterMachinesByRegion(machines, group, opts.Regions)
+	if err != nil {
+		return nil, err
+	}
+
+	return scaleMachinesVM(ctx, group, machines, opts)
+}
+
+// confirm asks the user a yes/no question, it's replaced in tests.
+var confirm = prompt.Confirm
+
+// scaleMachinesVM resizes the machines of group, once the user confirms the changes unless
+// opts.Yes is set. It returns a nil result when the user declines.
+func scaleMachinesVM(ctx context.Context, group string, machines []*fly.Machine, opts scaleVMOptions) (*scaleVMResult, error) {
+	preview, err := previewMachinesVM(machines, opts.GuestTarget)
+	if err != nil {
+		return nil, err
+	}
+	plan := &scaleVMResult{Group: group, DryRun: opts.DryRun, Machines: preview}
+	if opts.DryRun {
+		return plan, nil
 	}
 
-	machines, releaseFunc, err := mach.AcquireLeases(ctx, machines)
+	// Machines already at the new size are neither leased nor updated, so they don't restart
+	machines = lo.Filter(machines, func(_ *fly.Machine, i int) bool {
+		return !preview[i].Unchanged
+	})
+	if len(machines) == 0 {
+		plan.Unchanged = true
+		return plan, nil
+	}
+	if !opts.Yes {
+		switch confirmed, err := confirmScaleVM(ctx, plan); {
+		case err != nil:
+			return nil, err
+		case !confirmed:
+			return nil, nil
+		}
+	}
+
+	machines, releaseFunc, err := mach.AcquireLeasesWithTimeout(ctx, machines, opts.LeaseTimeout)
 	defer releaseFunc()
 	if err != nil {
 		return nil, err
 	}
 
+	results, err := ApplyGuestConfig(ctx, machines, opts.GuestTarget, GuestUpdateOptions{
+		MaxConcurrent:     opts.MaxConcurrent,
+		RollbackOnFailure: opts.RollbackOnFailure,
+	})
+	if results == nil {
+		return nil, err
+	}
+	updated := lo.SliceToMap(results, func(r MachineResult) (string, MachineResult) {
+		return r.ID, r
+	})
+	for i, m := range preview {
+		if !m.Unchanged {
+			preview[i] = updated[m.ID]
+		}
+	}
+	return &scaleVMResult{Group: group, Machines: preview}, err
+}
+
+// confirmScaleVM shows the changes planned for the machines of a group and asks whether to
+// make them. Without a terminal to ask on, the changes are made without asking, as they
+// were before there was a prompt.
+func confirmScaleVM(ctx context.Context, plan *scaleVMResult) (bool, error) {
+	io := iostreams.FromContext(ctx)
+	if !io.IsInteractive() {
+		return true, nil
+	}
+
+	if err := renderScaleVMResult(io.Out, plan); err != nil {
+		return false, err
+	}
+	resized := lo.CountBy(plan.Machines, func(m MachineResult) bool { return !m.Unchanged })
+	switch confirmed, err := confirm(ctx, fmt.Sprintf("Resize %d machines in process group '%s'?", resized, plan.Group)); {
+	case err == nil:
+		return confirmed, nil
+	case prompt.IsNonInteractive(err):
+		return true, nil
+	default:
+		return false, err
+	}
+}
+
+// previewMachinesVM returns the size and memory each machine has and would be updated to,
+// without touching any of them. Machines that already have the size are marked Unchanged.
+func previewMachinesVM(machines []*fly.Machine, target GuestTarget) ([]MachineResult, error) {
+	results := make([]MachineResult, 0, len(machines))
 	for _, machine := range machines {
-		if sizeName != "" {
-			machine.Config.Guest.SetSize(sizeName)
+		guest := *machine.Config.Guest
+		if err := applyGuestChanges(&guest, target); err != nil {
+			return nil, fmt.Errorf("machine %s: %w", machine.ID, err)
 		}
-		if memoryMB > 0 {
-			machine.Config.Guest.MemoryMB = memoryMB
+		if err := validateGuest(&guest, target); err != nil {
+			return nil, err
 		}
+		result := newMachineResult(machine.ID, machine.Region, machine.Config.Guest, &guest)
+		result.Unchanged = sameGuestSize(machine.Config.Guest, &guest)
+		results = append(results, result)
+	}
+	return results, nil
+}
 
-		input := &fly.LaunchMachineInput{
-			Name:   machine.Name,
-			Region: machine.Region,
-			Config: machine.Config,
+// renderScaleVMResult prints a table of the machines of result with their old and new sizes.
+func renderScaleVMResult(w io.Writer, result *scaleVMResult) error {
+	rows := make([][]string, 0, len(result.Machines))
+	for _, m := range result.Machines {
+		row := []string{
+			lo.Ternary(m.ID != "", m.ID, "(new)"),
+			m.Region,
+			lo.Ternary(m.OldSize != "", m.OldSize, "-"),
+			lo.Ternary(m.OldMemory != 0, fmt.Sprintf("%d MB", m.OldMemory), "-"),
+			m.NewSize,
+			fmt.Sprintf("%d MB", m.NewMemory),
 		}
-		if err := mach.Update(ctx, machine, input); err != nil {
-			return nil, err
+		switch {
+		case m.Error != "":
+			row[4], row[5] = "not updated", "-"
+		case m.RolledBack:
+			row[4], row[5] = "rolled back", "-"
+		case m.Unchanged:
+			row[4], row[5] = "already at target", "-"
 		}
+		rows = append(rows, row)
 	}
 
-	// Return fly.VMSize to remain compatible with v1 scale app signature
-	size := &fly.VMSize{
-		Name:     machines[0].Config.Guest.ToSize(),
-		MemoryMB: machines[0].Config.Guest.MemoryMB,
-		CPUCores: float32(machines[0].Config.Guest.CPUs),
+	if err := render.Table(w, "", rows, "Machine", "Region", "Size", "Memory", "New Size", "New Memory"); err != nil {
+		return err
+	}
+	if result.DryRun {
+		fmt.Fprintf(w, "Dry run, %d machines were left unchanged\n", len(result.Machines))
 	}
+	return nil
+}
+
+// v2ScaleVMEmptyGroup creates a machine at the requested size for a process group
+// that is declared in appConfig but doesn't have any machines yet.
+func v2ScaleVMEmptyGroup(ctx context.Context, appConfig *appconfig.Config, group string, opts scaleVMOptions) (*scaleVMResult, error) {
+	apiClient := flyutil.ClientFromContext(ctx)
+	io := iostreams.FromContext(ctx)
 
-	return size, nil
+	guest := &fly.MachineGuest{}
+	guest.SetSize(fly.DefaultVMSize)
+	if err := applyGuestChanges(guest, opts.GuestTarget); err != nil {
+		return nil, err
+	}
+	if err := validateGuest(guest, opts.GuestTarget); err != nil {
+		return nil, err
+	}
+	// The machine goes in the first of the regions it's restricted to, if any
+	region := appConfig.PrimaryRegion
+	if len(opts.Regions) > 0 {
+		region = opts.Regions[0]
+	}
+	if opts.DryRun {
+		return &scaleVMResult{
+			Group:    group,
+			DryRun:   true,
+			Machines: []MachineResult{newMachineResult("", region, nil, guest)},
+		}, nil
+	}
+
+	var latestCompleteRelease fly.Release
+	switch releases, err := apiClient.GetAppReleasesMachines(ctx, appConfig.AppName, "complete", 1); {
+	case err != nil:
+		return nil, err
+	case len(releases) == 0:
+		return nil, fmt.Errorf("this app has no complete releases. Run `fly deploy` to create one and rerun this command")
+	default:
+		latestCompleteRelease = releases[0]
+	}
+
+	defaults := newDefaults(appConfig, latestCompleteRelease, nil, nil, "", false, guest)
+	m, err := launchMachineForEmptyGroup(ctx, defaults, group, region, guest)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(io.Out, "Created %s group:%s region:%s size:%s\n", m.ID, group, m.Region, m.Config.Guest.ToSize())
+
+	return &scaleVMResult{
+		Group:    group,
+		Machines: []MachineResult{newMachineResult(m.ID, m.Region, nil, m.Config.Guest)},
+	}, nil
+}
+
+func launchMachineForEmptyGroup(ctx context.Context, defaults *defaultValues, group, region string, guest *fly.MachineGuest) (*fly.Machine, error) {
+	flapsClient := flapsutil.ClientFromContext(ctx)
+
+	mConfig, err := defaults.ToMachineConfig(group)
+	if err != nil {
+		return nil, err
+	}
+	if len(mConfig.Mounts) > 0 {
+		return nil, fmt.Errorf("process group '%s' mounts a volume, use `fly scale count` to create its machines", group)
+	}
+	mConfig.Guest = guest
+
+	return flapsClient.Launch(ctx, fly.LaunchMachineInput{Region: region, Config: mConfig})
 }
 
-func listMachinesWithGroup(ctx context.Context, group string) ([]*fly.Machine, error) {
+// listMachinesWithGroup returns the active machines of group. It fails when group isn't one of
+// the process groups of appConfig, unless some machines still run it, so that a mistyped group
+// isn't reported as an empty one.
+func listMachinesWithGroup(ctx context.Context, appConfig *appconfig.Config, group string) ([]*fly.Machine, error) {
 	machines, err := mach.ListActive(ctx)
 	if err != nil {
 		return nil, err
@@ -88,6 +337,26 @@ func listMachinesWithGroup(ctx context.Context, group string) ([]*fly.Machine, e
 	machines = lo.Filter(machines, func(m *fly.Machine, _ int) bool {
 		return m.ProcessGroup() == group
 	})
+	if len(machines) == 0 && !slices.Contains(appConfig.ProcessNames(), group) {
+		return nil, fmt.Errorf("process group '%s' is not defined in the app config\n * this app has the following process groups: %v", group, appConfig.FormatProcessNames())
+	}
 
 	return machines, nil
 }
+
+// filterMachinesByRegion returns the machines of group that are in one of regions, or all of
+// them when there are no regions. It fails when none of the machines are in those regions.
+func filterMachinesByRegion(machines []*fly.Machine, group string, regions []string) ([]*fly.Machine, error) {
+	if len(regions) == 0 {
+		return machines, nil
+	}
+	filtered := lo.Filter(machines, func(m *fly.Machine, _ int) bool {
+		return slices.Contains(regions, m.Region)
+	})
+	if len(filtered) == 0 {
+		inRegions := lo.Uniq(lo.Map(machines, func(m *fly.Machine, _ int) string { return m.Region }))
+		slices.Sort(inRegions)
+		return nil, fmt.Errorf("No active machines of process group '%s' in regions %s\n * this group has machines in: %s", group, strings.Join(regions, ", "), strings.Join(inRegions, ", "))
+	}
+	return filtered, nil
+}
@@ -0,0 +1,717 @@
+package scale
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/flapsutil"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/mock"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func Test_launchMachineForEmptyGroup(t *testing.T) {
+	var launched []fly.LaunchMachineInput
+	flapsClient := &mock.FlapsClient{
+		LaunchFunc: func(ctx context.Context, input fly.LaunchMachineInput) (*fly.Machine, error) {
+			launched = append(launched, input)
+			return &fly.Machine{ID: "m1", Region: input.Region, Config: input.Config}, nil
+		},
+	}
+	ctx := flapsutil.NewContextWithClient(context.Background(), flapsClient)
+
+	cfg := appconfig.NewConfig()
+	cfg.AppName = "my-app"
+	cfg.PrimaryRegion = "iad"
+	cfg.Processes = map[string]string{"app": "", "worker": "./worker"}
+	require.NoError(t, cfg.SetMachinesPlatform())
+
+	guest := &fly.MachineGuest{}
+	require.NoError(t, guest.SetSize("performance-2x"))
+
+	defaults := newDefaults(cfg, fly.Release{ID: "rel", Version: 3, ImageRef: "registry.fly.io/my-app:v3"}, nil, nil, "", false, guest)
+	m, err := launchMachineForEmptyGroup(ctx, defaults, "worker", "iad", guest)
+	require.NoError(t, err)
+
+	require.Len(t, launched, 1)
+	assert.Equal(t, "iad", launched[0].Region)
+	assert.Equal(t, "registry.fly.io/my-app:v3", launched[0].Config.Image)
+	assert.Equal(t, "worker", launched[0].Config.Metadata[fly.MachineConfigMetadataKeyFlyProcessGroup])
+	assert.Equal(t, "performance-2x", m.Config.Guest.ToSize())
+}
+
+func TestApplyGuestConfig(t *testing.T) {
+	var updated []fly.LaunchMachineInput
+	flapsClient := &mock.FlapsClient{
+		UpdateFunc: func(ctx context.Context, input fly.LaunchMachineInput, nonce string) (*fly.Machine, error) {
+			updated = append(updated, input)
+			return &fly.Machine{ID: input.ID, Config: input.Config}, nil
+		},
+		WaitFunc: func(ctx context.Context, machine *fly.Machine, state string, timeout time.Duration) error {
+			return nil
+		},
+	}
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+	ctx = flapsutil.NewContextWithClient(ctx, flapsClient)
+
+	newMachines := func() []*fly.Machine {
+		var machines []*fly.Machine
+		for _, id := range []string{"m1", "m2"} {
+			guest := &fly.MachineGuest{}
+			require.NoError(t, guest.SetSize("shared-cpu-1x"))
+			guest.MemoryMB = 1024
+			machines = append(machines, &fly.Machine{ID: id, Region: "iad", Config: &fly.MachineConfig{Guest: guest}})
+		}
+		return machines
+	}
+
+	results, err := ApplyGuestConfig(ctx, newMachines(), GuestTarget{SizeName: "performance-4x"}, GuestUpdateOptions{MaxConcurrent: 1})
+	require.NoError(t, err)
+	require.Len(t, updated, 2)
+	assert.Equal(t, []MachineResult{
+		{ID: "m1", Region: "iad", OldSize: "shared-cpu-1x", OldMemory: 1024, NewSize: "performance-4x", NewMemory: 8192, Guest: updated[0].Config.Guest},
+		{ID: "m2", Region: "iad", OldSize: "shared-cpu-1x", OldMemory: 1024, NewSize: "performance-4x", NewMemory: 8192, Guest: updated[1].Config.Guest},
+	}, results)
+	for i, input := range updated {
+		assert.Equal(t, []string{"m1", "m2"}[i], input.ID)
+		assert.Equal(t, "performance", input.Config.Guest.CPUKind)
+		assert.Equal(t, 4, input.Config.Guest.CPUs)
+		assert.Equal(t, 8192, input.Config.Guest.MemoryMB)
+	}
+
+	// Changing only the memory leaves the CPUs alone
+	updated = nil
+	_, err = ApplyGuestConfig(ctx, newMachines(), GuestTarget{MemoryMB: 2048}, GuestUpdateOptions{MaxConcurrent: 1})
+	require.NoError(t, err)
+	require.Len(t, updated, 2)
+	for _, input := range updated {
+		assert.Equal(t, "shared", input.Config.Guest.CPUKind)
+		assert.Equal(t, 1, input.Config.Guest.CPUs)
+		assert.Equal(t, 2048, input.Config.Guest.MemoryMB)
+	}
+
+	// Changing only the CPUs leaves the kind and memory alone
+	updated = nil
+	_, err = ApplyGuestConfig(ctx, newMachines(), GuestTarget{CPUs: 4}, GuestUpdateOptions{MaxConcurrent: 1})
+	require.NoError(t, err)
+	require.Len(t, updated, 2)
+	for _, input := range updated {
+		assert.Equal(t, "shared", input.Config.Guest.CPUKind)
+		assert.Equal(t, 4, input.Config.Guest.CPUs)
+		assert.Equal(t, 1024, input.Config.Guest.MemoryMB)
+	}
+
+	// The CPUs override the ones of the size, which still sets the kind
+	updated = nil
+	_, err = ApplyGuestConfig(ctx, newMachines(), GuestTarget{SizeName: "performance-1x", MemoryMB: 12288, CPUs: 6}, GuestUpdateOptions{MaxConcurrent: 1})
+	require.NoError(t, err)
+	require.Len(t, updated, 2)
+	for _, input := range updated {
+		assert.Equal(t, "performance", input.Config.Guest.CPUKind)
+		assert.Equal(t, 6, input.Config.Guest.CPUs)
+		assert.Equal(t, 12288, input.Config.Guest.MemoryMB)
+	}
+
+	// No machine is touched when the CPUs aren't valid for the kind
+	updated = nil
+	_, err = ApplyGuestConfig(ctx, newMachines(), GuestTarget{SizeName: "shared-cpu-1x", CPUs: 16}, GuestUpdateOptions{MaxConcurrent: 1})
+	var invalidErr mach.InvalidConfigErr
+	require.ErrorAs(t, err, &invalidErr)
+	assert.Equal(t, "For the CPU kind shared, 16 CPUs is not valid", invalidErr.Description())
+	assert.Empty(t, updated)
+
+	_, err = ApplyGuestConfig(ctx, newMachines(), GuestTarget{SizeName: "performance-1x", CPUs: 2}, GuestUpdateOptions{MaxConcurrent: 1})
+	require.ErrorAs(t, err, &invalidErr)
+	assert.Equal(t, "For performance VMs with 2 CPUs, 2048MiB of memory is too low", invalidErr.Description())
+	assert.Empty(t, updated)
+}
+
+func TestApplyGuestConfigCPUKind(t *testing.T) {
+	var updated []fly.LaunchMachineInput
+	flapsClient := &mock.FlapsClient{
+		UpdateFunc: func(ctx context.Context, input fly.LaunchMachineInput, nonce string) (*fly.Machine, error) {
+			updated = append(updated, input)
+			return &fly.Machine{ID: input.ID, Config: input.Config}, nil
+		},
+		WaitFunc: func(ctx context.Context, machine *fly.Machine, state string, timeout time.Duration) error {
+			return nil
+		},
+	}
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+	ctx = flapsutil.NewContextWithClient(ctx, flapsClient)
+
+	newMachine := func() []*fly.Machine {
+		guest := &fly.MachineGuest{}
+		require.NoError(t, guest.SetSize("shared-cpu-2x"))
+		guest.MemoryMB = 4096
+		return []*fly.Machine{{ID: "m1", Region: "iad", Config: &fly.MachineConfig{Guest: guest}}}
+	}
+
+	// Changing only the kind keeps the CPUs and memory
+	_, err := ApplyGuestConfig(ctx, newMachine(), GuestTarget{CPUKind: "performance"}, GuestUpdateOptions{MaxConcurrent: 1})
+	require.NoError(t, err)
+	require.Len(t, updated, 1)
+	assert.Equal(t, &fly.MachineGuest{CPUKind: "performance", CPUs: 2, MemoryMB: 4096}, updated[0].Config.Guest)
+
+	// The kind applies on top of a size too
+	updated = nil
+	_, err = ApplyGuestConfig(ctx, newMachine(), GuestTarget{SizeName: "shared-cpu-4x", CPUKind: "performance", MemoryMB: 8192}, GuestUpdateOptions{MaxConcurrent: 1})
+	require.NoError(t, err)
+	require.Len(t, updated, 1)
+	assert.Equal(t, &fly.MachineGuest{CPUKind: "performance", CPUs: 4, MemoryMB: 8192}, updated[0].Config.Guest)
+
+	// Counts and memory must be valid for the new kind
+	updated = nil
+	var invalidErr mach.InvalidConfigErr
+	_, err = ApplyGuestConfig(ctx, newMachine(), GuestTarget{CPUKind: "shared", CPUs: 10}, GuestUpdateOptions{MaxConcurrent: 1})
+	require.ErrorAs(t, err, &invalidErr)
+	assert.Equal(t, "For the CPU kind shared, 10 CPUs is not valid", invalidErr.Description())
+
+	_, err = ApplyGuestConfig(ctx, newMachine(), GuestTarget{CPUKind: "performance", CPUs: 4}, GuestUpdateOptions{MaxConcurrent: 1})
+	require.ErrorAs(t, err, &invalidErr)
+	assert.Equal(t, "For performance VMs with 4 CPUs, 4096MiB of memory is too low", invalidErr.Description())
+	assert.Empty(t, updated)
+}
+
+func TestApplyGuestConfigGPUs(t *testing.T) {
+	var updated []fly.LaunchMachineInput
+	flapsClient := &mock.FlapsClient{
+		UpdateFunc: func(ctx context.Context, input fly.LaunchMachineInput, nonce string) (*fly.Machine, error) {
+			updated = append(updated, input)
+			return &fly.Machine{ID: input.ID, Config: input.Config}, nil
+		},
+		WaitFunc: func(ctx context.Context, machine *fly.Machine, state string, timeout time.Duration) error {
+			return nil
+		},
+	}
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+	ctx = flapsutil.NewContextWithClient(ctx, flapsClient)
+
+	newMachine := func(size string) []*fly.Machine {
+		guest := &fly.MachineGuest{}
+		require.NoError(t, guest.SetSize(size))
+		return []*fly.Machine{{ID: "m1", Region: "ord", Config: &fly.MachineConfig{Guest: guest}}}
+	}
+
+	_, err := ApplyGuestConfig(ctx, newMachine("a100-40gb"), GuestTarget{GPUs: 2, GPUKind: "a100-sxm4-80gb"}, GuestUpdateOptions{MaxConcurrent: 1})
+	require.NoError(t, err)
+	require.Len(t, updated, 1)
+	assert.Equal(t, "a100-sxm4-80gb", updated[0].Config.Guest.GPUKind)
+	assert.Equal(t, 2, updated[0].Config.Guest.GPUs)
+	assert.Equal(t, 8, updated[0].Config.Guest.CPUs)
+
+	// A GPU kind attaches a GPU to a machine without any
+	updated = nil
+	_, err = ApplyGuestConfig(ctx, newMachine("performance-8x"), GuestTarget{GPUKind: "l40s"}, GuestUpdateOptions{MaxConcurrent: 1})
+	require.NoError(t, err)
+	require.Len(t, updated, 1)
+	assert.Equal(t, "l40s", updated[0].Config.Guest.GPUKind)
+	assert.Equal(t, 1, updated[0].Config.Guest.GPUs)
+
+	// And "none" detaches them
+	updated = nil
+	_, err = ApplyGuestConfig(ctx, newMachine("a100-40gb"), GuestTarget{GPUKind: "none"}, GuestUpdateOptions{MaxConcurrent: 1})
+	require.NoError(t, err)
+	require.Len(t, updated, 1)
+	assert.Empty(t, updated[0].Config.Guest.GPUKind)
+	assert.Zero(t, updated[0].Config.Guest.GPUs)
+
+	updated = nil
+	var invalidErr mach.InvalidConfigErr
+	_, err = ApplyGuestConfig(ctx, newMachine("performance-8x"), GuestTarget{GPUKind: "h100"}, GuestUpdateOptions{MaxConcurrent: 1})
+	require.ErrorAs(t, err, &invalidErr)
+	assert.Equal(t, "The GPU kind given: h100, is not valid", invalidErr.Description())
+
+	_, err = ApplyGuestConfig(ctx, newMachine("performance-8x"), GuestTarget{GPUs: 2}, GuestUpdateOptions{MaxConcurrent: 1})
+	require.ErrorAs(t, err, &invalidErr)
+	assert.Equal(t, "2 GPUs were requested without a GPU kind", invalidErr.Description())
+
+	_, err = ApplyGuestConfig(ctx, newMachine("shared-cpu-8x"), GuestTarget{GPUKind: "a10"}, GuestUpdateOptions{MaxConcurrent: 1})
+	require.ErrorAs(t, err, &invalidErr)
+	assert.Equal(t, "GPUs can't be attached to shared CPUs", invalidErr.Description())
+	assert.Empty(t, updated)
+}
+
+func TestApplyGuestConfigConcurrently(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		active    int
+		maxActive int
+		updated   []string
+	)
+	flapsClient := &mock.FlapsClient{
+		UpdateFunc: func(ctx context.Context, input fly.LaunchMachineInput, nonce string) (*fly.Machine, error) {
+			mu.Lock()
+			active++
+			maxActive = max(maxActive, active)
+			updated = append(updated, input.ID)
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			active--
+			mu.Unlock()
+			if input.ID == "m2" || input.ID == "m5" {
+				return nil, fmt.Errorf("boom")
+			}
+			return &fly.Machine{ID: input.ID, Config: input.Config}, nil
+		},
+		WaitFunc: func(ctx context.Context, machine *fly.Machine, state string, timeout time.Duration) error {
+			return nil
+		},
+	}
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+	ctx = flapsutil.NewContextWithClient(ctx, flapsClient)
+
+	var machines []*fly.Machine
+	for i := 1; i <= 6; i++ {
+		guest := &fly.MachineGuest{}
+		require.NoError(t, guest.SetSize("shared-cpu-1x"))
+		machines = append(machines, &fly.Machine{ID: fmt.Sprintf("m%d", i), Config: &fly.MachineConfig{Guest: guest}})
+	}
+
+	// Failures don't stop the other machines from being updated
+	results, err := ApplyGuestConfig(ctx, machines, GuestTarget{SizeName: "shared-cpu-2x"}, GuestUpdateOptions{MaxConcurrent: 3})
+	require.ErrorContains(t, err, "failed to update 2 of 6 machines (m2, m5):\n")
+	assert.ErrorContains(t, err, "could not update machine m2: boom")
+	assert.ErrorContains(t, err, "could not update machine m5: boom")
+	assert.ElementsMatch(t, []string{"m1", "m2", "m3", "m4", "m5", "m6"}, updated)
+	require.Len(t, results, 6)
+	for _, result := range results {
+		assert.Equal(t, "shared-cpu-2x", result.NewSize)
+		if result.ID == "m2" || result.ID == "m5" {
+			assert.Contains(t, result.Error, "boom")
+		} else {
+			assert.Empty(t, result.Error)
+		}
+	}
+	assert.Greater(t, maxActive, 1)
+	assert.LessOrEqual(t, maxActive, 3)
+}
+
+func TestApplyGuestConfigRollbackOnFailure(t *testing.T) {
+	type update struct {
+		ID   string
+		Size string
+	}
+	var updates []update
+	flapsClient := &mock.FlapsClient{
+		UpdateFunc: func(ctx context.Context, input fly.LaunchMachineInput, nonce string) (*fly.Machine, error) {
+			updates = append(updates, update{ID: input.ID, Size: input.Config.Guest.ToSize()})
+			if input.ID == "m3" {
+				return nil, fmt.Errorf("boom")
+			}
+			return &fly.Machine{ID: input.ID, Config: input.Config}, nil
+		},
+		WaitFunc: func(ctx context.Context, machine *fly.Machine, state string, timeout time.Duration) error {
+			return nil
+		},
+	}
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+	ctx = flapsutil.NewContextWithClient(ctx, flapsClient)
+
+	var machines []*fly.Machine
+	for i := 1; i <= 4; i++ {
+		guest := &fly.MachineGuest{}
+		require.NoError(t, guest.SetSize("shared-cpu-1x"))
+		machines = append(machines, &fly.Machine{ID: fmt.Sprintf("m%d", i), Config: &fly.MachineConfig{Guest: guest}})
+	}
+
+	results, err := ApplyGuestConfig(ctx, machines, GuestTarget{SizeName: "shared-cpu-2x"}, GuestUpdateOptions{MaxConcurrent: 1, RollbackOnFailure: true})
+	require.ErrorContains(t, err, "failed to update 1 of 4 machines (m3)")
+
+	// m4 is never updated, and m1 and m2 go back to their old size
+	assert.Equal(t, []update{
+		{"m1", "shared-cpu-2x"},
+		{"m2", "shared-cpu-2x"},
+		{"m3", "shared-cpu-2x"},
+		{"m1", "shared-cpu-1x"},
+		{"m2", "shared-cpu-1x"},
+	}, updates)
+	for _, i := range []int{0, 1, 3} {
+		assert.Equal(t, "shared-cpu-1x", machines[i].Config.Guest.ToSize(), machines[i].ID)
+	}
+
+	require.Len(t, results, 4)
+	assert.True(t, results[0].RolledBack)
+	assert.True(t, results[1].RolledBack)
+	assert.Equal(t, "shared-cpu-1x", results[1].NewSize)
+	assert.Contains(t, results[2].Error, "boom")
+	assert.Equal(t, "skipped after another machine failed to update", results[3].Error)
+}
+
+func Test_previewMachinesVM(t *testing.T) {
+	var machines []*fly.Machine
+	for _, id := range []string{"m1", "m2"} {
+		guest := &fly.MachineGuest{}
+		require.NoError(t, guest.SetSize("shared-cpu-1x"))
+		machines = append(machines, &fly.Machine{ID: id, Region: "iad", Config: &fly.MachineConfig{Guest: guest}})
+	}
+
+	results, err := previewMachinesVM(machines, GuestTarget{SizeName: "shared-cpu-2x", MemoryMB: 1024})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for i, result := range results {
+		assert.Equal(t, machines[i].ID, result.ID)
+		assert.Equal(t, "shared-cpu-1x", result.OldSize)
+		assert.Equal(t, 256, result.OldMemory)
+		assert.Equal(t, "shared-cpu-2x", result.NewSize)
+		assert.Equal(t, 1024, result.NewMemory)
+	}
+	// The machines keep their guests
+	for _, machine := range machines {
+		assert.Equal(t, "shared-cpu-1x", machine.Config.Guest.ToSize())
+		assert.Equal(t, 256, machine.Config.Guest.MemoryMB)
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, renderScaleVMResult(&out, &scaleVMResult{Group: "app", DryRun: true, Machines: results}))
+	assert.Contains(t, out.String(), "m1")
+	assert.Contains(t, out.String(), "1024 MB")
+	assert.Contains(t, out.String(), "Dry run, 2 machines were left unchanged")
+
+	// A change that would fail to apply fails the preview too
+	_, err = previewMachinesVM(machines, GuestTarget{CPUs: 16})
+	var invalidErr mach.InvalidConfigErr
+	require.ErrorAs(t, err, &invalidErr)
+}
+
+func Test_previewMachinesVMDeltas(t *testing.T) {
+	newMachines := func(sizes ...string) []*fly.Machine {
+		var machines []*fly.Machine
+		for i, size := range sizes {
+			guest := &fly.MachineGuest{}
+			require.NoError(t, guest.SetSize(size))
+			machines = append(machines, &fly.Machine{ID: fmt.Sprintf("m%d", i+1), Config: &fly.MachineConfig{Guest: guest}})
+		}
+		return machines
+	}
+
+	// Each machine gets 512MB more than it has
+	results, err := previewMachinesVM(newMachines("shared-cpu-1x", "shared-cpu-2x"), GuestTarget{MemoryDeltaMB: 512})
+	require.NoError(t, err)
+	assert.Equal(t, 768, results[0].NewMemory)
+	assert.Equal(t, 1024, results[1].NewMemory)
+
+	// Dropping a CPU brings the memory down to what's left of them allows
+	machines := newMachines("performance-2x")
+	machines[0].Config.Guest.MemoryMB = 16384
+	results, err = previewMachinesVM(machines, GuestTarget{CPUsDelta: -1})
+	require.NoError(t, err)
+	assert.Equal(t, "performance-1x", results[0].NewSize)
+	assert.Equal(t, 8192, results[0].NewMemory)
+
+	_, err = previewMachinesVM(newMachines("shared-cpu-2x", "shared-cpu-1x"), GuestTarget{CPUsDelta: -1})
+	require.EqualError(t, err, "machine m2: can't remove 1 CPUs from a VM with 1")
+
+	_, err = previewMachinesVM(newMachines("shared-cpu-1x"), GuestTarget{MemoryDeltaMB: -256})
+	require.EqualError(t, err, "machine m1: can't remove 256MB of memory from a VM with 256MB")
+
+	// Results that aren't a valid size fail like an absolute change would
+	_, err = previewMachinesVM(newMachines("shared-cpu-4x"), GuestTarget{CPUsDelta: 1})
+	var invalidErr mach.InvalidConfigErr
+	require.ErrorAs(t, err, &invalidErr)
+}
+
+func Test_previewMachinesVMSizeMemory(t *testing.T) {
+	guest := &fly.MachineGuest{}
+	require.NoError(t, guest.SetSize("shared-cpu-1x"))
+	machines := []*fly.Machine{{ID: "m1", Config: &fly.MachineConfig{Guest: guest}}}
+
+	results, err := previewMachinesVM(machines, GuestTarget{SizeName: "performance-2x", MemoryMB: 8192})
+	require.NoError(t, err)
+	assert.Equal(t, "performance-2x", results[0].NewSize)
+	assert.Equal(t, 8192, results[0].NewMemory)
+
+	_, err = previewMachinesVM(machines, GuestTarget{SizeName: "shared-cpu-1x", MemoryMB: 4096})
+	require.ErrorContains(t, err, "4096MB of memory isn't valid for size 'shared-cpu-1x'\n * shared VMs with 1 CPU take between 256MB and 2048MB of memory, in increments of 256MB")
+
+	_, err = previewMachinesVM(machines, GuestTarget{SizeName: "performance-2x", MemoryMB: 5000})
+	require.ErrorContains(t, err, "performance VMs with 2 CPUs take between 4096MB and 16384MB of memory, in increments of 1024MB")
+
+	// A number of CPUs the kind doesn't have is reported as such
+	_, err = previewMachinesVM(machines, GuestTarget{SizeName: "shared-cpu-1x", CPUs: 16, MemoryMB: 256})
+	var invalidErr mach.InvalidConfigErr
+	require.ErrorAs(t, err, &invalidErr)
+	assert.Equal(t, "For the CPU kind shared, 16 CPUs is not valid", invalidErr.Description())
+}
+
+func Test_scaleVMResultVMSize(t *testing.T) {
+	small := &fly.MachineGuest{}
+	require.NoError(t, small.SetSize("shared-cpu-2x"))
+	large := &fly.MachineGuest{}
+	require.NoError(t, large.SetSize("performance-2x"))
+
+	result := &scaleVMResult{Machines: []MachineResult{
+		{ID: "m1", Error: "boom", Guest: small},
+		{ID: "m2", Guest: large},
+	}}
+	assert.Equal(t, &fly.VMSize{Name: "performance-2x", MemoryMB: 4096, CPUCores: 2, CPUClass: "performance"}, result.VMSize())
+
+	result.Machines = result.Machines[:1]
+	assert.Nil(t, result.VMSize())
+}
+
+func Test_scaleMachinesVMDeclined(t *testing.T) {
+	flapsClient := &mock.FlapsClient{
+		AcquireLeaseFunc: func(ctx context.Context, machineID string, ttl *int) (*fly.MachineLease, error) {
+			t.Errorf("acquired a lease on %s after the changes were declined", machineID)
+			return nil, fmt.Errorf("unexpected lease")
+		},
+		UpdateFunc: func(ctx context.Context, input fly.LaunchMachineInput, nonce string) (*fly.Machine, error) {
+			t.Errorf("updated %s after the changes were declined", input.ID)
+			return nil, fmt.Errorf("unexpected update")
+		},
+	}
+	ios, _, out, _ := iostreams.Test()
+	ios.SetStdinTTY(true)
+	ios.SetStdoutTTY(true)
+	ctx := iostreams.NewContext(context.Background(), ios)
+	ctx = flapsutil.NewContextWithClient(ctx, flapsClient)
+
+	var questions []string
+	defer func(orig func(context.Context, string) (bool, error)) { confirm = orig }(confirm)
+	confirm = func(ctx context.Context, message string) (bool, error) {
+		questions = append(questions, message)
+		return false, nil
+	}
+
+	var machines []*fly.Machine
+	for _, id := range []string{"m1", "m2"} {
+		guest := &fly.MachineGuest{}
+		require.NoError(t, guest.SetSize("shared-cpu-1x"))
+		machines = append(machines, &fly.Machine{ID: id, Region: "iad", Config: &fly.MachineConfig{Guest: guest}})
+	}
+
+	result, err := scaleMachinesVM(ctx, "web", machines, scaleVMOptions{GuestTarget: GuestTarget{SizeName: "shared-cpu-2x"}, MaxConcurrent: 1})
+	require.NoError(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, []string{"Resize 2 machines in process group 'web'?"}, questions)
+	assert.Contains(t, out.String(), "shared-cpu-2x")
+	for _, machine := range machines {
+		assert.Equal(t, "shared-cpu-1x", machine.Config.Guest.ToSize())
+	}
+}
+
+func Test_scaleMachinesVMLeaseTimeout(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		released []string
+	)
+	flapsClient := &mock.FlapsClient{
+		AcquireLeaseFunc: func(ctx context.Context, machineID string, ttl *int) (*fly.MachineLease, error) {
+			if machineID == "m2" || machineID == "m3" {
+				// Someone else holds these leases
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return &fly.MachineLease{Status: "success", Data: &fly.MachineLeaseData{Nonce: "nonce-" + machineID}}, nil
+		},
+		ReleaseLeaseFunc: func(ctx context.Context, machineID, nonce string) error {
+			lock.Lock()
+			defer lock.Unlock()
+			released = append(released, machineID)
+			return nil
+		},
+		UpdateFunc: func(ctx context.Context, input fly.LaunchMachineInput, nonce string) (*fly.Machine, error) {
+			t.Errorf("updated %s without leases on every machine", input.ID)
+			return nil, fmt.Errorf("unexpected update")
+		},
+	}
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+	ctx = flapsutil.NewContextWithClient(ctx, flapsClient)
+
+	var machines []*fly.Machine
+	for _, id := range []string{"m1", "m2", "m3", "m4"} {
+		guest := &fly.MachineGuest{}
+		require.NoError(t, guest.SetSize("shared-cpu-1x"))
+		machines = append(machines, &fly.Machine{ID: id, Region: "iad", HostStatus: fly.HostStatusOk, Config: &fly.MachineConfig{Guest: guest}})
+	}
+
+	result, err := scaleMachinesVM(ctx, "web", machines, scaleVMOptions{
+		GuestTarget:   GuestTarget{SizeName: "shared-cpu-2x"},
+		MaxConcurrent: 1,
+		Yes:           true,
+		LeaseTimeout:  50 * time.Millisecond,
+	})
+	assert.Nil(t, result)
+	require.ErrorContains(t, err, "timed out after 50ms waiting for leases on 2 of 4 machines: m2, m3")
+	assert.NotContains(t, err.Error(), "m1")
+	assert.ElementsMatch(t, []string{"m1", "m4"}, released)
+}
+
+func Test_listMachinesWithGroup(t *testing.T) {
+	newMachine := func(id, group string) *fly.Machine {
+		return &fly.Machine{ID: id, State: fly.MachineStateStarted, Config: &fly.MachineConfig{
+			Metadata: map[string]string{fly.MachineConfigMetadataKeyFlyProcessGroup: group},
+		}}
+	}
+	flapsClient := &mock.FlapsClient{
+		ListFunc: func(ctx context.Context, state string) ([]*fly.Machine, error) {
+			return []*fly.Machine{newMachine("m1", "web"), newMachine("m2", "web"), newMachine("m3", "legacy")}, nil
+		},
+	}
+	ctx := flapsutil.NewContextWithClient(context.Background(), flapsClient)
+
+	cfg := appconfig.NewConfig()
+	cfg.Processes = map[string]string{"web": "", "worker": "./worker"}
+	require.NoError(t, cfg.SetMachinesPlatform())
+
+	machines, err := listMachinesWithGroup(ctx, cfg, "web")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m1", "m2"}, lo.Map(machines, func(m *fly.Machine, _ int) string { return m.ID }))
+
+	// A group of the app without machines is empty, not unknown
+	machines, err = listMachinesWithGroup(ctx, cfg, "worker")
+	require.NoError(t, err)
+	assert.Empty(t, machines)
+
+	// A mistyped group lists the groups of the app
+	_, err = listMachinesWithGroup(ctx, cfg, "wrker")
+	require.EqualError(t, err, "process group 'wrker' is not defined in the app config\n * this app has the following process groups: ['web', 'worker']")
+
+	// Machines still running a group that was removed from the config can be scaled
+	machines, err = listMachinesWithGroup(ctx, cfg, "legacy")
+	require.NoError(t, err)
+	require.Len(t, machines, 1)
+	assert.Equal(t, "m3", machines[0].ID)
+}
+
+func Test_scaleMachinesVMInRegions(t *testing.T) {
+	var (
+		lock    sync.Mutex
+		updated []string
+	)
+	flapsClient := &mock.FlapsClient{
+		AcquireLeaseFunc: func(ctx context.Context, machineID string, ttl *int) (*fly.MachineLease, error) {
+			return &fly.MachineLease{Status: "success", Data: &fly.MachineLeaseData{Nonce: "nonce-" + machineID}}, nil
+		},
+		ReleaseLeaseFunc: func(ctx context.Context, machineID, nonce string) error {
+			return nil
+		},
+		UpdateFunc: func(ctx context.Context, input fly.LaunchMachineInput, nonce string) (*fly.Machine, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			updated = append(updated, input.ID)
+			return &fly.Machine{ID: input.ID, Config: input.Config}, nil
+		},
+		WaitFunc: func(ctx context.Context, machine *fly.Machine, state string, timeout time.Duration) error {
+			return nil
+		},
+	}
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+	ctx = flapsutil.NewContextWithClient(ctx, flapsClient)
+
+	var machines []*fly.Machine
+	for i, region := range []string{"iad", "lhr", "iad", "ord"} {
+		guest := &fly.MachineGuest{}
+		require.NoError(t, guest.SetSize("shared-cpu-1x"))
+		machines = append(machines, &fly.Machine{ID: fmt.Sprintf("m%d", i+1), Region: region, HostStatus: fly.HostStatusOk, Config: &fly.MachineConfig{Guest: guest}})
+	}
+
+	inRegions, err := filterMachinesByRegion(machines, "web", []string{"iad", "ord"})
+	require.NoError(t, err)
+	result, err := scaleMachinesVM(ctx, "web", inRegions, scaleVMOptions{GuestTarget: GuestTarget{SizeName: "shared-cpu-2x"}, MaxConcurrent: 1, Yes: true})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"m1", "m3", "m4"}, updated)
+	assert.ElementsMatch(t, []string{"m1", "m3", "m4"}, lo.Map(result.Machines, func(m MachineResult, _ int) string { return m.ID }))
+	assert.Equal(t, "shared-cpu-1x", machines[1].Config.Guest.ToSize())
+
+	all, err := filterMachinesByRegion(machines, "web", nil)
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+
+	_, err = filterMachinesByRegion(machines, "web", []string{"syd"})
+	require.EqualError(t, err, "No active machines of process group 'web' in regions syd\n * this group has machines in: iad, lhr, ord")
+}
+
+func Test_scaleMachinesVMSkipsMachinesAtTarget(t *testing.T) {
+	var (
+		lock            sync.Mutex
+		leased, updated []string
+	)
+	flapsClient := &mock.FlapsClient{
+		AcquireLeaseFunc: func(ctx context.Context, machineID string, ttl *int) (*fly.MachineLease, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			leased = append(leased, machineID)
+			return &fly.MachineLease{Status: "success", Data: &fly.MachineLeaseData{Nonce: "nonce-" + machineID}}, nil
+		},
+		ReleaseLeaseFunc: func(ctx context.Context, machineID, nonce string) error {
+			return nil
+		},
+		UpdateFunc: func(ctx context.Context, input fly.LaunchMachineInput, nonce string) (*fly.Machine, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			updated = append(updated, input.ID)
+			return &fly.Machine{ID: input.ID, Config: input.Config}, nil
+		},
+		WaitFunc: func(ctx context.Context, machine *fly.Machine, state string, timeout time.Duration) error {
+			return nil
+		},
+	}
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+	ctx = flapsutil.NewContextWithClient(ctx, flapsClient)
+
+	newMachines := func(sizes ...string) []*fly.Machine {
+		var machines []*fly.Machine
+		for i, size := range sizes {
+			guest := &fly.MachineGuest{}
+			require.NoError(t, guest.SetSize(size))
+			machines = append(machines, &fly.Machine{ID: fmt.Sprintf("m%d", i+1), Region: "iad", HostStatus: fly.HostStatusOk, Config: &fly.MachineConfig{Guest: guest}})
+		}
+		return machines
+	}
+
+	machines := newMachines("shared-cpu-2x", "shared-cpu-1x", "shared-cpu-2x", "performance-1x")
+	// Same CPUs, different memory
+	machines[2].Config.Guest.MemoryMB = 1024
+	result, err := scaleMachinesVM(ctx, "web", machines, scaleVMOptions{GuestTarget: GuestTarget{SizeName: "shared-cpu-2x"}, MaxConcurrent: 1, Yes: true})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"m2", "m3", "m4"}, leased)
+	assert.ElementsMatch(t, []string{"m2", "m3", "m4"}, updated)
+	assert.False(t, result.Unchanged)
+	require.Len(t, result.Machines, 4)
+	for i, m := range result.Machines {
+		assert.Equal(t, fmt.Sprintf("m%d", i+1), m.ID)
+		assert.Equal(t, "shared-cpu-2x", m.NewSize)
+		assert.Equal(t, 512, m.NewMemory)
+		assert.Equal(t, i == 0, m.Unchanged)
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, renderScaleVMResult(&out, result))
+	assert.Contains(t, out.String(), "already at target")
+
+	// Nothing is leased or updated when every machine is already at the target
+	leased, updated = nil, nil
+	result, err = scaleMachinesVM(ctx, "web", newMachines("shared-cpu-2x", "shared-cpu-2x"), scaleVMOptions{GuestTarget: GuestTarget{SizeName: "shared-cpu-2x"}, MaxConcurrent: 1, Yes: true})
+	require.NoError(t, err)
+	assert.Empty(t, leased)
+	assert.Empty(t, updated)
+	assert.True(t, result.Unchanged)
+	require.Len(t, result.Machines, 2)
+	assert.True(t, result.Machines[0].Unchanged)
+	assert.True(t, result.Machines[1].Unchanged)
+	assert.Equal(t, "shared-cpu-2x", result.VMSize().Name)
+}
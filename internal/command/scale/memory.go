@@ -3,9 +3,7 @@ package scale
 import (
 	"context"
 
-	"github.com/docker/go-units"
 	"github.com/spf13/cobra"
-	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/flag"
 )
@@ -24,6 +22,9 @@ func newScaleMemory() *cobra.Command {
 		flag.App(),
 		flag.AppConfig(),
 		flag.ProcessGroup("The process group to apply the VM size to"),
+		maxConcurrentFlag,
+		leaseTimeoutFlag,
+		flag.Yes(),
 	)
 	return cmd
 }
@@ -31,10 +32,10 @@ func newScaleMemory() *cobra.Command {
 func runScaleMemory(ctx context.Context) error {
 	group := flag.GetProcessGroup(ctx)
 
-	memoryMB, err := helpers.ParseSize(flag.FirstArg(ctx), units.RAMInBytes, units.MiB)
+	memoryMB, err := parseMemoryMB(flag.FirstArg(ctx))
 	if err != nil {
 		return err
 	}
 
-	return scaleVertically(ctx, group, "", memoryMB)
+	return scaleVertically(ctx, group, scaleVMOptions{GuestTarget: GuestTarget{MemoryMB: memoryMB}})
 }
@@ -0,0 +1,62 @@
+package scale
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+	fly "github.com/superfly/fly-go"
+)
+
+// validateSizeName checks that name is one of the machine presets. Otherwise the error suggests
+// the closest preset, when there's one close enough to be a typo, and lists all of them.
+func validateSizeName(name string) error {
+	if err := (&fly.MachineGuest{}).SetSize(name); err == nil {
+		return nil
+	}
+
+	sizes := lo.Keys(fly.MachinePresets)
+	slices.Sort(sizes)
+
+	msg := fmt.Sprintf("invalid VM size '%s'", name)
+	if closest := closestSizeName(name, sizes); closest != "" {
+		msg += fmt.Sprintf(", did you mean '%s'?", closest)
+	}
+	return fmt.Errorf("%s\n * valid sizes are: %s", msg, strings.Join(sizes, ", "))
+}
+
+// closestSizeName returns the size with the smallest edit distance to name, or "" when even
+// that one differs in more than a third of its characters.
+func closestSizeName(name string, sizes []string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	closest, best := "", 0
+	for _, size := range sizes {
+		d := editDistance(name, size)
+		if d <= len(size)/3 && (closest == "" || d < best) {
+			closest, best = size, d
+		}
+	}
+	return closest
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
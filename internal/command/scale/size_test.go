@@ -0,0 +1,36 @@
+package scale
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_validateSizeName(t *testing.T) {
+	assert.NoError(t, validateSizeName("shared-cpu-1x"))
+	assert.NoError(t, validateSizeName("performance-16x"))
+
+	err := validateSizeName("shared-1x")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid VM size 'shared-1x', did you mean 'shared-cpu-1x'?")
+
+	err = validateSizeName("preformance-2x")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did you mean 'performance-2x'?")
+
+	err = validateSizeName("gigantic")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "did you mean")
+	assert.Contains(t, err.Error(), "invalid VM size 'gigantic'\n * valid sizes are: ")
+	for _, size := range []string{"a100-40gb", "l40s", "performance-8x", "shared-cpu-4x"} {
+		assert.Contains(t, err.Error(), size)
+	}
+}
+
+func Test_editDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("l40s", "l40s"))
+	assert.Equal(t, 4, editDistance("shared-1x", "shared-cpu-1x"))
+	assert.Equal(t, 3, editDistance("", "a10"))
+	assert.Equal(t, 1, editDistance("shared-cpu-2x", "shared-cpu-1x"))
+}
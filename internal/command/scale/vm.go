@@ -3,15 +3,40 @@ package scale
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/docker/go-units"
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flag/completion"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/render"
 	"github.com/superfly/flyctl/iostreams"
 )
 
+// maxConcurrentFlag bounds how many machines are resized at once.
+var maxConcurrentFlag = flag.Int{
+	Name:        "max-concurrent",
+	Description: "Maximum number of machines to update concurrently",
+	Default:     8,
+}
+
+// leaseTimeoutFlag bounds how long to wait for the leases on the machines being resized.
+var leaseTimeoutFlag = flag.Duration{
+	Name:        "lease-timeout",
+	Description: "How long to wait for the leases on the machines before giving up, 0 to wait indefinitely",
+	Default:     30 * time.Second,
+}
+
 func newScaleVm() *cobra.Command {
 	const (
 		short = "Change an app's VM to a named size (eg. shared-cpu-1x, performance-1x, performance-2x...)"
@@ -19,8 +44,28 @@ func newScaleVm() *cobra.Command {
 
 For a full list of supported sizes use the command 'flyctl platform vm-sizes'
 
-Memory size can be set with --memory=number-of-MB
+Memory size can be set with --memory, in MB or with a unit
 e.g. flyctl scale vm shared-cpu-1x --memory=2048
+e.g. flyctl scale vm shared-cpu-1x --memory=2gb
+
+The number of CPUs can be set with --cpus, keeping the CPU kind of the size
+e.g. flyctl scale vm shared-cpu-1x --cpus=6 --memory=1536
+
+Memory and CPUs can also be changed relative to what each machine has
+e.g. flyctl scale vm --memory=+512mb
+e.g. flyctl scale vm --cpus=-1
+
+The CPU kind can be changed without a size with --cpu-kind
+e.g. flyctl scale vm --cpu-kind=performance --memory=4gb
+
+GPUs can be set with --gpus and --gpu-kind
+e.g. flyctl scale vm a100-40gb --gpus=2
+
+Several process groups can be resized at once with --process-group=web,worker,
+or every group with --all
+
+Only the machines in some regions can be resized with --region
+e.g. flyctl scale vm performance-2x --region=iad,lhr
 
 For pricing, see https://fly.io/docs/about/pricing/`
 	)
@@ -28,45 +73,376 @@ For pricing, see https://fly.io/docs/about/pricing/`
 		command.RequireSession,
 		command.RequireAppName,
 	)
-	cmd.Args = cobra.ExactArgs(1)
+	cmd.Args = cobra.MaximumNArgs(1)
 	flag.Add(cmd,
 		flag.App(),
 		flag.AppConfig(),
-		flag.Int{
+		flag.String{
 			Name:        "vm-memory",
-			Description: "Memory in MB for the VM",
-			Default:     0,
+			Description: "Memory for the VM, in MB or with a unit like 512mb or 2gb, or a change to each machine's memory like +512mb or -1gb",
 			Aliases:     []string{"memory"},
 		},
-		flag.ProcessGroup("The process group to apply the VM size to"),
+		flag.String{
+			Name:        "vm-cpus",
+			Description: "Number of CPUs for the VM, of the size's CPU kind, or a change to each machine's CPUs like +1 or -2",
+			Aliases:     []string{"cpus"},
+		},
+		flag.String{
+			Name:        "vm-cpu-kind",
+			Description: "The kind of CPU for the VM ('shared' or 'performance'), keeping the current number of CPUs and memory",
+			Aliases:     []string{"cpu-kind"},
+		},
+		flag.Int{
+			Name:        "vm-gpus",
+			Description: "Number of GPUs for the VM",
+			Aliases:     []string{"gpus"},
+		},
+		flag.String{
+			Name:        "vm-gpu-kind",
+			Description: fmt.Sprintf("The GPU model for the VM (%s), or 'none' to detach its GPUs", strings.Join(mach.GPUKinds, ", ")),
+			Aliases:     []string{"gpu-kind"},
+		},
+		flag.ProcessGroup("The process group to apply the VM size to, or several separated by commas"),
+		flag.Bool{
+			Name:        "all",
+			Description: "Apply the VM size to every process group of the app",
+		},
+		flag.String{
+			Name:         "region",
+			Shorthand:    "r",
+			Description:  "Comma separated list of regions whose machines to resize. Defaults to every region",
+			CompletionFn: completion.CompleteRegions,
+		},
+		flag.Bool{
+			Name:        "create-if-empty",
+			Description: "Create a machine at the requested size if the process group doesn't have any machines yet",
+		},
+		maxConcurrentFlag,
+		leaseTimeoutFlag,
+		flag.Bool{
+			Name:        "rollback-on-failure",
+			Description: "If a machine fails to update, put the machines already updated back to their previous size",
+		},
+		flag.Bool{
+			Name:        "dry-run",
+			Description: "Show the current and new size of each machine without changing them",
+		},
+		flag.JSONOutput(),
+		flag.Yes(),
+		flag.Bool{
+			Name:        "save",
+			Description: "Save the new VM size to the [[vm]] section of the app's fly.toml",
+		},
 	)
 	return cmd
 }
 
 func runScaleVM(ctx context.Context) error {
 	sizeName := flag.FirstArg(ctx)
-	memoryMB := flag.GetInt(ctx, "vm-memory")
-	group := flag.GetProcessGroup(ctx)
-	return scaleVertically(ctx, group, sizeName, memoryMB)
+	memoryMB, memoryDeltaMB, err := parseMemoryChange(flag.GetString(ctx, "vm-memory"))
+	if err != nil {
+		return err
+	}
+	cpus, cpusDelta, err := parseCPUsChange(flag.GetString(ctx, "vm-cpus"))
+	if err != nil {
+		return err
+	}
+	opts := scaleVMOptions{GuestTarget: GuestTarget{
+		SizeName:      sizeName,
+		MemoryMB:      memoryMB,
+		MemoryDeltaMB: memoryDeltaMB,
+		CPUs:          cpus,
+		CPUsDelta:     cpusDelta,
+		CPUKind:       flag.GetString(ctx, "vm-cpu-kind"),
+		GPUs:          flag.GetInt(ctx, "vm-gpus"),
+		GPUKind:       flag.GetString(ctx, "vm-gpu-kind"),
+	}}
+	if !opts.changesGuest() {
+		return fmt.Errorf("pass a size, or at least one of --vm-memory, --vm-cpus, --vm-cpu-kind, --vm-gpus and --vm-gpu-kind")
+	}
+	return scaleVertically(ctx, flag.GetProcessGroup(ctx), opts)
 }
 
-func scaleVertically(ctx context.Context, group, sizeName string, memoryMB int) error {
+// scaleVertically resizes the machines of group with the size, memory and CPUs in opts. The
+// rest of opts comes from the flags.
+func scaleVertically(ctx context.Context, group string, opts scaleVMOptions) error {
 	io := iostreams.FromContext(ctx)
 	appName := appconfig.NameFromContext(ctx)
 
-	size, err := v2ScaleVM(ctx, appName, group, sizeName, memoryMB)
+	// Check for a local config before scaling, so --save doesn't fail after the fact
+	localConfig := appconfig.ConfigFromContext(ctx)
+	save := flag.GetBool(ctx, "save")
+	if save && localConfig == nil {
+		return fmt.Errorf("--save requires a local fly.toml, use --config to point at it")
+	}
+
+	groups, err := scaleVMGroups(ctx, appName, group, flag.GetBool(ctx, "all"))
 	if err != nil {
 		return err
 	}
+	opts.Regions = splitList(flag.GetRegion(ctx))
+	opts.CreateIfEmpty = flag.GetBool(ctx, "create-if-empty")
+	opts.MaxConcurrent = flag.GetInt(ctx, "max-concurrent")
+	opts.DryRun = flag.GetBool(ctx, "dry-run")
+	opts.RollbackOnFailure = flag.GetBool(ctx, "rollback-on-failure")
+	opts.Yes = flag.GetYes(ctx)
+	opts.LeaseTimeout = flag.GetDuration(ctx, "lease-timeout")
+
+	jsonOutput := config.FromContext(ctx).JSONOutput
+	if jsonOutput {
+		// Keep the progress of the updates out of the JSON
+		progress := *io
+		progress.Out = io.ErrOut
+		ctx = iostreams.NewContext(ctx, &progress)
+	}
+
+	var (
+		results []*scaleVMResult
+		failed  []string
+		lastErr error
+	)
+	saved := false
+	for _, group := range groups {
+		if len(groups) > 1 && !jsonOutput {
+			fmt.Fprintf(io.Out, "Process group '%s'\n", group)
+		}
+
+		result, err := v2ScaleVM(ctx, appName, group, opts)
+		if result != nil {
+			results = append(results, result)
+			if !jsonOutput {
+				if err := renderScaleVMResult(io.Out, result); err != nil {
+					return err
+				}
+			}
+		}
+		switch {
+		case err != nil:
+			// Keep going so one group doesn't hold back the others
+			if len(groups) > 1 {
+				fmt.Fprintf(io.ErrOut, "Failed to scale VM for '%s': %v\n", group, err)
+			}
+			failed = append(failed, group)
+			lastErr = err
+			continue
+		case result == nil:
+			fmt.Fprintf(io.ErrOut, "Left process group '%s' unchanged\n", group)
+			continue
+		case result.DryRun:
+			continue
+		case result.Unchanged:
+			// Nothing was resized, so there's nothing to report or save either
+			fmt.Fprintf(io.ErrOut, "Left process group '%s' unchanged, its machines are already at the new size\n", group)
+			continue
+		}
+
+		size := result.VMSize()
+		if !jsonOutput {
+			if group == "" {
+				fmt.Fprintf(io.Out, "Scaled VM Type to '%s'\n", size.Name)
+			} else {
+				fmt.Fprintf(io.Out, "Scaled VM Type for '%s' to '%s'\n", group, size.Name)
+			}
+
+			fmt.Fprintf(io.Out, "%15s: %s\n", "CPU Cores", formatCores(*size))
+			fmt.Fprintf(io.Out, "%15s: %s\n", "Memory", formatMemory(*size))
+		}
+
+		if save {
+			if err := saveComputeForGroup(localConfig, group, size); err != nil {
+				return err
+			}
+			saved = true
+		}
+	}
+
+	if jsonOutput {
+		if err := render.JSON(io.Out, results); err != nil {
+			return err
+		}
+	}
+	if saved {
+		if err := localConfig.WriteToDisk(ctx, localConfig.ConfigFilePath()); err != nil {
+			return err
+		}
+	}
+	switch {
+	case len(groups) == 1:
+		return lastErr
+	case len(failed) > 0:
+		return fmt.Errorf("failed to scale process groups %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// scaleVMGroups returns the process groups to resize, given the value of --process-group,
+// which may list several comma separated groups, and --all. A single group, or none, is
+// passed through for v2ScaleVM to resolve.
+func scaleVMGroups(ctx context.Context, appName, requested string, all bool) ([]string, error) {
+	groups := splitList(requested)
+	if !all && len(groups) <= 1 {
+		return []string{requested}, nil
+	}
+
+	appConfig, err := appconfig.FromRemoteApp(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+	return selectProcessGroups(appConfig, groups, all)
+}
+
+// selectProcessGroups checks groups against the process groups of appConfig, returning all of
+// them when all is set.
+func selectProcessGroups(appConfig *appconfig.Config, groups []string, all bool) ([]string, error) {
+	if all {
+		if len(groups) > 0 {
+			return nil, fmt.Errorf("--all can't be used together with --process-group")
+		}
+		return appConfig.ProcessNames(), nil
+	}
+
+	for _, group := range groups {
+		if !slices.Contains(appConfig.ProcessNames(), group) {
+			return nil, fmt.Errorf("process group '%s' is not defined in the app config\n * this app has the following process groups: %v", group, appConfig.FormatProcessNames())
+		}
+	}
+	return lo.Uniq(groups), nil
+}
+
+// splitList splits a comma separated flag value, like the groups of --process-group or the
+// regions of --region, dropping blanks.
+func splitList(raw string) []string {
+	return lo.Compact(lo.Map(strings.Split(raw, ","), func(item string, _ int) string {
+		return strings.TrimSpace(item)
+	}))
+}
+
+// parseMemoryMB converts a memory size given as a number of megabytes or with a unit,
+// like 512mb or 2gb, into megabytes. An empty size is zero, which keeps the current memory.
+func parseMemoryMB(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	memoryMB, err := helpers.ParseSize(raw, units.RAMInBytes, units.MiB)
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("'%s' is not a valid memory size, use a number of MB or a size like 512mb or 2gb: %w", raw, err)
+	case memoryMB <= 0:
+		return 0, fmt.Errorf("memory must be at least 1MB, got '%s'", raw)
+	}
+	return memoryMB, nil
+}
 
+// parseMemoryChange parses the value of --vm-memory, which is either a memory size, see
+// parseMemoryMB, or a change to the memory of each machine when it starts with + or -.
+func parseMemoryChange(raw string) (memoryMB, deltaMB int, err error) {
+	sign, size, relative := cutSign(raw)
+	if !relative {
+		memoryMB, err = parseMemoryMB(raw)
+		return memoryMB, 0, err
+	}
+	if size == "" {
+		return 0, 0, fmt.Errorf("the change in memory must be a size like +512mb or -1gb, got '%s'", raw)
+	}
+	deltaMB, err = parseMemoryMB(size)
+	if err != nil {
+		return 0, 0, err
+	}
+	return 0, sign * deltaMB, nil
+}
+
+// parseCPUsChange parses the value of --vm-cpus, which is either a number of CPUs or a change
+// to the CPUs of each machine when it starts with + or -. An empty value is zero, which keeps
+// the current number of CPUs.
+func parseCPUsChange(raw string) (cpus, delta int, err error) {
+	if raw == "" {
+		return 0, 0, nil
+	}
+	sign, count, relative := cutSign(raw)
+	n, err := strconv.Atoi(count)
+	switch {
+	case err != nil:
+		return 0, 0, fmt.Errorf("'%s' is not a valid number of CPUs, use a number like 2 or a change like +1 or -1", raw)
+	case n <= 0 && relative:
+		return 0, 0, fmt.Errorf("the change in CPUs must be at least 1, got '%s'", raw)
+	case n <= 0:
+		return 0, 0, fmt.Errorf("the number of CPUs must be positive, got %d", n)
+	case relative:
+		return 0, sign * n, nil
+	default:
+		return n, 0, nil
+	}
+}
+
+// cutSign splits the leading + or - off raw, returning 1 or -1 for it and whether there was one.
+func cutSign(raw string) (sign int, rest string, ok bool) {
+	if rest, ok := strings.CutPrefix(raw, "+"); ok {
+		return 1, rest, true
+	}
+	if rest, ok := strings.CutPrefix(raw, "-"); ok {
+		return -1, rest, true
+	}
+	return 1, raw, false
+}
+
+// saveComputeForGroup records the scaled VM size in the compute section of cfg
+// for the given group, adding a new section when none apply only to that group.
+// Sizes without a preset, like shared-cpu-6x, are recorded by CPU kind and count.
+func saveComputeForGroup(cfg *appconfig.Config, group string, size *fly.VMSize) error {
 	if group == "" {
-		fmt.Fprintf(io.Out, "Scaled VM Type to '%s'\n", size.Name)
-	} else {
-		fmt.Fprintf(io.Out, "Scaled VM Type for '%s' to '%s'\n", group, size.Name)
+		group = cfg.DefaultProcessName()
+	}
+
+	guest := &fly.MachineGuest{}
+	preset := true
+	if err := guest.SetSize(size.Name); err != nil {
+		if size.CPUClass == "" {
+			return err
+		}
+		preset = false
 	}
 
-	fmt.Fprintf(io.Out, "%15s: %s\n", "CPU Cores", formatCores(*size))
-	fmt.Fprintf(io.Out, "%15s: %s\n", "Memory", formatMemory(*size))
+	compute := cfg.ComputeForGroup(group)
+	switch {
+	case compute == nil:
+		compute = &appconfig.Compute{}
+		if len(cfg.ProcessNames()) > 1 {
+			compute.Processes = []string{group}
+		}
+		cfg.Compute = append(cfg.Compute, compute)
+	case len(compute.Processes) == 0 && len(cfg.ProcessNames()) > 1,
+		len(compute.Processes) > 1:
+		// The matching section is shared with other groups, so split this group out of it
+		compute.Processes = slices.DeleteFunc(slices.Clone(compute.Processes), func(p string) bool {
+			return p == group
+		})
+		compute = helpers.Clone(compute)
+		compute.Processes = []string{group}
+		cfg.Compute = append(cfg.Compute, compute)
+	}
+
+	compute.Size = lo.Ternary(preset, size.Name, "")
+	compute.Memory = ""
+	if size.MemoryMB != guest.MemoryMB {
+		if size.MemoryMB%1024 == 0 {
+			compute.Memory = fmt.Sprintf("%dgb", size.MemoryMB/1024)
+		} else {
+			compute.Memory = fmt.Sprintf("%dmb", size.MemoryMB)
+		}
+	}
+	// The size and memory above describe these, so don't let stale values override them
+	if compute.MachineGuest != nil {
+		compute.CPUKind = ""
+		compute.CPUs = 0
+		compute.MemoryMB = 0
+	}
+	if !preset {
+		if compute.MachineGuest == nil {
+			compute.MachineGuest = &fly.MachineGuest{}
+		}
+		compute.CPUKind = size.CPUClass
+		compute.CPUs = int(size.CPUCores)
+	}
 	return nil
 }
 
@@ -0,0 +1,187 @@
+package scale
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestSaveComputeForGroup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fly.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+app = "my-app"
+primary_region = "ord"
+
+[env]
+  FOO = "bar"
+
+[[vm]]
+  size = "shared-cpu-1x"
+  memory = "512mb"
+`), 0o644))
+
+	cfg, err := appconfig.LoadConfig(path)
+	require.NoError(t, err)
+
+	require.NoError(t, saveComputeForGroup(cfg, "", &fly.VMSize{Name: "performance-2x", MemoryMB: 8192}))
+	require.NoError(t, cfg.WriteToFile(path))
+
+	cfg, err = appconfig.LoadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Compute, 1)
+	assert.Equal(t, "performance-2x", cfg.Compute[0].Size)
+	assert.Equal(t, "8gb", cfg.Compute[0].Memory)
+	assert.Equal(t, map[string]string{"FOO": "bar"}, cfg.Env)
+}
+
+func TestSaveComputeForGroupAddsCompute(t *testing.T) {
+	cfg := appconfig.NewConfig()
+	cfg.Processes = map[string]string{"web": "serve", "worker": "work"}
+	cfg.Compute = []*appconfig.Compute{{Size: "shared-cpu-1x"}}
+
+	// The default preset memory isn't written out
+	require.NoError(t, saveComputeForGroup(cfg, "worker", &fly.VMSize{Name: "shared-cpu-2x", MemoryMB: 512}))
+	require.Len(t, cfg.Compute, 2)
+	assert.Equal(t, &appconfig.Compute{Size: "shared-cpu-1x"}, cfg.Compute[0])
+	assert.Equal(t, &appconfig.Compute{Size: "shared-cpu-2x", Processes: []string{"worker"}}, cfg.Compute[1])
+
+	// Scaling the group again updates its own section
+	require.NoError(t, saveComputeForGroup(cfg, "worker", &fly.VMSize{Name: "shared-cpu-4x", MemoryMB: 1536}))
+	require.Len(t, cfg.Compute, 2)
+	assert.Equal(t, &appconfig.Compute{Size: "shared-cpu-4x", Memory: "1536mb", Processes: []string{"worker"}}, cfg.Compute[1])
+}
+
+func TestSaveComputeForGroupWithoutPreset(t *testing.T) {
+	cfg := appconfig.NewConfig()
+	cfg.Compute = []*appconfig.Compute{{Size: "shared-cpu-1x"}}
+
+	require.NoError(t, saveComputeForGroup(cfg, "", &fly.VMSize{Name: "performance-6x", CPUClass: "performance", CPUCores: 6, MemoryMB: 12288}))
+	require.Len(t, cfg.Compute, 1)
+	assert.Equal(t, &appconfig.Compute{
+		Memory:       "12gb",
+		MachineGuest: &fly.MachineGuest{CPUKind: "performance", CPUs: 6},
+	}, cfg.Compute[0])
+}
+
+func TestParseMemoryMB(t *testing.T) {
+	for raw, want := range map[string]int{
+		"":      0,
+		"512":   512,
+		"512mb": 512,
+		"2gb":   2048,
+		"2GB":   2048,
+		"1.5gb": 1536,
+	} {
+		got, err := parseMemoryMB(raw)
+		require.NoError(t, err, raw)
+		assert.Equal(t, want, got, raw)
+	}
+
+	_, err := parseMemoryMB("2xb")
+	require.ErrorContains(t, err, "'2xb' is not a valid memory size, use a number of MB or a size like 512mb or 2gb")
+	_, err = parseMemoryMB("lots")
+	require.ErrorContains(t, err, "'lots' is not a valid memory size")
+	_, err = parseMemoryMB("0")
+	require.ErrorContains(t, err, "memory must be at least 1MB, got '0'")
+	_, err = parseMemoryMB("100kb")
+	require.ErrorContains(t, err, "memory must be at least 1MB, got '100kb'")
+}
+
+func TestParseMemoryChange(t *testing.T) {
+	memoryMB, deltaMB, err := parseMemoryChange("2gb")
+	require.NoError(t, err)
+	assert.Equal(t, 2048, memoryMB)
+	assert.Zero(t, deltaMB)
+
+	memoryMB, deltaMB, err = parseMemoryChange("+512mb")
+	require.NoError(t, err)
+	assert.Zero(t, memoryMB)
+	assert.Equal(t, 512, deltaMB)
+
+	_, deltaMB, err = parseMemoryChange("-1gb")
+	require.NoError(t, err)
+	assert.Equal(t, -1024, deltaMB)
+
+	_, _, err = parseMemoryChange("+lots")
+	require.ErrorContains(t, err, "'lots' is not a valid memory size")
+	_, _, err = parseMemoryChange("+0mb")
+	require.ErrorContains(t, err, "memory must be at least 1MB, got '0mb'")
+	for _, raw := range []string{"+", "-"} {
+		_, _, err = parseMemoryChange(raw)
+		require.ErrorContains(t, err, fmt.Sprintf("the change in memory must be a size like +512mb or -1gb, got '%s'", raw))
+	}
+}
+
+func TestParseCPUsChange(t *testing.T) {
+	cpus, delta, err := parseCPUsChange("")
+	require.NoError(t, err)
+	assert.Zero(t, cpus)
+	assert.Zero(t, delta)
+
+	cpus, delta, err = parseCPUsChange("4")
+	require.NoError(t, err)
+	assert.Equal(t, 4, cpus)
+	assert.Zero(t, delta)
+
+	cpus, delta, err = parseCPUsChange("+1")
+	require.NoError(t, err)
+	assert.Zero(t, cpus)
+	assert.Equal(t, 1, delta)
+
+	_, delta, err = parseCPUsChange("-2")
+	require.NoError(t, err)
+	assert.Equal(t, -2, delta)
+
+	_, _, err = parseCPUsChange("0")
+	require.ErrorContains(t, err, "the number of CPUs must be positive, got 0")
+	_, _, err = parseCPUsChange("+0")
+	require.ErrorContains(t, err, "the change in CPUs must be at least 1, got '+0'")
+	_, _, err = parseCPUsChange("two")
+	require.ErrorContains(t, err, "'two' is not a valid number of CPUs")
+	_, _, err = parseCPUsChange("+")
+	require.ErrorContains(t, err, "'+' is not a valid number of CPUs")
+}
+
+func TestCutSign(t *testing.T) {
+	for raw, want := range map[string]struct {
+		sign int
+		rest string
+		ok   bool
+	}{
+		"+512mb": {1, "512mb", true},
+		"-1":     {-1, "1", true},
+		"+":      {1, "", true},
+		"-":      {-1, "", true},
+		"2gb":    {1, "2gb", false},
+	} {
+		sign, rest, ok := cutSign(raw)
+		assert.Equal(t, want.sign, sign, raw)
+		assert.Equal(t, want.rest, rest, raw)
+		assert.Equal(t, want.ok, ok, raw)
+	}
+}
+
+func TestSelectProcessGroups(t *testing.T) {
+	cfg := appconfig.NewConfig()
+	cfg.Processes = map[string]string{"web": "serve", "worker": "work", "cron": "tick"}
+
+	groups, err := selectProcessGroups(cfg, splitList("web, worker,web"), false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"web", "worker"}, groups)
+
+	groups, err = selectProcessGroups(cfg, nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cron", "web", "worker"}, groups)
+
+	_, err = selectProcessGroups(cfg, []string{"web", "wroker"}, false)
+	require.ErrorContains(t, err, "process group 'wroker' is not defined in the app config\n * this app has the following process groups: ['cron', 'web', 'worker']")
+
+	_, err = selectProcessGroups(cfg, []string{"web"}, true)
+	require.ErrorContains(t, err, "--all can't be used together with --process-group")
+}
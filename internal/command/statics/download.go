@@ -0,0 +1,78 @@
+package statics
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/deploy/statics"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newDownload() *cobra.Command {
+	const (
+		short = "Download a statics version from an app's bucket"
+		long  = `Download the statics of a version from an app's Tigris bucket into a local
+directory, keeping their paths. Each static is in a numbered directory, in the
+order they're listed in fly.toml.
+
+By default the version the app's machines serve is downloaded.`
+	)
+	cmd := command.New("download", short, long, runDownload,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+	cmd.Args = cobra.NoArgs
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Int{
+			Name:        "version",
+			Description: "The statics version to download, instead of the live one",
+		},
+		flag.String{
+			Name:        "dest",
+			Description: "The directory to download the statics to, statics-<version> by default",
+		},
+	)
+	return cmd
+}
+
+func runDownload(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		appName = appconfig.NameFromContext(ctx)
+		version = flag.GetInt(ctx, "version")
+		dest    = flag.GetString(ctx, "dest")
+	)
+
+	if version == 0 {
+		live, err := liveVersion(ctx, appName)
+		if err != nil {
+			return err
+		}
+		if live == 0 {
+			return fmt.Errorf("none of the machines of %s serve statics, pass --version to pick one from `fly statics list`", appName)
+		}
+		version = live
+	}
+	if dest == "" {
+		dest = fmt.Sprintf("statics-%d", version)
+	}
+
+	files, bytes, err := statics.DownloadVersion(ctx, appName, version, dest)
+	if err != nil {
+		return err
+	}
+	if files == 0 {
+		return fmt.Errorf("statics version %d doesn't exist for %s", version, appName)
+	}
+	fmt.Fprintf(io.Out, "Downloaded %s files (%s) of statics version %d to %s\n",
+		humanize.Comma(int64(files)), humanize.Bytes(uint64(bytes)), version, helpers.PathRelativeToCWD(dest))
+	return nil
+}
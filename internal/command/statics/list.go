@@ -0,0 +1,120 @@
+package statics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+	"github.com/superfly/fly-go/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/deploy/statics"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newList() *cobra.Command {
+	const (
+		short = "List the statics versions in an app's bucket"
+		long  = `List the statics versions stored in an app's Tigris bucket, with the number
+of objects and total size of each, and which version the app's machines serve.`
+	)
+	cmd := command.New("list", short, long, runList,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+	cmd.Aliases = []string{"ls"}
+	cmd.Args = cobra.NoArgs
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.JSONOutput(),
+	)
+	return cmd
+}
+
+// listedVersion is a statics version as shown by `fly statics list`.
+type listedVersion struct {
+	statics.VersionSummary
+	Live bool `json:"live"`
+}
+
+func runList(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		cfg     = config.FromContext(ctx)
+		appName = appconfig.NameFromContext(ctx)
+	)
+
+	summaries, err := statics.ListVersions(ctx, appName)
+	if err != nil {
+		return err
+	}
+	live, err := liveVersion(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	versions := make([]listedVersion, 0, len(summaries))
+	for _, summary := range summaries {
+		versions = append(versions, listedVersion{VersionSummary: summary, Live: summary.Version == live})
+	}
+
+	if cfg.JSONOutput {
+		return render.JSON(io.Out, versions)
+	}
+
+	if len(versions) == 0 {
+		fmt.Fprintf(io.Out, "No statics have been pushed for %s\n", appName)
+		return nil
+	}
+
+	rows := make([][]string, 0, len(versions))
+	for _, version := range versions {
+		liveMark := ""
+		if version.Live {
+			liveMark = "live"
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(version.Version),
+			strconv.Itoa(version.Objects),
+			humanize.Bytes(uint64(version.Bytes)),
+			liveMark,
+		})
+	}
+	return render.Table(io.Out, "", rows, "Version", "Objects", "Size", "Status")
+}
+
+// liveVersion returns the statics version served by the app's machines, or 0 if none of them serve
+// statics pushed by flyctl. If machines disagree, e.g. during a deploy, the newest version wins.
+func liveVersion(ctx context.Context, appName string) (int, error) {
+	flapsClient, err := flapsutil.NewClientWithOptions(ctx, flaps.NewClientOpts{
+		AppName: appName,
+	})
+	if err != nil {
+		return 0, err
+	}
+	machines, err := flapsClient.List(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+
+	prefix := statics.BucketPrefix(ctx, appName)
+	live := 0
+	for _, machine := range machines {
+		if machine.Config == nil {
+			continue
+		}
+		for _, static := range machine.Config.Statics {
+			if version, ok := statics.VersionFromGuestPath(prefix, static.GuestPath); ok {
+				live = max(live, version)
+			}
+		}
+	}
+	return live, nil
+}
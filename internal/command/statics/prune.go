@@ -0,0 +1,105 @@
+package statics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/deploy/statics"
+	"github.com/superfly/flyctl/internal/command/orgs"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyutil"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newPrune() *cobra.Command {
+	const (
+		short = "Delete the statics of old releases"
+		long  = `Delete the statics of old releases from an app's statics bucket, keeping
+only the statics of the latest releases.
+
+Pass --org to prune every app in an organization. Apps are pruned
+concurrently, and an app that fails or times out doesn't stop the others.`
+	)
+	cmd := command.New("prune", short, long, runPrune,
+		command.RequireSession,
+		command.LoadAppConfigIfPresent,
+	)
+	cmd.Args = cobra.NoArgs
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Org(),
+		flag.Int{
+			Name:        "concurrency",
+			Description: "Number of apps to prune at once when pruning an organization",
+			Default:     4,
+		},
+		flag.Duration{
+			Name:        "timeout",
+			Description: "Maximum time to spend pruning a single app",
+			Default:     5 * time.Minute,
+		},
+	)
+	return cmd
+}
+
+func runPrune(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+
+	appNames, err := pruneTargets(ctx)
+	if err != nil {
+		return err
+	}
+
+	results := statics.PruneApps(ctx, appNames, statics.PruneOptions{
+		Concurrency: flag.GetInt(ctx, "concurrency"),
+		Timeout:     flag.GetDuration(ctx, "timeout"),
+	})
+
+	rows := lo.Map(results, func(result statics.PruneResult, _ int) []string {
+		if result.Err != nil {
+			return []string{result.App, fmt.Sprintf("failed: %v", result.Err)}
+		}
+		return []string{result.App, "pruned"}
+	})
+	if err := render.Table(io.Out, "", rows, "App", "Result"); err != nil {
+		return err
+	}
+
+	if failed := lo.CountBy(results, func(result statics.PruneResult) bool { return result.Err != nil }); failed > 0 {
+		return fmt.Errorf("failed to prune statics for %d of %d app(s)", failed, len(results))
+	}
+	return nil
+}
+
+// pruneTargets returns every app in the --org organization, or the current app.
+func pruneTargets(ctx context.Context) ([]string, error) {
+	if flag.GetOrg(ctx) == "" {
+		appName := flag.GetApp(ctx)
+		if cfg := appconfig.ConfigFromContext(ctx); appName == "" && cfg != nil {
+			appName = cfg.AppName
+		}
+		if appName == "" {
+			return nil, command.ErrRequireAppName
+		}
+		return []string{appName}, nil
+	}
+
+	org, err := orgs.OrgFromFlagOrSelect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	apps, err := flyutil.ClientFromContext(ctx).GetAppsForOrganization(ctx, org.ID)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(apps, func(app fly.App, _ int) string { return app.Name }), nil
+}
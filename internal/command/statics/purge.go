@@ -0,0 +1,124 @@
+package statics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/deploy/statics"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newPurge() *cobra.Command {
+	const (
+		short = "Delete statics versions from an app's bucket"
+		long  = `Delete statics versions from an app's Tigris bucket.
+
+By default every version except the one the app's machines serve is deleted.
+Pass --version to delete a single version, or --all to delete every version,
+including the live one. Deleting the live version breaks the app's statics
+until they're pushed again.`
+	)
+	cmd := command.New("purge", short, long, runPurge,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+	cmd.Args = cobra.NoArgs
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Yes(),
+		flag.Int{
+			Name:        "version",
+			Description: "Only delete this statics version",
+		},
+		flag.Bool{
+			Name:        "all",
+			Description: "Delete every statics version, including the live one",
+		},
+	)
+	return cmd
+}
+
+func runPurge(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		appName = appconfig.NameFromContext(ctx)
+		version = flag.GetInt(ctx, "version")
+		all     = flag.GetBool(ctx, "all")
+	)
+
+	if version != 0 && all {
+		return errors.New("--version and --all can't be used together")
+	}
+
+	summaries, err := statics.ListVersions(ctx, appName)
+	if err != nil {
+		return err
+	}
+	existing := lo.Map(summaries, func(summary statics.VersionSummary, _ int) int { return summary.Version })
+
+	live, err := liveVersion(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	versions := selectPurgeVersions(existing, live, version, all)
+	if version != 0 && len(versions) == 0 {
+		return fmt.Errorf("statics version %d doesn't exist for %s", version, appName)
+	}
+	if len(versions) == 0 {
+		fmt.Fprintf(io.Out, "No statics versions to delete for %s\n", appName)
+		return nil
+	}
+
+	if !flag.GetYes(ctx) {
+		message := fmt.Sprintf("Delete statics version(s) %s of %s?", formatVersions(versions), appName)
+		if slices.Contains(versions, live) {
+			message = fmt.Sprintf("Delete statics version(s) %s of %s, including live version %d?", formatVersions(versions), appName, live)
+		}
+		switch confirmed, err := prompt.Confirm(ctx, message); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	deleted, err := statics.DeleteVersions(ctx, appName, versions)
+	fmt.Fprintf(io.Out, "Deleted %d object(s) from %d statics version(s)\n", deleted, len(versions))
+	return err
+}
+
+// selectPurgeVersions picks the versions to delete out of the existing ones: just the requested version,
+// every version with all, or otherwise every version except the live one.
+func selectPurgeVersions(existing []int, live, version int, all bool) []int {
+	switch {
+	case version != 0:
+		if slices.Contains(existing, version) {
+			return []int{version}
+		}
+		return nil
+	case all:
+		return existing
+	default:
+		return lo.Without(existing, live)
+	}
+}
+
+func formatVersions(versions []int) string {
+	return strings.Join(lo.Map(versions, func(version int, _ int) string { return strconv.Itoa(version) }), ", ")
+}
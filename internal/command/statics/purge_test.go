@@ -0,0 +1,19 @@
+package statics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectPurgeVersions(t *testing.T) {
+	existing := []int{3, 4, 5}
+
+	assert.Equal(t, []int{3, 5}, selectPurgeVersions(existing, 4, 0, false))
+	assert.Equal(t, []int{3, 4, 5}, selectPurgeVersions(existing, 4, 0, true))
+	assert.Equal(t, []int{4}, selectPurgeVersions(existing, 4, 4, false))
+	assert.Empty(t, selectPurgeVersions(existing, 4, 9, false))
+
+	// Without a live version, nothing is protected
+	assert.Equal(t, []int{3, 4, 5}, selectPurgeVersions(existing, 0, 0, false))
+}
@@ -0,0 +1,134 @@
+package statics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	fly "github.com/superfly/fly-go"
+	"github.com/superfly/fly-go/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/deploy/statics"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flapsutil"
+	"github.com/superfly/flyctl/internal/flyutil"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newPush() *cobra.Command {
+	const (
+		short = "Push an app's statics without deploying"
+		long  = `Push the statics configured in fly.toml to the app's Tigris bucket, without
+deploying a new release. The bucket is created if the app doesn't have one yet.
+
+The statics are pushed under a new version, then the app's machines are updated
+to serve them. Only statics with a relative guest_path are pushed.`
+	)
+	cmd := command.New("push", short, long, runPush,
+		command.RequireSession,
+		command.RequireAppName,
+		command.LoadAppConfigIfPresent,
+	)
+	cmd.Args = cobra.NoArgs
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+	)
+	return cmd
+}
+
+func runPush(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+	client := flyutil.ClientFromContext(ctx)
+	appName := appconfig.NameFromContext(ctx)
+
+	appConfig := appconfig.ConfigFromContext(ctx)
+	if appConfig == nil {
+		return errors.New("pushing statics requires a fly.toml, pass its path with --config")
+	}
+	if !lo.SomeBy(appConfig.Statics, func(static appconfig.Static) bool {
+		return statics.StaticIsCandidateForTigrisPush(static, false)
+	}) {
+		return errors.New("no statics to push, only [[statics]] with a relative guest_path are pushed to Tigris")
+	}
+	appConfig.AppName = appName
+
+	app, err := client.GetApp(ctx, appName)
+	if err != nil {
+		return err
+	}
+	org, err := client.GetOrganizationBySlug(ctx, app.Organization.Slug)
+	if err != nil {
+		return err
+	}
+
+	releaseVersion := 0
+	if app.CurrentRelease != nil {
+		releaseVersion = app.CurrentRelease.Version
+	}
+
+	deployer := statics.Deployer(appConfig, app, org, releaseVersion, statics.Options{})
+	if err := deployer.Configure(ctx); err != nil {
+		return err
+	}
+	if err := deployer.UseNextVersion(ctx); err != nil {
+		return err
+	}
+	if err := deployer.Push(ctx); err != nil {
+		return err
+	}
+
+	// The new statics are kept if this fails, since some machines may already be serving them.
+	if err := updateMachineStatics(ctx, appName, appConfig.MachineStatics()); err != nil {
+		return fmt.Errorf("statics version %d was pushed, but the app's machines couldn't be updated to serve it: %w", deployer.Version(), err)
+	}
+
+	if err := deployer.Finalize(ctx); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Pushed statics version %d for %s\n", deployer.Version(), appName)
+	return nil
+}
+
+// updateMachineStatics points every active machine of the app at the given statics.
+func updateMachineStatics(ctx context.Context, appName string, machineStatics []*fly.Static) error {
+	flapsClient, err := flapsutil.NewClientWithOptions(ctx, flaps.NewClientOpts{
+		AppName: appName,
+	})
+	if err != nil {
+		return err
+	}
+	ctx = flapsutil.NewContextWithClient(ctx, flapsClient)
+
+	machines, err := mach.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+	if len(machines) == 0 {
+		return nil
+	}
+
+	machines, releaseFunc, err := mach.AcquireLeases(ctx, machines)
+	defer releaseFunc()
+	if err != nil {
+		return err
+	}
+
+	for _, machine := range machines {
+		machine.Config.Statics = machineStatics
+		input := &fly.LaunchMachineInput{
+			Name:   machine.Name,
+			Region: machine.Region,
+			Config: machine.Config,
+		}
+		if err := mach.Update(ctx, machine, input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+// Package statics implements the statics command chain.
+package statics
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+)
+
+// New initializes and returns a new statics Command.
+func New() (cmd *cobra.Command) {
+	const (
+		short = "Manage an app's statics"
+		long  = `The STATICS commands manage the static files that are pushed to Tigris
+when an app is deployed.`
+	)
+	cmd = command.New("statics", short, long, nil)
+
+	cmd.AddCommand(
+		newDownload(),
+		newList(),
+		newPrune(),
+		newPurge(),
+		newPush(),
+	)
+	return
+}
@@ -2,8 +2,11 @@ package machine
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sourcegraph/conc/pool"
@@ -58,6 +61,63 @@ func AcquireLeases(ctx context.Context, machines []*fly.Machine) ([]*fly.Machine
 	return leaseHoldingMachines, releaseFunc, err
 }
 
+// AcquireLeasesWithTimeout is AcquireLeases, giving up on the machines whose lease isn't acquired
+// within timeout. The error names every machine that couldn't be leased in time. The leases that
+// were acquired are still returned, so they can be released. A timeout of 0 waits indefinitely.
+func AcquireLeasesWithTimeout(ctx context.Context, machines []*fly.Machine, timeout time.Duration) ([]*fly.Machine, releaseLeaseFunc, error) {
+	if timeout <= 0 {
+		return AcquireLeases(ctx, machines)
+	}
+
+	acquireCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var (
+		lock     sync.Mutex
+		timedOut []string
+	)
+	acquirePool := pool.NewWithResults[*fly.Machine]().
+		WithErrors().
+		WithMaxGoroutines(maxConcurrentLeases)
+
+	for _, m := range machines {
+		m := m
+		acquirePool.Go(func() (*fly.Machine, error) {
+			// Skip leasing for unreachable machines
+			if m.HostStatus != fly.HostStatusOk {
+				return m, nil
+			}
+
+			leased, _, err := AcquireLease(acquireCtx, m)
+			if err != nil && errors.Is(acquireCtx.Err(), context.DeadlineExceeded) {
+				lock.Lock()
+				timedOut = append(timedOut, m.ID)
+				lock.Unlock()
+				return nil, nil
+			}
+			return leased, err
+		})
+	}
+
+	leaseHoldingMachines, err := acquirePool.Wait()
+	leaseHoldingMachines = slices.DeleteFunc(leaseHoldingMachines, func(m *fly.Machine) bool { return m == nil })
+
+	releaseFunc := func() {
+		p := pool.New()
+		for _, m := range leaseHoldingMachines {
+			p.Go(func() { releaseLease(ctx, m) })
+		}
+		p.Wait()
+	}
+
+	if len(timedOut) > 0 {
+		slices.Sort(timedOut)
+		timeoutErr := fmt.Errorf("timed out after %s waiting for leases on %d of %d machines: %s", timeout, len(timedOut), len(machines), strings.Join(timedOut, ", "))
+		err = errors.Join(timeoutErr, err)
+	}
+	return leaseHoldingMachines, releaseFunc, err
+}
+
 func releaseLease(ctx context.Context, machine *fly.Machine) {
 	if machine == nil || machine.LeaseNonce == "" {
 		return
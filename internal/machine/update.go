@@ -18,6 +18,9 @@ var cpusPerKind = map[string][]int{
 	"performance": {1, 2, 4, 6, 8, 10, 12, 14, 16},
 }
 
+// GPUKinds are the GPU models machines can attach.
+var GPUKinds = []string{"a100-pcie-40gb", "a100-sxm4-80gb", "l40s", "a10"}
+
 func Update(ctx context.Context, m *fly.Machine, input *fly.LaunchMachineInput) error {
 	var (
 		flapsClient    = flapsutil.ClientFromContext(ctx)
@@ -28,53 +31,9 @@ func Update(ctx context.Context, m *fly.Machine, input *fly.LaunchMachineInput)
 	)
 
 	if input != nil && input.Config != nil && input.Config.Guest != nil {
-		var invalidConfigErr InvalidConfigErr
-		invalidConfigErr.guest = input.Config.Guest
-		// Check that there's a valid number of CPUs
-		validNumCpus, ok := cpusPerKind[input.Config.Guest.CPUKind]
-		if !ok {
-			invalidConfigErr.Reason = invalidCpuKind
-			return invalidConfigErr
-		} else if !slices.Contains(validNumCpus, input.Config.Guest.CPUs) {
-			invalidConfigErr.Reason = invalidNumCPUs
-			return invalidConfigErr
-		}
-
-		if input.Config.Guest.CPUKind == "shared" && input.Config.Guest.MemoryMB%256 != 0 {
-			invalidConfigErr.Reason = invalidMemorySize
-			return invalidConfigErr
-		} else if input.Config.Guest.CPUKind == "performance" && input.Config.Guest.MemoryMB%1024 != 0 {
-			invalidConfigErr.Reason = invalidMemorySize
-			return invalidConfigErr
-		}
-
-		// Check memory sizes
-		var min_memory_size int
-
-		if input.Config.Guest.CPUKind == "shared" {
-			min_memory_size = fly.MIN_MEMORY_MB_PER_SHARED_CPU * input.Config.Guest.CPUs
-		} else if input.Config.Guest.CPUKind == "performance" {
-			min_memory_size = fly.MIN_MEMORY_MB_PER_CPU * input.Config.Guest.CPUs
+		if err := ValidateGuest(input.Config.Guest); err != nil {
+			return err
 		}
-
-		if min_memory_size > input.Config.Guest.MemoryMB {
-			invalidConfigErr.Reason = memoryTooLow
-			return invalidConfigErr
-		}
-
-		var maxMemory int
-
-		if input.Config.Guest.CPUKind == "shared" {
-			maxMemory = input.Config.Guest.CPUs * fly.MAX_MEMORY_MB_PER_SHARED_CPU
-		} else if input.Config.Guest.CPUKind == "performance" {
-			maxMemory = input.Config.Guest.CPUs * fly.MAX_MEMORY_MB_PER_CPU
-		}
-
-		if input.Config.Guest.MemoryMB > maxMemory {
-			invalidConfigErr.Reason = memoryTooHigh
-			return invalidConfigErr
-		}
-
 	}
 
 	fmt.Fprintf(io.Out, "Updating machine %s\n", colorize.Bold(m.ID))
@@ -112,11 +71,88 @@ func Update(ctx context.Context, m *fly.Machine, input *fly.LaunchMachineInput)
 	return nil
 }
 
+// ValidateGuest checks that the CPU kind, number of CPUs and memory of guest make up a size
+// machines can run with. The returned InvalidConfigErr suggests a valid value.
+func ValidateGuest(guest *fly.MachineGuest) error {
+	var invalidConfigErr InvalidConfigErr
+	invalidConfigErr.guest = guest
+	// Check that there's a valid number of CPUs
+	validNumCpus, ok := cpusPerKind[guest.CPUKind]
+	if !ok {
+		invalidConfigErr.Reason = invalidCpuKind
+		return invalidConfigErr
+	} else if !slices.Contains(validNumCpus, guest.CPUs) {
+		invalidConfigErr.Reason = invalidNumCPUs
+		return invalidConfigErr
+	}
+
+	if guest.CPUKind == "shared" && guest.MemoryMB%256 != 0 {
+		invalidConfigErr.Reason = invalidMemorySize
+		return invalidConfigErr
+	} else if guest.CPUKind == "performance" && guest.MemoryMB%1024 != 0 {
+		invalidConfigErr.Reason = invalidMemorySize
+		return invalidConfigErr
+	}
+
+	// Check memory sizes
+	var min_memory_size int
+
+	if guest.CPUKind == "shared" {
+		min_memory_size = fly.MIN_MEMORY_MB_PER_SHARED_CPU * guest.CPUs
+	} else if guest.CPUKind == "performance" {
+		min_memory_size = fly.MIN_MEMORY_MB_PER_CPU * guest.CPUs
+	}
+
+	if min_memory_size > guest.MemoryMB {
+		invalidConfigErr.Reason = memoryTooLow
+		return invalidConfigErr
+	}
+
+	var maxMemory int
+
+	if guest.CPUKind == "shared" {
+		maxMemory = guest.CPUs * fly.MAX_MEMORY_MB_PER_SHARED_CPU
+	} else if guest.CPUKind == "performance" {
+		maxMemory = guest.CPUs * fly.MAX_MEMORY_MB_PER_CPU
+	}
+
+	if guest.MemoryMB > maxMemory {
+		invalidConfigErr.Reason = memoryTooHigh
+		return invalidConfigErr
+	}
+	return nil
+}
+
+// ValidateGPUs checks that the GPUs of guest, if it has any, are a supported model attached to
+// performance CPUs. It isn't part of ValidateGuest, so machines with GPU models that flyctl
+// doesn't know of yet can still be updated.
+func ValidateGPUs(guest *fly.MachineGuest) error {
+	if guest.GPUKind == "" && guest.GPUs == 0 {
+		return nil
+	}
+
+	invalidConfigErr := InvalidConfigErr{guest: guest}
+	switch {
+	case !slices.Contains(GPUKinds, guest.GPUKind):
+		invalidConfigErr.Reason = invalidGPUKind
+	case guest.GPUs < 1:
+		invalidConfigErr.Reason = invalidNumGPUs
+	case guest.CPUKind != "performance":
+		invalidConfigErr.Reason = gpuOnSharedCPU
+	default:
+		return nil
+	}
+	return invalidConfigErr
+}
+
 type invalidConfigReason string
 
 const (
 	invalidCpuKind    invalidConfigReason = "invalid CPU kind"
 	invalidNumCPUs    invalidConfigReason = "invalid number of CPUs"
+	invalidGPUKind    invalidConfigReason = "invalid GPU kind"
+	invalidNumGPUs    invalidConfigReason = "invalid number of GPUs"
+	gpuOnSharedCPU    invalidConfigReason = "GPUs require performance CPUs"
 	invalidMemorySize invalidConfigReason = "invalid memory size"
 	memoryTooLow      invalidConfigReason = "memory size for config is too low"
 	memoryTooHigh     invalidConfigReason = "memory size for config is too high"
@@ -133,6 +169,15 @@ func (e InvalidConfigErr) Description() string {
 		return fmt.Sprintf("The CPU kind given: %s, is not valid", e.guest.CPUKind)
 	case invalidNumCPUs:
 		return fmt.Sprintf("For the CPU kind %s, %d CPUs is not valid", e.guest.CPUKind, e.guest.CPUs)
+	case invalidGPUKind:
+		if e.guest.GPUKind == "" {
+			return fmt.Sprintf("%d GPUs were requested without a GPU kind", e.guest.GPUs)
+		}
+		return fmt.Sprintf("The GPU kind given: %s, is not valid", e.guest.GPUKind)
+	case invalidNumGPUs:
+		return fmt.Sprintf("For the GPU kind %s, %d GPUs is not valid", e.guest.GPUKind, e.guest.GPUs)
+	case gpuOnSharedCPU:
+		return fmt.Sprintf("GPUs can't be attached to %s CPUs", e.guest.CPUKind)
 	case invalidMemorySize:
 		return fmt.Sprintf("%dMiB of memory is not valid", e.guest.MemoryMB)
 	case memoryTooLow:
@@ -150,6 +195,12 @@ func (e InvalidConfigErr) Suggestion() string {
 	case invalidNumCPUs:
 		validNumCpus := cpusPerKind[e.guest.CPUKind]
 		return fmt.Sprintf("Valid numbers are %v", validNumCpus)
+	case invalidGPUKind:
+		return fmt.Sprintf("Valid values are %v", GPUKinds)
+	case invalidNumGPUs:
+		return "Attach at least one GPU"
+	case gpuOnSharedCPU:
+		return "Use performance CPUs, e.g. with a GPU size like a100-40gb"
 	case invalidMemorySize:
 		var incrementSize int = 1024
 		switch e.guest.CPUKind {